@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type AccountHandler struct {
+	AccountService *service.AccountService
+}
+
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		AccountService: accountService,
+	}
+}
+
+// ExportData downloads everything stored about the current user as JSON (default) or a ZIP bundle
+func (h *AccountHandler) ExportData(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		ErrorResponse(c, http.StatusBadRequest, "Invalid format, expected json or zip")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"format": format})
+
+	export, err := h.AccountService.ExportData(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "export_data")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to export data")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("pollex-export-%s.%s", export.ExportedAt.Format("2006-01-02"), format)
+
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.JSON(http.StatusOK, export)
+		logger.LogEnd(http.StatusOK)
+		return
+	}
+
+	// built in memory so a failure can still be reported as an error response
+	var buf bytes.Buffer
+	if err := export.WriteZip(&buf); err != nil {
+		logger.LogError(err, "write_zip")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to export data")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"bytes": buf.Len()})
+}
+
+// ScheduleDeletion schedules the current user's account for deletion after the grace period
+func (h *AccountHandler) ScheduleDeletion(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	sessionID, err := middleware.GetSessionID(c)
+	if err != nil {
+		logger.LogError(err, "get_session_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	var input service.AccountDeletionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"keep_votes": input.KeepVotes})
+
+	request, err := h.AccountService.ScheduleDeletion(c.Request.Context(), userID, sessionID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidCredentials):
+			logger.LogError(err, "invalid_password")
+			ErrorResponse(c, http.StatusUnauthorized, "Invalid password")
+			logger.LogEnd(http.StatusUnauthorized)
+		case errors.Is(err, util.ErrRecentSignInRequired):
+			logger.LogError(err, "recent_sign_in_required")
+			ErrorResponse(c, http.StatusForbidden, "Enter your password, or sign in again to confirm")
+			logger.LogEnd(http.StatusForbidden)
+		case errors.Is(err, util.ErrLastAdmin):
+			logger.LogError(err, "last_admin")
+			ErrorResponse(c, http.StatusConflict, "The last admin can't delete their account")
+			logger.LogEnd(http.StatusConflict)
+		default:
+			logger.LogError(err, "schedule_deletion")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to schedule account deletion")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("account deletion scheduled", "scheduled_for", request.ScheduledFor)
+
+	OkResponse(c, request)
+	logger.LogEnd(http.StatusOK)
+}
+
+// DeletionStatus returns the current user's pending deletion, if any
+func (h *AccountHandler) DeletionStatus(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	request, err := h.AccountService.GetDeletion(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, util.ErrDeletionNotScheduled) {
+			ErrorResponse(c, http.StatusNotFound, "Account deletion not scheduled")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "get_deletion")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to get account deletion status")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, request)
+	logger.LogEnd(http.StatusOK)
+}
+
+// CancelDeletion cancels the current user's pending deletion
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	if err := h.AccountService.CancelDeletion(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, util.ErrDeletionNotScheduled) {
+			logger.LogError(err, "not_scheduled")
+			ErrorResponse(c, http.StatusNotFound, "Account deletion not scheduled")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "cancel_deletion")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to cancel account deletion")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Logger().Info("account deletion cancelled")
+
+	OkResponse(c, gin.H{"message": "Account deletion cancelled"})
+	logger.LogEnd(http.StatusOK)
+}
+
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale" binding:"required"`
+}
+
+// UpdateLocale sets the language of the current user's emails
+func (h *AccountHandler) UpdateLocale(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+
+	var req UpdateLocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"locale": req.Locale})
+
+	locale, err := h.AccountService.UpdateLocale(c.Request.Context(), userID, req.Locale)
+	if err != nil {
+		if errors.Is(err, util.ErrUnsupportedLocale) {
+			logger.LogError(err, "unsupported_locale")
+			ErrorResponseWithData(c, http.StatusBadRequest, "Unsupported locale", gin.H{"locales": mailer.Locales})
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "update_locale")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to update locale")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"locale": locale})
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterAccountRoutes(r *gin.Engine, queries *repository.Queries, emailService *service.EmailService) {
+	handler := NewAccountHandler(service.NewAccountService(queries, emailService))
+
+	accountRoutes := r.Group("/user").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		accountRoutes.GET("/export", handler.ExportData)
+		accountRoutes.GET("/deletion", handler.DeletionStatus)
+		accountRoutes.POST("/deletion", handler.ScheduleDeletion)
+		accountRoutes.DELETE("/deletion", handler.CancelDeletion)
+		accountRoutes.PUT("/locale", handler.UpdateLocale)
+	}
+}
@@ -2,9 +2,10 @@ package controllers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -136,16 +137,20 @@ func convertAuditLogsByActionRow(row repository.ListAuditLogsByActionRow) AuditL
 }
 
 type AdminHandler struct {
-	AdminService *service.AdminService
-	AuditService *service.AuditService
-	Queries      *repository.Queries
+	AdminService         *service.AdminService
+	AuditService         *service.AuditService
+	SuspensionService    *service.SuspensionService
+	ImpersonationService *service.ImpersonationService
+	Queries              *repository.Queries
 }
 
-func NewAdminHandler(adminService *service.AdminService, auditService *service.AuditService, queries *repository.Queries) *AdminHandler {
+func NewAdminHandler(adminService *service.AdminService, auditService *service.AuditService, queries *repository.Queries, config *util.Config) *AdminHandler {
 	return &AdminHandler{
-		AdminService: adminService,
-		AuditService: auditService,
-		Queries:      queries,
+		AdminService:         adminService,
+		AuditService:         auditService,
+		SuspensionService:    service.NewSuspensionService(queries),
+		ImpersonationService: service.NewImpersonationService(queries, config),
+		Queries:              queries,
 	}
 }
 
@@ -181,11 +186,7 @@ func (h *AdminHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s Users listed | actor=%s%s%s | count=%s%d%s | total=%s%d%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, len(response.Users), util.ColorReset,
-		util.ColorYellow, response.Total, util.ColorReset)
+	logger.Logger().Info("users listed", "count", len(response.Users), "total", response.Total)
 
 	OkResponse(c, response)
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"user_count": len(response.Users)})
@@ -245,45 +246,41 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	// Validate role
-	var newRole repository.UserRole
-	switch req.Role {
-	case "user":
-		newRole = repository.UserRoleUser
-	case "admin":
-		newRole = repository.UserRoleAdmin
-	default:
-		ErrorResponse(c, http.StatusBadRequest, "Invalid role. Must be 'user' or 'admin'")
-		logger.LogEnd(http.StatusBadRequest)
-		return
-	}
-
 	logger.LogStart(map[string]interface{}{"target_user_id": userID.String(), "new_role": req.Role})
 
 	updatedUser, err := h.AdminService.UpdateUserRole(c.Request.Context(), service.UpdateUserRoleInput{
 		ActorUserID:  actorID,
 		TargetUserID: userID,
-		NewRole:      newRole,
+		NewRole:      req.Role,
 	})
 
 	if err != nil {
-		if err == util.ErrLastAdmin {
+		switch {
+		case errors.Is(err, util.ErrLastAdmin):
 			logger.LogError(err, "last_admin_protection")
 			ErrorResponse(c, http.StatusForbidden, "Cannot demote the last admin")
 			logger.LogEnd(http.StatusForbidden)
-			return
+		case errors.Is(err, util.ErrRoleNotFound):
+			logger.LogError(err, "unknown_role")
+			ErrorResponse(c, http.StatusBadRequest, "Unknown role")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrUserNotFound):
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+		case errors.Is(err, util.ErrInsufficientPerms):
+			logger.LogError(err, "insufficient_permissions")
+			ErrorResponse(c, http.StatusForbidden, "You can't assign or remove a role with permissions you don't have")
+			logger.LogEnd(http.StatusForbidden)
+		default:
+			logger.LogError(err, "update_role_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to update user role")
+			logger.LogEnd(http.StatusInternalServerError)
 		}
-		logger.LogError(err, "update_role_failed")
-		ErrorResponse(c, http.StatusInternalServerError, "Failed to update user role")
-		logger.LogEnd(http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s User role updated | actor=%s%s%s | target=%s%s%s | new_role=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, userID.String()[:8], util.ColorReset,
-		util.ColorYellow, req.Role, util.ColorReset)
+	logger.Logger().Info("user role updated", "target_user_id", userID.String(), "new_role", req.Role)
 
 	OkResponse(c, updatedUser)
 	logger.LogEnd(http.StatusOK)
@@ -330,17 +327,14 @@ func (h *AdminHandler) UpdateUserName(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s User name updated | actor=%s%s%s | target=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, userID.String()[:8], util.ColorReset)
+	logger.Logger().Info("user name updated", "target_user_id", userID.String())
 
 	OkResponse(c, updatedUser)
 	logger.LogEnd(http.StatusOK)
 }
 
 type ResetPasswordRequest struct {
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 // ResetUserPassword resets a user's password
@@ -360,7 +354,7 @@ func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.LogError(err, "bind_json")
-		ErrorResponse(c, http.StatusBadRequest, "Invalid request body. Password must be at least 6 characters")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
 		logger.LogEnd(http.StatusBadRequest)
 		return
 	}
@@ -374,16 +368,26 @@ func (h *AdminHandler) ResetUserPassword(c *gin.Context) {
 	})
 
 	if err != nil {
+		var policyErr *util.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			logger.LogError(err, "password_policy")
+			PasswordPolicyErrorResponse(c, policyErr)
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		if err == util.ErrUserNotFound {
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
 		logger.LogError(err, "reset_password_failed")
 		ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
 		logger.LogEnd(http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s User password reset | actor=%s%s%s | target=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, userID.String()[:8], util.ColorReset)
+	logger.Logger().Info("user password reset", "target_user_id", userID.String())
 
 	OkResponse(c, gin.H{"message": "Password reset successfully"})
 	logger.LogEnd(http.StatusOK)
@@ -430,15 +434,418 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s User deleted | actor=%s%s%s | target=%s%s%s",
-		util.ColorRed+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, userID.String()[:8], util.ColorReset)
+	logger.Logger().Info("user deleted", "target_user_id", userID.String())
 
 	OkResponse(c, gin.H{"message": "User deleted successfully"})
 	logger.LogEnd(http.StatusOK)
 }
 
+// ForceLogoutUser revokes all sessions of a user
+func (h *AdminHandler) ForceLogoutUser(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String()})
+
+	revoked, err := h.AdminService.ForceLogout(c.Request.Context(), service.ForceLogoutInput{
+		ActorUserID:  actorID,
+		TargetUserID: userID,
+	})
+
+	if err != nil {
+		if err == util.ErrUserNotFound {
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "force_logout_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to log out user")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Logger().Info("user logged out everywhere", "target_user_id", userID.String(), "revoked_sessions", revoked)
+
+	OkResponse(c, gin.H{"message": "User logged out of all sessions", "revoked": revoked})
+	logger.LogEnd(http.StatusOK)
+}
+
+// GetUserSuspension returns a user's current suspension
+func (h *AdminHandler) GetUserSuspension(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String()})
+
+	suspension, err := h.SuspensionService.GetSuspension(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, util.ErrNotSuspended) {
+			ErrorResponse(c, http.StatusNotFound, "User is not suspended")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "get_suspension_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to get suspension")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, suspension)
+	logger.LogEnd(http.StatusOK)
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// omit for a permanent ban
+	Until *time.Time `json:"until"`
+}
+
+// SuspendUser suspends a user until the given time, or permanently, and signs them out
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String(), "until": req.Until})
+
+	suspension, err := h.SuspensionService.SuspendUser(c.Request.Context(), service.SuspendUserInput{
+		ActorUserID:  actorID,
+		TargetUserID: userID,
+		Reason:       req.Reason,
+		Until:        req.Until,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidInput):
+			logger.LogError(err, "invalid_suspension")
+			ErrorResponse(c, http.StatusBadRequest, "A reason (up to 500 characters) is required and the end date must be in the future")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrCannotSuspendSelf):
+			logger.LogError(err, "suspend_self")
+			ErrorResponse(c, http.StatusBadRequest, "You can't suspend your own account")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrUserNotFound):
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+		case errors.Is(err, util.ErrInsufficientPerms):
+			logger.LogError(err, "insufficient_permissions")
+			ErrorResponse(c, http.StatusForbidden, "You can't suspend a user with permissions you don't have")
+			logger.LogEnd(http.StatusForbidden)
+		default:
+			logger.LogError(err, "suspend_user_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to suspend user")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("user suspended", "target_user_id", userID.String(), "until", req.Until)
+
+	OkResponse(c, suspension)
+	logger.LogEnd(http.StatusOK)
+}
+
+// UnsuspendUser lifts a user's suspension
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String()})
+
+	err = h.SuspensionService.UnsuspendUser(c.Request.Context(), service.UnsuspendUserInput{
+		ActorUserID:  actorID,
+		TargetUserID: userID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrNotSuspended):
+			logger.LogError(err, "not_suspended")
+			ErrorResponse(c, http.StatusNotFound, "User is not suspended")
+			logger.LogEnd(http.StatusNotFound)
+		case errors.Is(err, util.ErrUserNotFound):
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+		case errors.Is(err, util.ErrInsufficientPerms):
+			logger.LogError(err, "insufficient_permissions")
+			ErrorResponse(c, http.StatusForbidden, "You can't unsuspend a user with permissions you don't have")
+			logger.LogEnd(http.StatusForbidden)
+		default:
+			logger.LogError(err, "unsuspend_user_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to unsuspend user")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("user unsuspended", "target_user_id", userID.String())
+
+	OkResponse(c, gin.H{"message": "Suspension lifted"})
+	logger.LogEnd(http.StatusOK)
+}
+
+type ImpersonateUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// defaults to 15, at most 60
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+// ImpersonateUser starts a time-boxed session as another user. The access token is returned
+// in the body rather than as cookies so the admin's own session is kept; send it as a Bearer
+// token and end it with DELETE /impersonation.
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	var req ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String(), "duration_minutes": req.DurationMinutes})
+
+	impersonation, err := h.ImpersonationService.StartImpersonation(c.Request.Context(), service.StartImpersonationInput{
+		ActorUserID:  actorID,
+		TargetUserID: userID,
+		Reason:       req.Reason,
+		Duration:     time.Duration(req.DurationMinutes) * time.Minute,
+		Meta: service.SessionMeta{
+			UserAgent: c.Request.UserAgent(),
+			IPAddress: c.ClientIP(),
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidInput):
+			logger.LogError(err, "invalid_impersonation")
+			ErrorResponse(c, http.StatusBadRequest, "A reason (up to 500 characters) is required and the duration must be at most 60 minutes")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrCannotImpersonate):
+			logger.LogError(err, "cannot_impersonate")
+			ErrorResponse(c, http.StatusForbidden, "Admins can't be impersonated")
+			logger.LogEnd(http.StatusForbidden)
+		case errors.Is(err, util.ErrUserNotFound):
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+		default:
+			logger.LogError(err, "impersonate_user_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to impersonate user")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("impersonation started",
+		"target_user_id", userID.String(),
+		"session_id", impersonation.SessionID.String(),
+		"expires_at", impersonation.ExpiresAt,
+	)
+
+	OkResponse(c, impersonation)
+	logger.LogEnd(http.StatusOK)
+}
+
+// EndImpersonation ends the impersonation session the request was made with
+func (h *AdminHandler) EndImpersonation(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	userID, _ := middleware.GetUserID(c)
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	impersonatorID, ok := middleware.GetImpersonatorID(c)
+	if !ok {
+		ErrorResponse(c, http.StatusBadRequest, "Not impersonating a user")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+	sessionID, _ := middleware.GetSessionID(c)
+
+	if err := h.ImpersonationService.EndImpersonation(c.Request.Context(), impersonatorID, userID, sessionID); err != nil {
+		if errors.Is(err, util.ErrNotImpersonating) {
+			ErrorResponse(c, http.StatusBadRequest, "Not impersonating a user")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "end_impersonation_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to end impersonation")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Logger().Info("impersonation ended", "impersonator_id", impersonatorID.String(), "session_id", sessionID.String())
+
+	OkResponse(c, gin.H{"message": "Impersonation ended"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// ListLockouts returns locked accounts and accounts with recent failed logins
+func (h *AdminHandler) ListLockouts(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+	logger.LogStart()
+
+	// Parse pagination parameters
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100 // Cap at 100
+	}
+	if limit < 1 {
+		limit = 50
+	}
+
+	lockouts, err := h.AdminService.ListLockouts(c.Request.Context(), service.ListLockoutsInput{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		logger.LogError(err, "list_lockouts_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve lockouts")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{
+		"lockouts": lockouts,
+		"limit":    limit,
+		"offset":   offset,
+	})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"lockout_count": len(lockouts)})
+}
+
+// GetUserLockout returns a user's lockout status and recent login attempts
+func (h *AdminHandler) GetUserLockout(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String()})
+
+	status, err := h.AdminService.GetLockout(c.Request.Context(), userID)
+	if err != nil {
+		if err == util.ErrUserNotFound {
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "get_lockout_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve lockout status")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, status)
+	logger.LogEnd(http.StatusOK)
+}
+
+// ClearUserLockout unlocks a user's account and resets failed login tracking
+func (h *AdminHandler) ClearUserLockout(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"target_user_id": userID.String()})
+
+	err = h.AdminService.ClearLockout(c.Request.Context(), service.ClearLockoutInput{
+		ActorUserID:  actorID,
+		TargetUserID: userID,
+	})
+	if err != nil {
+		if err == util.ErrUserNotFound {
+			logger.LogError(err, "user_not_found")
+			ErrorResponse(c, http.StatusNotFound, "User not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "clear_lockout_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to clear lockout")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Logger().Info("lockout cleared", "target_user_id", userID.String())
+
+	OkResponse(c, gin.H{"message": "Lockout cleared"})
+	logger.LogEnd(http.StatusOK)
+}
+
 // ToggleEmailVerification toggles email verification status for a user
 func (h *AdminHandler) ToggleEmailVerification(c *gin.Context) {
 	logger := util.NewRequestLogger(c)
@@ -492,10 +899,7 @@ func (h *AdminHandler) ToggleEmailVerification(c *gin.Context) {
 		action = "unverified"
 	}
 
-	log.Printf("%s[ADMIN]%s Email %s | actor=%s%s%s | target=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset, action,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, userID.String()[:8], util.ColorReset)
+	logger.Logger().Info("email verification updated", "target_user_id", userID.String(), "status", action)
 
 	OkResponse(c, gin.H{"message": "Email verification status updated successfully"})
 	logger.LogEnd(http.StatusOK)
@@ -541,11 +945,7 @@ func (h *AdminHandler) ListPolls(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s Polls listed | actor=%s%s%s | count=%s%d%s | total=%s%d%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, len(response.Polls), util.ColorReset,
-		util.ColorYellow, response.Total, util.ColorReset)
+	logger.Logger().Info("polls listed", "count", len(response.Polls), "total", response.Total)
 
 	OkResponse(c, response)
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"poll_count": len(response.Polls)})
@@ -579,10 +979,7 @@ func (h *AdminHandler) ClosePoll(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s Poll closed | actor=%s%s%s | poll=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, pollID.String()[:8], util.ColorReset)
+	logger.Logger().Info("poll closed", "poll_id", pollID.String())
 
 	OkResponse(c, poll)
 	logger.LogEnd(http.StatusOK)
@@ -616,10 +1013,7 @@ func (h *AdminHandler) ReopenPoll(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s Poll reopened | actor=%s%s%s | poll=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, pollID.String()[:8], util.ColorReset)
+	logger.Logger().Info("poll reopened", "poll_id", pollID.String())
 
 	OkResponse(c, poll)
 	logger.LogEnd(http.StatusOK)
@@ -653,10 +1047,7 @@ func (h *AdminHandler) DeletePoll(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[ADMIN]%s Poll deleted | actor=%s%s%s | poll=%s%s%s",
-		util.ColorRed+util.ColorBold, util.ColorReset,
-		util.ColorCyan, actorID.String()[:8], util.ColorReset,
-		util.ColorMagenta, pollID.String()[:8], util.ColorReset)
+	logger.Logger().Info("poll deleted", "poll_id", pollID.String())
 
 	OkResponse(c, gin.H{"message": "Poll deleted successfully"})
 	logger.LogEnd(http.StatusOK)
@@ -756,32 +1147,45 @@ func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
 func RegisterAdminRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config) {
 	auditService := service.NewAuditService(queries)
 	adminService := service.NewAdminService(queries, auditService)
-	handler := NewAdminHandler(adminService, auditService, queries)
+	handler := NewAdminHandler(adminService, auditService, queries, config)
 
-	// Admin routes - all require authentication + admin role
-	adminRoutes := r.Group("/admin").Use(middleware.AuthMiddleware()).Use(middleware.RequireAdmin(queries))
+	// Admin routes - each route requires its own permission
+	adminRoutes := r.Group("/admin").Use(middleware.AuthMiddleware(queries)).Use(middleware.DenyImpersonation()).Use(middleware.RequireScope(service.ScopeAdmin)).Use(middleware.RequireTwoFactor(queries))
 	{
 		// User management
-		adminRoutes.GET("/users", handler.ListUsers)
-		adminRoutes.GET("/users/:id", handler.GetUser)
-		adminRoutes.PUT("/users/:id/role", handler.UpdateUserRole)
-		adminRoutes.PUT("/users/:id/name", handler.UpdateUserName)
-		adminRoutes.PUT("/users/:id/verification", handler.ToggleEmailVerification)
-		adminRoutes.POST("/users/:id/reset-password", handler.ResetUserPassword)
-		adminRoutes.DELETE("/users/:id", handler.DeleteUser)
+		adminRoutes.GET("/users", middleware.RequirePermission(queries, service.PermissionUserRead), handler.ListUsers)
+		adminRoutes.GET("/users/:id", middleware.RequirePermission(queries, service.PermissionUserRead), handler.GetUser)
+		adminRoutes.PUT("/users/:id/role", middleware.RequirePermission(queries, service.PermissionUserRoleAssign), handler.UpdateUserRole)
+		adminRoutes.PUT("/users/:id/name", middleware.RequirePermission(queries, service.PermissionUserUpdate), handler.UpdateUserName)
+		adminRoutes.PUT("/users/:id/verification", middleware.RequirePermission(queries, service.PermissionUserUpdate), handler.ToggleEmailVerification)
+		adminRoutes.POST("/users/:id/reset-password", middleware.RequirePermission(queries, service.PermissionUserPasswordReset), handler.ResetUserPassword)
+		adminRoutes.POST("/users/:id/logout", middleware.RequirePermission(queries, service.PermissionUserLogout), handler.ForceLogoutUser)
+		adminRoutes.GET("/users/:id/lockout", middleware.RequirePermission(queries, service.PermissionUserRead), handler.GetUserLockout)
+		adminRoutes.DELETE("/users/:id/lockout", middleware.RequirePermission(queries, service.PermissionUserUnlock), handler.ClearUserLockout)
+		adminRoutes.GET("/users/:id/suspension", middleware.RequirePermission(queries, service.PermissionUserRead), handler.GetUserSuspension)
+		adminRoutes.POST("/users/:id/suspension", middleware.RequirePermission(queries, service.PermissionUserSuspend), handler.SuspendUser)
+		adminRoutes.DELETE("/users/:id/suspension", middleware.RequirePermission(queries, service.PermissionUserSuspend), handler.UnsuspendUser)
+		adminRoutes.POST("/users/:id/impersonate", middleware.RequireSessionAuth(), middleware.RequirePermission(queries, service.PermissionUserImpersonate), handler.ImpersonateUser)
+		adminRoutes.DELETE("/users/:id", middleware.RequirePermission(queries, service.PermissionUserDelete), handler.DeleteUser)
 
 		// Poll management
-		adminRoutes.GET("/polls", handler.ListPolls)
-		adminRoutes.POST("/polls/:id/close", handler.ClosePoll)
-		adminRoutes.POST("/polls/:id/reopen", handler.ReopenPoll)
-		adminRoutes.DELETE("/polls/:id", handler.DeletePoll)
+		adminRoutes.GET("/polls", middleware.RequirePermission(queries, service.PermissionPollReadAny), handler.ListPolls)
+		adminRoutes.POST("/polls/:id/close", middleware.RequirePermission(queries, service.PermissionPollCloseAny), handler.ClosePoll)
+		adminRoutes.POST("/polls/:id/reopen", middleware.RequirePermission(queries, service.PermissionPollCloseAny), handler.ReopenPoll)
+		adminRoutes.DELETE("/polls/:id", middleware.RequirePermission(queries, service.PermissionPollDeleteAny), handler.DeletePoll)
+
+		// Login lockouts
+		adminRoutes.GET("/lockouts", middleware.RequirePermission(queries, service.PermissionUserRead), handler.ListLockouts)
 
 		// Audit logs
-		adminRoutes.GET("/audit", handler.ListAuditLogs)
+		adminRoutes.GET("/audit", middleware.RequirePermission(queries, service.PermissionAuditRead), handler.ListAuditLogs)
 
 		// Test endpoint for debugging audit logs
-		adminRoutes.POST("/test-audit", handler.TestAudit)
+		adminRoutes.POST("/test-audit", middleware.RequirePermission(queries, service.PermissionAuditWrite), handler.TestAudit)
 	}
+
+	// called with the impersonation token, so it sits outside the admin group
+	r.DELETE("/impersonation", middleware.AuthMiddleware(queries), handler.EndImpersonation)
 }
 
 // TestAudit is a test endpoint to verify audit logging works
@@ -791,8 +1195,6 @@ func (h *AdminHandler) TestAudit(c *gin.Context) {
 	logger.SetUserID(actorID)
 	logger.LogStart()
 
-	log.Printf("[TEST] Testing audit log creation for user: %s", actorID)
-
 	// Try to create a test audit log
 	err := h.AuditService.LogUserAction(
 		c.Request.Context(),
@@ -802,13 +1204,12 @@ func (h *AdminHandler) TestAudit(c *gin.Context) {
 	)
 
 	if err != nil {
-		log.Printf("[TEST] Failed to create audit log: %v", err)
 		ErrorResponse(c, http.StatusInternalServerError, "Failed to create audit log: "+err.Error())
 		logger.LogEnd(http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("[TEST] Successfully created test audit log")
+	logger.Logger().Debug("test audit log created")
 	OkResponse(c, gin.H{"message": "Test audit log created successfully"})
 	logger.LogEnd(http.StatusOK)
 }
@@ -2,12 +2,16 @@ package controllers
 
 import (
 	"errors"
-	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
@@ -16,17 +20,32 @@ type AuthHandler struct {
 	Queries      *repository.Queries
 	TokenService *service.TokenService
 	AuthService  *service.AuthService
+	RoleService  *service.RoleService
+}
+
+// ProfileResponse is the current user with the permissions their role grants
+type ProfileResponse struct {
+	repository.GetUserByIDRow
+	Permissions []string `json:"permissions"`
+	// set while an admin is impersonating the user
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty"`
 }
 
 type LoginResponse struct {
 	Message string `json:"message"`
+	// set when the account has 2FA; send it with a code to /auth/login/2fa
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+	// set when the account's role requires 2FA but it hasn't been set up
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
 }
 
-func NewAuthHandler(queries *repository.Queries, tokenService *service.TokenService, service *service.AuthService) *AuthHandler {
+func NewAuthHandler(queries *repository.Queries, tokenService *service.TokenService, authService *service.AuthService) *AuthHandler {
 	return &AuthHandler{
 		Queries:      queries,
 		TokenService: tokenService,
-		AuthService:  service,
+		AuthService:  authService,
+		RoleService:  service.NewRoleService(queries),
 	}
 }
 
@@ -44,8 +63,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	logger.LogStart(map[string]interface{}{"email": input.Email})
 
-	token, err := h.AuthService.Login(c, input)
+	result, err := h.AuthService.Login(c, input)
 	if err != nil {
+		var blocked *service.LoginBlockedError
+		if errors.As(err, &blocked) {
+			logger.LogError(err, "login_blocked")
+			respondLoginBlocked(c, blocked)
+			logger.LogEnd(http.StatusTooManyRequests)
+			return
+		}
+		var suspended *service.AccountSuspendedError
+		if errors.As(err, &suspended) {
+			logger.LogError(err, "account_suspended")
+			respondSuspended(c, suspended)
+			logger.LogEnd(http.StatusForbidden)
+			return
+		}
 		if errors.Is(err, util.ErrInvalidCredentials) {
 			logger.LogError(err, "invalid_credentials")
 			ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
@@ -58,28 +91,322 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	config := util.NewConfig()
-	c.SetCookie(
-		"pollex.session", token, 900*4, "/", config.CookieDomain, config.CookieSecure, true)
+	if result.MFAToken != "" {
+		logger.Logger().Info("login requires second factor", "email", input.Email)
+		c.JSON(http.StatusOK, LoginResponse{
+			Message:     "two-factor code required",
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		logger.LogEnd(http.StatusOK)
+		return
+	}
+
+	setAuthCookies(c, h.AuthService.Config, result.Tokens)
+
+	logger.Logger().Info("login successful", "email", input.Email)
+	c.JSON(http.StatusOK, LoginResponse{
+		Message:                "logged in!",
+		TwoFactorSetupRequired: result.TwoFactorSetupRequired,
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+// respondLoginBlocked answers a throttled or locked login with 429 and Retry-After
+func respondLoginBlocked(c *gin.Context, blocked *service.LoginBlockedError) {
+	retryAfter := int(math.Ceil(blocked.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	if errors.Is(blocked, util.ErrAccountLocked) {
+		ErrorResponseWithData(c, http.StatusTooManyRequests,
+			"Account temporarily locked after too many failed logins. Check your email to unlock it",
+			gin.H{"reason": "account_locked", "retry_after": retryAfter})
+		return
+	}
+
+	ErrorResponseWithData(c, http.StatusTooManyRequests, "Too many login attempts, please try again later",
+		gin.H{"reason": "too_many_attempts", "retry_after": retryAfter})
+}
+
+// respondSuspended answers a login by a suspended user with 403 and the suspension details
+func respondSuspended(c *gin.Context, suspended *service.AccountSuspendedError) {
+	ErrorResponseWithData(c, http.StatusForbidden, "Your account is suspended", gin.H{
+		"reason":            "account_suspended",
+		"suspension_reason": suspended.Reason,
+		"suspended_until":   suspended.Until,
+	})
+}
+
+// UnlockAccount endpoint - clears a lockout with the token from the lockout email
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	if err := h.AuthService.LoginProtection.Unlock(c.Request.Context(), input.Token); err != nil {
+		if errors.Is(err, util.ErrInvalidUnlockToken) {
+			logger.LogError(err, "invalid_unlock_token")
+			ErrorResponse(c, http.StatusBadRequest, "Invalid or expired unlock link")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "unlock_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock account")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Account unlocked"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// LoginTwoFactor endpoint - second login step for accounts with 2FA
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	var input service.TwoFactorLoginInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	tokens, err := h.AuthService.CompleteTwoFactorLogin(c, input)
+	if err != nil {
+		var suspended *service.AccountSuspendedError
+		var blocked *service.LoginBlockedError
+		switch {
+		case errors.As(err, &suspended):
+			logger.LogError(err, "account_suspended")
+			respondSuspended(c, suspended)
+			logger.LogEnd(http.StatusForbidden)
+		case errors.As(err, &blocked):
+			logger.LogError(err, "login_blocked")
+			respondLoginBlocked(c, blocked)
+			logger.LogEnd(http.StatusTooManyRequests)
+		case errors.Is(err, util.ErrTooManyTwoFactorCodes):
+			logger.LogError(err, "mfa_token_exhausted")
+			ErrorResponse(c, http.StatusUnauthorized, "Too many invalid codes, please sign in again")
+			logger.LogEnd(http.StatusUnauthorized)
+		case errors.Is(err, util.ErrInvalidCredentials):
+			logger.LogError(err, "invalid_mfa_token")
+			ErrorResponse(c, http.StatusUnauthorized, "Login expired, please sign in again")
+			logger.LogEnd(http.StatusUnauthorized)
+		case errors.Is(err, util.ErrInvalidTwoFactorCode), errors.Is(err, util.ErrTwoFactorNotEnabled):
+			logger.LogError(err, "invalid_two_factor_code")
+			ErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code")
+			logger.LogEnd(http.StatusUnauthorized)
+		default:
+			logger.LogError(err, "auth_service_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Authentication failed")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	setAuthCookies(c, h.AuthService.Config, tokens)
 
-	log.Printf("%s[AUTH]%s Login successful | email=%s%s%s", util.ColorGreen+util.ColorBold, util.ColorReset, util.ColorCyan, input.Email, util.ColorReset)
+	logger.Logger().Info("two-factor login successful")
 	c.JSON(http.StatusOK, LoginResponse{Message: "logged in!"})
 	logger.LogEnd(http.StatusOK)
 }
 
+// Refresh endpoint - exchanges the refresh cookie for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	logger.LogStart()
+
+	refreshToken, err := c.Cookie(refreshCookieName)
+	if err != nil || refreshToken == "" {
+		logger.LogError(util.ErrInvalidRefresh, "missing_refresh_cookie")
+		ErrorResponse(c, http.StatusUnauthorized, "Missing refresh token")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.AuthService.RefreshTokens(c.Request.Context(), refreshToken, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidRefresh) || errors.Is(err, util.ErrRefreshTokenReused) {
+			logger.LogError(err, "invalid_refresh_token")
+			clearAuthCookies(c, h.AuthService.Config)
+			ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+			logger.LogEnd(http.StatusUnauthorized)
+			return
+		}
+		var suspended *service.AccountSuspendedError
+		if errors.As(err, &suspended) {
+			logger.LogError(err, "account_suspended")
+			clearAuthCookies(c, h.AuthService.Config)
+			respondSuspended(c, suspended)
+			logger.LogEnd(http.StatusForbidden)
+			return
+		}
+		logger.LogError(err, "refresh_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh session")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	setAuthCookies(c, h.AuthService.Config, tokens)
+
+	c.JSON(http.StatusOK, gin.H{"message": "refreshed"})
+	logger.LogEnd(http.StatusOK)
+}
+
 func (h *AuthHandler) Logout(c *gin.Context) {
 	logger := util.NewRequestLogger(c)
 	logger.LogStart()
 
-	config := util.NewConfig()
-	// clear cookie
-	c.SetCookie(
-		"pollex.session", "", -1, "/", config.CookieDomain, config.CookieSecure, true)
+	// revoke the refresh token family so the session can't be renewed
+	if refreshToken, err := c.Cookie(refreshCookieName); err == nil && refreshToken != "" {
+		if err := h.AuthService.RevokeRefreshToken(c.Request.Context(), refreshToken); err != nil && !errors.Is(err, util.ErrInvalidRefresh) {
+			logger.LogError(err, "revoke_refresh_token")
+		}
+	}
+
+	clearAuthCookies(c, h.AuthService.Config)
 
 	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
 	logger.LogEnd(http.StatusOK)
 }
 
+// RequestMagicLink endpoint - emails a one-time sign-in link
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	var input service.MagicLinkRequestInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid email address")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"email": input.Email})
+
+	browserToken, _ := c.Cookie(magicLinkCookieName)
+	browserToken, err := h.AuthService.RequestMagicLink(c.Request.Context(), input, browserToken)
+	if err != nil {
+		logger.LogError(err, "send_magic_link")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to send sign-in link")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	// binds the link to this browser
+	c.SetSameSite(h.AuthService.Config.CookieSameSite)
+	c.SetCookie(magicLinkCookieName, browserToken, int(service.MagicLinkLifespan.Seconds()),
+		magicLinkCookiePath, h.AuthService.Config.CookieDomain, h.AuthService.Config.CookieSecure, true)
+
+	OkResponse(c, gin.H{
+		"message": "If an account exists with this email, a sign-in link has been sent",
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+// MagicLinkLogin endpoint - signs in with the token from a magic link
+func (h *AuthHandler) MagicLinkLogin(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	var input service.MagicLinkLoginInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	browserToken, _ := c.Cookie(magicLinkCookieName)
+	result, err := h.AuthService.CompleteMagicLinkLogin(c, input, browserToken)
+	if err != nil {
+		var suspended *service.AccountSuspendedError
+		switch {
+		case errors.As(err, &suspended):
+			logger.LogError(err, "account_suspended")
+			respondSuspended(c, suspended)
+			logger.LogEnd(http.StatusForbidden)
+		case errors.Is(err, util.ErrInvalidMagicLink):
+			logger.LogError(err, "invalid_magic_link")
+			ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired sign-in link")
+			logger.LogEnd(http.StatusUnauthorized)
+		case errors.Is(err, util.ErrMagicLinkBrowserMismatch):
+			logger.LogError(err, "magic_link_browser_mismatch")
+			ErrorResponseWithData(c, http.StatusUnauthorized, "Open the sign-in link in the browser where you requested it",
+				gin.H{"reason": "browser_mismatch"})
+			logger.LogEnd(http.StatusUnauthorized)
+		default:
+			logger.LogError(err, "auth_service_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Authentication failed")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.SetSameSite(h.AuthService.Config.CookieSameSite)
+	c.SetCookie(magicLinkCookieName, "", -1, magicLinkCookiePath,
+		h.AuthService.Config.CookieDomain, h.AuthService.Config.CookieSecure, true)
+
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, LoginResponse{
+			Message:     "two-factor code required",
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		logger.LogEnd(http.StatusOK)
+		return
+	}
+
+	setAuthCookies(c, h.AuthService.Config, result.Tokens)
+
+	logger.Logger().Info("magic link login successful")
+	c.JSON(http.StatusOK, LoginResponse{
+		Message:                "logged in!",
+		TwoFactorSetupRequired: result.TwoFactorSetupRequired,
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+const (
+	sessionCookieName = "pollex.session"
+	refreshCookieName = "pollex.refresh"
+	// the refresh cookie is only sent to the auth endpoints
+	refreshCookiePath = "/auth"
+	// binds a magic link to the browser that requested it
+	magicLinkCookieName = "pollex.magic_link"
+	magicLinkCookiePath = "/auth/magic-link"
+)
+
+// setAuthCookies stores the access token in the session cookie and the refresh token in the refresh cookie
+func setAuthCookies(c *gin.Context, config *util.Config, tokens service.TokenPair) {
+	c.SetSameSite(config.CookieSameSite)
+	c.SetCookie(sessionCookieName, tokens.AccessToken, int(time.Until(tokens.AccessExpiresAt).Seconds()),
+		"/", config.CookieDomain, config.CookieSecure, true)
+	c.SetCookie(refreshCookieName, tokens.RefreshToken, int(time.Until(tokens.RefreshExpiresAt).Seconds()),
+		refreshCookiePath, config.CookieDomain, config.CookieSecure, true)
+}
+
+// clearAuthCookies removes both auth cookies
+func clearAuthCookies(c *gin.Context, config *util.Config) {
+	c.SetSameSite(config.CookieSameSite)
+	c.SetCookie(sessionCookieName, "", -1, "/", config.CookieDomain, config.CookieSecure, true)
+	c.SetCookie(refreshCookieName, "", -1, refreshCookiePath, config.CookieDomain, config.CookieSecure, true)
+}
+
 // Register endpoint
 func (h *AuthHandler) Register(c *gin.Context) {
 	logger := util.NewRequestLogger(c)
@@ -97,6 +424,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	user, err := h.AuthService.Register(c, input)
 
 	if err != nil {
+		var policyErr *util.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			logger.LogError(err, "password_policy")
+			PasswordPolicyErrorResponse(c, policyErr)
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, util.ErrEmailExists) {
 			logger.LogError(err, "email_exists")
 			ErrorResponse(c, http.StatusConflict, "Email already registered")
@@ -109,11 +443,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[AUTH]%s Registration successful | email=%s%s%s | name=%s%s%s | user_id=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, input.Email, util.ColorReset,
-		util.ColorCyan, input.Name, util.ColorReset,
-		util.ColorMagenta, user.ID, util.ColorReset)
+	logger.Logger().Info("registration successful", "email", input.Email, "new_user_id", user.ID.String())
 	c.JSON(http.StatusCreated, gin.H{
 		"data":  user,
 		"isNew": true,
@@ -145,23 +475,63 @@ func (h *AuthHandler) Profile(c *gin.Context) {
 		return
 	}
 
-	OkResponse(c, user)
+	permissions, err := h.RoleService.RolePermissions(c.Request.Context(), user.Role)
+	if err != nil {
+		logger.LogError(err, "get_permissions")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to load permissions")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	response := ProfileResponse{
+		GetUserByIDRow: user,
+		Permissions:    permissions,
+	}
+	if impersonatorID, ok := middleware.GetImpersonatorID(c); ok {
+		response.ImpersonatorID = &impersonatorID
+	}
+
+	OkResponse(c, response)
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"user_id": userId})
 }
 
-func RegisterAuthRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config, emailService *service.EmailService) {
+// CSRFToken endpoint - returns the CSRF token to send in the X-CSRF-Token header, for clients
+// that can't read the pollex.csrf cookie
+func (h *AuthHandler) CSRFToken(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	logger.LogStart()
+
+	token := middleware.GetCSRFToken(c)
+	if token == "" {
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to issue CSRF token")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	OkResponse(c, gin.H{"csrf_token": token})
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterAuthRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config, emailService *service.EmailService, limiter *ratelimit.Limiter) {
 
 	AuthService := service.NewAuthService(config, queries, service.NewTokenService(config), emailService)
 	handler := NewAuthHandler(queries, service.NewTokenService(config), AuthService)
 	authRoutes := r.Group("/auth")
 	{
-		authRoutes.POST("/login", handler.Login)
-		authRoutes.POST("/register", handler.Register)
+		authRoutes.POST("/login", middleware.RateLimit(limiter, middleware.LoginRateLimit), handler.Login)
+		authRoutes.POST("/login/2fa", middleware.RateLimit(limiter, middleware.LoginRateLimit), handler.LoginTwoFactor)
+		authRoutes.POST("/magic-link", middleware.RateLimit(limiter, middleware.MagicLinkRequestRateLimit), handler.RequestMagicLink)
+		authRoutes.POST("/magic-link/verify", middleware.RateLimit(limiter, middleware.MagicLinkVerifyRateLimit), handler.MagicLinkLogin)
+		authRoutes.POST("/register", middleware.RateLimit(limiter, middleware.RegisterRateLimit), handler.Register)
+		authRoutes.POST("/refresh", handler.Refresh)
+		authRoutes.POST("/unlock", handler.UnlockAccount)
 		authRoutes.POST("/logout", handler.Logout)
+		authRoutes.GET("/csrf", handler.CSRFToken)
 	}
 
-	userRoutes := r.Group("/user").Use(middleware.AuthMiddleware())
+	userRoutes := r.Group("/user").Use(middleware.AuthMiddleware(queries))
 	{
-		userRoutes.GET("/profile", handler.Profile)
+		userRoutes.GET("/profile", middleware.RequireScope(service.ScopeProfileRead), handler.Profile)
 	}
 }
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,6 @@ import (
 	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type EmailHandler struct {
@@ -262,12 +262,12 @@ func (h *EmailHandler) ResetPassword(c *gin.Context) {
 	var input struct {
 		Token    string `json:"token" binding:"required"`
 		UID      string `json:"uid" binding:"required"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		logger.LogError(err, "bind_json")
-		ErrorResponse(c, http.StatusBadRequest, "Invalid request. Password must be at least 8 characters")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
 		logger.LogEnd(http.StatusBadRequest)
 		return
 	}
@@ -283,8 +283,25 @@ func (h *EmailHandler) ResetPassword(c *gin.Context) {
 	logger.SetUserID(userID)
 	logger.LogStart()
 
+	user, err := h.Queries.GetUserByID(c, userID)
+	if err != nil {
+		logger.LogError(err, "get_user")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid reset token")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	// Check the new password against the policy
+	var policyErr *util.PasswordPolicyError
+	if err := util.ValidatePassword(input.Password, user.Name, user.Email); errors.As(err, &policyErr) {
+		logger.LogError(err, "password_policy")
+		PasswordPolicyErrorResponse(c, policyErr)
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
 	// Hash the new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := util.HashPassword(input.Password)
 	if err != nil {
 		logger.LogError(err, "hash_password")
 		ErrorResponse(c, http.StatusInternalServerError, "Failed to process password")
@@ -293,7 +310,7 @@ func (h *EmailHandler) ResetPassword(c *gin.Context) {
 	}
 
 	// Reset password
-	err = h.EmailService.ResetPassword(c, userID, input.Token, string(hashedPassword))
+	err = h.EmailService.ResetPassword(c, userID, input.Token, hashedPassword)
 	if err != nil {
 		logger.LogError(err, "reset_password_failed")
 		ErrorResponse(c, http.StatusBadRequest, err.Error())
@@ -320,6 +337,6 @@ func RegisterEmailRoutes(r *gin.Engine, queries *repository.Queries, emailServic
 		emailRoutes.POST("/reset-password", handler.ResetPassword)
 
 		// Protected routes (require authentication)
-		emailRoutes.POST("/resend-verification", middleware.AuthMiddleware(), handler.ResendVerificationEmail)
+		emailRoutes.POST("/resend-verification", middleware.AuthMiddleware(queries), middleware.RequireSessionAuth(), middleware.DenyImpersonation(), handler.ResendVerificationEmail)
 	}
 }
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type EmailChangeHandler struct {
+	EmailChangeService *service.EmailChangeService
+}
+
+func NewEmailChangeHandler(emailChangeService *service.EmailChangeService) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		EmailChangeService: emailChangeService,
+	}
+}
+
+// RequestChange starts moving the current user's account to a new email address
+func (h *EmailChangeHandler) RequestChange(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	var input service.EmailChangeRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	if err := h.EmailChangeService.RequestChange(c.Request.Context(), userID, input); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidCredentials):
+			logger.LogError(err, "invalid_password")
+			ErrorResponse(c, http.StatusUnauthorized, "Invalid password")
+			logger.LogEnd(http.StatusUnauthorized)
+		case errors.Is(err, util.ErrEmailUnchanged):
+			logger.LogError(err, "email_unchanged")
+			ErrorResponse(c, http.StatusBadRequest, "New email is the same as the current one")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrEmailExists):
+			logger.LogError(err, "email_exists")
+			ErrorResponse(c, http.StatusConflict, "Email already registered")
+			logger.LogEnd(http.StatusConflict)
+		case errors.Is(err, util.ErrEmailChangeRateLimited):
+			logger.LogError(err, "rate_limited")
+			ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+			logger.LogEnd(http.StatusTooManyRequests)
+		default:
+			logger.LogError(err, "request_email_change")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to request email change")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Confirmation email sent to the new address"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// ConfirmChange applies a pending email change and signs out the user's other sessions
+func (h *EmailChangeHandler) ConfirmChange(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	sessionID, err := middleware.GetSessionID(c)
+	if err != nil {
+		logger.LogError(err, "get_session_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	var input service.EmailChangeConfirmInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	user, err := h.EmailChangeService.ConfirmChange(c.Request.Context(), userID, sessionID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidEmailChange):
+			logger.LogError(err, "invalid_token")
+			ErrorResponse(c, http.StatusBadRequest, "Invalid or expired email change link")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrEmailExists):
+			logger.LogError(err, "email_exists")
+			ErrorResponse(c, http.StatusConflict, "Email already registered")
+			logger.LogEnd(http.StatusConflict)
+		default:
+			logger.LogError(err, "confirm_email_change")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to change email")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("email changed")
+
+	OkResponse(c, user)
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterEmailChangeRoutes(r *gin.Engine, queries *repository.Queries, emailService *service.EmailService) {
+	handler := NewEmailChangeHandler(service.NewEmailChangeService(queries, emailService))
+
+	emailChangeRoutes := r.Group("/user/email").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		emailChangeRoutes.POST("", handler.RequestChange)
+		emailChangeRoutes.POST("/confirm", handler.ConfirmChange)
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type EmailOutboxHandler struct {
+	OutboxService *service.EmailOutboxService
+}
+
+func NewEmailOutboxHandler(outboxService *service.EmailOutboxService) *EmailOutboxHandler {
+	return &EmailOutboxHandler{
+		OutboxService: outboxService,
+	}
+}
+
+// ListEmails returns outbox emails with ?status= (default failed), newest first
+func (h *EmailOutboxHandler) ListEmails(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	status := c.DefaultQuery("status", service.EmailStatusFailed)
+	logger.LogStart(map[string]interface{}{"status": status})
+
+	// Parse pagination parameters
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if limit > 100 {
+		limit = 100 // Cap at 100
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	result, err := h.OutboxService.ListEmails(c.Request.Context(), service.ListEmailsInput{
+		Status: status,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidEmailStatus) {
+			ErrorResponse(c, http.StatusBadRequest, "Status must be pending, sent or failed")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "list_emails_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve emails")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, result)
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"email_count": len(result.Emails)})
+}
+
+// GetEmail returns the delivery status of one outbox email
+func (h *EmailOutboxHandler) GetEmail(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	emailID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_email_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid email ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"outbox_id": emailID.String()})
+
+	email, err := h.OutboxService.GetEmail(c.Request.Context(), emailID)
+	if err != nil {
+		if errors.Is(err, util.ErrEmailNotFound) {
+			ErrorResponse(c, http.StatusNotFound, "Email not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "get_email_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve email")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, email)
+	logger.LogEnd(http.StatusOK)
+}
+
+// RetryEmail puts a failed email back in the delivery queue
+func (h *EmailOutboxHandler) RetryEmail(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	emailID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_email_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid email ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"outbox_id": emailID.String()})
+
+	email, err := h.OutboxService.RetryEmail(c.Request.Context(), actorID, emailID)
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrEmailNotFound):
+			ErrorResponse(c, http.StatusNotFound, "Email not found")
+			logger.LogEnd(http.StatusNotFound)
+		case errors.Is(err, util.ErrEmailNotFailed):
+			ErrorResponse(c, http.StatusConflict, "Only failed emails can be retried")
+			logger.LogEnd(http.StatusConflict)
+		default:
+			logger.LogError(err, "retry_email_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to retry email")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("email queued for retry", "outbox_id", emailID.String())
+
+	OkResponse(c, email)
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterEmailOutboxRoutes(r *gin.Engine, queries *repository.Queries, outboxService *service.EmailOutboxService) {
+	handler := NewEmailOutboxHandler(outboxService)
+
+	outboxRoutes := r.Group("/admin/emails").Use(middleware.AuthMiddleware(queries)).Use(middleware.DenyImpersonation()).Use(middleware.RequireScope(service.ScopeAdmin)).Use(middleware.RequireTwoFactor(queries)).Use(middleware.RequirePermission(queries, service.PermissionEmailManage))
+	{
+		outboxRoutes.GET("", handler.ListEmails)
+		outboxRoutes.GET("/:id", handler.GetEmail)
+		outboxRoutes.POST("/:id/retry", handler.RetryEmail)
+	}
+}
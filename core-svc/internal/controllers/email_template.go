@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type EmailTemplateHandler struct {
+	EmailService *service.EmailService
+}
+
+func NewEmailTemplateHandler(emailService *service.EmailService) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		EmailService: emailService,
+	}
+}
+
+// ListTemplates returns the email templates and the locales they can be rendered in
+func (h *EmailTemplateHandler) ListTemplates(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+	logger.LogStart()
+
+	OkResponse(c, gin.H{
+		"templates": mailer.TemplateNames,
+		"locales":   mailer.Locales,
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+// PreviewTemplate renders a template with sample data, in ?locale= (default en)
+func (h *EmailTemplateHandler) PreviewTemplate(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	name := c.Param("name")
+	locale := c.DefaultQuery("locale", mailer.DefaultLocale)
+	logger.LogStart(map[string]interface{}{"template": name, "locale": locale})
+
+	if mailer.NormalizeLocale(locale) == "" {
+		ErrorResponseWithData(c, http.StatusBadRequest, "Unsupported locale", gin.H{"locales": mailer.Locales})
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	rendered, err := h.EmailService.PreviewTemplate(name, locale)
+	if err != nil {
+		if errors.Is(err, mailer.ErrUnknownTemplate) {
+			ErrorResponse(c, http.StatusNotFound, "Email template not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "render_template")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to render email template")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, rendered)
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterEmailTemplateRoutes(r *gin.Engine, queries *repository.Queries, emailService *service.EmailService) {
+	handler := NewEmailTemplateHandler(emailService)
+
+	templateRoutes := r.Group("/admin/email-templates").Use(middleware.AuthMiddleware(queries)).Use(middleware.DenyImpersonation()).Use(middleware.RequireScope(service.ScopeAdmin)).Use(middleware.RequireTwoFactor(queries)).Use(middleware.RequirePermission(queries, service.PermissionEmailPreview))
+	{
+		templateRoutes.GET("", handler.ListTemplates)
+		templateRoutes.GET("/:name", handler.PreviewTemplate)
+	}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// how long verifiers may cache the key set; a new key should be published at least this
+// long before it starts signing
+const jwksMaxAge = "public, max-age=300"
+
+type JWKSHandler struct {
+	Keys *util.KeySet
+}
+
+func NewJWKSHandler(keys *util.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		Keys: keys,
+	}
+}
+
+// JWKS serves the public token verification keys as a JSON Web Key Set (RFC 7517).
+// The body is the bare key set rather than the usual response envelope, as verifiers expect.
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.Keys.JWKS())
+}
+
+func RegisterJWKSRoutes(r *gin.Engine) {
+	handler := NewJWKSHandler(util.SigningKeys())
+
+	r.GET("/.well-known/jwks.json", handler.JWKS)
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type NotificationHandler struct {
+	NotificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		NotificationService: notificationService,
+	}
+}
+
+// GetPreferences returns which notification emails the current user receives
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	preferences, err := h.NotificationService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "get_preferences")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notification preferences")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"preferences": preferences})
+	logger.LogEnd(http.StatusOK)
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences service.NotificationPreferences `json:"preferences" binding:"required"`
+}
+
+// UpdatePreferences turns notification categories on or off; categories left out are unchanged
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"preferences": req.Preferences})
+
+	preferences, err := h.NotificationService.UpdatePreferences(c.Request.Context(), userID, req.Preferences)
+	if err != nil {
+		if errors.Is(err, util.ErrUnknownNotificationCategory) {
+			logger.LogError(err, "unknown_category")
+			ErrorResponseWithData(c, http.StatusBadRequest, "Unknown notification category", gin.H{"categories": service.NotificationCategories})
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "update_preferences")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to update notification preferences")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"preferences": preferences})
+	logger.LogEnd(http.StatusOK)
+}
+
+// Unsubscribe turns off the category named in the signed ?token= of an unsubscribe link. Mail
+// clients call it directly for one-click unsubscribing (RFC 8058), so it needs no session.
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	logger.LogStart()
+
+	token := c.Query("token")
+	if token == "" {
+		ErrorResponse(c, http.StatusBadRequest, "Missing token")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.NotificationService.Unsubscribe(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidUnsubscribeToken) {
+			logger.LogError(err, "invalid_token")
+			ErrorResponse(c, http.StatusBadRequest, "Invalid or expired unsubscribe link")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "unsubscribe")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to unsubscribe")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"category": category})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"category": category})
+}
+
+func RegisterNotificationRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config) {
+	handler := NewNotificationHandler(service.NewNotificationService(queries, config))
+
+	preferenceRoutes := r.Group("/user/notifications").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		preferenceRoutes.GET("", handler.GetPreferences)
+		preferenceRoutes.PUT("", handler.UpdatePreferences)
+	}
+
+	// the signed token is the only credential
+	r.POST("/notifications/unsubscribe", handler.Unsubscribe)
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const (
+	oidcStateCookieName = "pollex.oidc"
+	oidcStateCookiePath = "/auth/oidc"
+)
+
+type OIDCHandler struct {
+	OIDCService *service.OIDCService
+	Config      *util.Config
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, config *util.Config) *OIDCHandler {
+	return &OIDCHandler{
+		OIDCService: oidcService,
+		Config:      config,
+	}
+}
+
+// OIDCProviderDTO is a login provider as shown on the login page
+type OIDCProviderDTO struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
+
+// UserIdentityDTO is a linked external identity
+type UserIdentityDTO struct {
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// ListProviders returns the configured login providers
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	providers := make([]OIDCProviderDTO, 0, len(h.OIDCService.Providers()))
+	for _, p := range h.OIDCService.Providers() {
+		providers = append(providers, OIDCProviderDTO{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			LoginURL:    h.Config.APIBaseURL + "/auth/oidc/" + p.Name + "/login",
+		})
+	}
+
+	OkResponse(c, gin.H{"providers": providers})
+}
+
+// Login redirects the browser to the provider
+func (h *OIDCHandler) Login(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	provider := c.Param("provider")
+	logger.LogStart(map[string]interface{}{"provider": provider})
+
+	authURL, stateCookie, err := h.OIDCService.BeginLogin(c.Request.Context(), provider)
+	if err != nil {
+		if errors.Is(err, util.ErrUnknownProvider) {
+			logger.LogError(err, "unknown_provider")
+			ErrorResponse(c, http.StatusNotFound, "Unknown login provider")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "begin_oidc_login")
+		ErrorResponse(c, http.StatusBadGateway, "Login provider is unavailable")
+		logger.LogEnd(http.StatusBadGateway)
+		return
+	}
+
+	// Lax so the cookie comes back on the provider's top-level redirect
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookieName, stateCookie, int(service.OIDCStateLifespan.Seconds()),
+		oidcStateCookiePath, h.Config.CookieDomain, h.Config.CookieSecure, true)
+
+	c.Redirect(http.StatusFound, authURL)
+	logger.LogEnd(http.StatusFound)
+}
+
+// Callback finishes the login and redirects back to the web app
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	provider := c.Param("provider")
+	logger.LogStart(map[string]interface{}{"provider": provider})
+
+	stateCookie, _ := c.Cookie(oidcStateCookieName)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookieName, "", -1, oidcStateCookiePath, h.Config.CookieDomain, h.Config.CookieSecure, true)
+
+	// the user cancelled or the provider refused
+	if providerErr := c.Query("error"); providerErr != "" {
+		logger.Logger().Warn("oidc provider returned an error", "provider", provider, "error", providerErr)
+		h.redirectToLogin(c, logger, "provider_error")
+		return
+	}
+
+	result, err := h.OIDCService.CompleteLogin(c, service.OIDCCallbackInput{
+		Provider:    provider,
+		Code:        c.Query("code"),
+		State:       c.Query("state"),
+		StateCookie: stateCookie,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrAccountSuspended):
+			logger.LogError(err, "account_suspended")
+			h.redirectToLogin(c, logger, "account_suspended")
+		case errors.Is(err, util.ErrOIDCEmailNotVerified):
+			logger.LogError(err, "email_not_verified")
+			h.redirectToLogin(c, logger, "email_not_verified")
+		case errors.Is(err, util.ErrOIDCEmailMissing):
+			logger.LogError(err, "email_missing")
+			h.redirectToLogin(c, logger, "email_missing")
+		case errors.Is(err, util.ErrOIDCStateMismatch), errors.Is(err, util.ErrOIDCInvalidToken):
+			logger.LogError(err, "invalid_oidc_response")
+			h.redirectToLogin(c, logger, "invalid_state")
+		default:
+			logger.LogError(err, "oidc_login_failed")
+			h.redirectToLogin(c, logger, "login_failed")
+		}
+		return
+	}
+
+	if result.MFAToken != "" {
+		// the fragment never reaches a server
+		c.Redirect(http.StatusFound, h.Config.AppBaseURL+"/auth#mfa_token="+url.QueryEscape(result.MFAToken))
+		logger.LogEnd(http.StatusFound, map[string]interface{}{"mfa_required": true})
+		return
+	}
+
+	setAuthCookies(c, h.Config, result.Tokens)
+
+	c.Redirect(http.StatusFound, h.Config.AppBaseURL+"/")
+	logger.LogEnd(http.StatusFound)
+}
+
+func (h *OIDCHandler) redirectToLogin(c *gin.Context, logger *util.RequestLogger, reason string) {
+	c.Redirect(http.StatusFound, h.Config.AppBaseURL+"/auth?error="+url.QueryEscape(reason))
+	logger.LogEnd(http.StatusFound, map[string]interface{}{"error": reason})
+}
+
+// ListIdentities returns the external identities linked to the current user
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	identities, err := h.OIDCService.ListIdentities(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "list_identities")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to list linked accounts")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	identityDTOs := make([]UserIdentityDTO, 0, len(identities))
+	for _, identity := range identities {
+		identityDTOs = append(identityDTOs, convertUserIdentity(identity))
+	}
+
+	OkResponse(c, gin.H{"identities": identityDTOs})
+	logger.LogEnd(http.StatusOK)
+}
+
+// UnlinkIdentity removes a linked external identity
+func (h *OIDCHandler) UnlinkIdentity(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_identity_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid identity ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"identity_id": identityID.String()})
+
+	if err := h.OIDCService.UnlinkIdentity(c.Request.Context(), userID, identityID); err != nil {
+		if errors.Is(err, util.ErrIdentityNotFound) {
+			logger.LogError(err, "identity_not_found")
+			ErrorResponse(c, http.StatusNotFound, "Linked account not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "unlink_identity")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to unlink account")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Account unlinked"})
+	logger.LogEnd(http.StatusOK)
+}
+
+func convertUserIdentity(identity repository.UserIdentity) UserIdentityDTO {
+	return UserIdentityDTO{
+		ID:          identity.ID.String(),
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		CreatedAt:   identity.CreatedAt,
+		LastLoginAt: identity.LastLoginAt,
+	}
+}
+
+func RegisterOIDCRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config, emailService *service.EmailService) {
+	authService := service.NewAuthService(config, queries, service.NewTokenService(config), emailService)
+	handler := NewOIDCHandler(service.NewOIDCService(config, queries, authService), config)
+
+	oidcRoutes := r.Group("/auth/oidc")
+	{
+		oidcRoutes.GET("/providers", handler.ListProviders)
+		oidcRoutes.GET("/:provider/login", handler.Login)
+		oidcRoutes.GET("/:provider/callback", handler.Callback)
+	}
+
+	identityRoutes := r.Group("/user/identities").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		identityRoutes.GET("", handler.ListIdentities)
+		identityRoutes.DELETE("/:id", handler.UnlinkIdentity)
+	}
+}
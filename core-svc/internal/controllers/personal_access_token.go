@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type PersonalAccessTokenHandler struct {
+	TokenService *service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(tokenService *service.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		TokenService: tokenService,
+	}
+}
+
+// PersonalAccessTokenDTO is a personal access token without its secret
+type PersonalAccessTokenDTO struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func convertPersonalAccessToken(token repository.PersonalAccessToken) PersonalAccessTokenDTO {
+	var lastUsedAt *time.Time
+	if token.LastUsedAt.Valid {
+		lastUsedAt = &token.LastUsedAt.Time
+	}
+
+	return PersonalAccessTokenDTO{
+		ID:          token.ID.String(),
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.Scopes,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  lastUsedAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// ListTokens returns the current user's active personal access tokens
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	tokens, err := h.TokenService.ListTokens(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "list_tokens")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to list tokens")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	tokenDTOs := make([]PersonalAccessTokenDTO, 0, len(tokens))
+	for _, token := range tokens {
+		tokenDTOs = append(tokenDTOs, convertPersonalAccessToken(token))
+	}
+
+	OkResponse(c, gin.H{
+		"tokens":           tokenDTOs,
+		"available_scopes": service.PersonalAccessTokenScopes,
+	})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"count": len(tokenDTOs)})
+}
+
+// CreateToken creates a personal access token; the secret is only returned in this response
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	var input service.CreatePersonalAccessTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+	input.UserID = userID
+
+	logger.LogStart(map[string]interface{}{"name": input.Name, "scopes": input.Scopes})
+
+	token, secret, err := h.TokenService.CreateToken(c.Request.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidScope):
+			logger.LogError(err, "invalid_scope")
+			ErrorResponseWithData(c, http.StatusBadRequest, "Invalid scope", gin.H{
+				"available_scopes": service.PersonalAccessTokenScopes,
+			})
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrInvalidInput):
+			logger.LogError(err, "invalid_input")
+			ErrorResponse(c, http.StatusBadRequest, "Name is required and expiry must be between 1 and 365 days")
+			logger.LogEnd(http.StatusBadRequest)
+		case errors.Is(err, util.ErrInsufficientPerms):
+			logger.LogError(err, "insufficient_permissions")
+			ErrorResponse(c, http.StatusForbidden, "Only admins can create tokens with the admin scope")
+			logger.LogEnd(http.StatusForbidden)
+		default:
+			logger.LogError(err, "create_token_failed")
+			ErrorResponse(c, http.StatusInternalServerError, "Failed to create token")
+			logger.LogEnd(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Logger().Info("personal access token created", "token_id", token.ID.String())
+
+	Response(c, gin.H{
+		"token":  secret,
+		"detail": convertPersonalAccessToken(token),
+	}, http.StatusCreated)
+	logger.LogEnd(http.StatusCreated, map[string]interface{}{"token_id": token.ID.String()})
+}
+
+// RevokeToken revokes one of the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_token_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid token ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"token_id": tokenID.String()})
+
+	if err := h.TokenService.RevokeToken(c.Request.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, util.ErrTokenNotFound) {
+			logger.LogError(err, "token_not_found")
+			ErrorResponse(c, http.StatusNotFound, "Token not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "revoke_token_failed")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke token")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Token revoked"})
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterPersonalAccessTokenRoutes(r *gin.Engine, queries *repository.Queries) {
+	handler := NewPersonalAccessTokenHandler(service.NewPersonalAccessTokenService(queries))
+
+	// tokens can only be managed from a browser session, never with another token
+	tokenRoutes := r.Group("/user/tokens").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		tokenRoutes.GET("", handler.ListTokens)
+		tokenRoutes.POST("", handler.CreateToken)
+		tokenRoutes.DELETE("/:id", handler.RevokeToken)
+	}
+}
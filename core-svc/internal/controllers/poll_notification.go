@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type PollNotificationHandler struct {
+	NotificationService *service.PollNotificationService
+}
+
+func NewPollNotificationHandler(notificationService *service.PollNotificationService) *PollNotificationHandler {
+	return &PollNotificationHandler{
+		NotificationService: notificationService,
+	}
+}
+
+// pollOwnerError responds to the errors every owner-only poll endpoint can return and reports
+// whether it did
+func pollOwnerError(c *gin.Context, logger *util.RequestLogger, err error) bool {
+	switch {
+	case errors.Is(err, util.ErrPollNotFound):
+		ErrorResponse(c, http.StatusNotFound, "Poll not found")
+		logger.LogEnd(http.StatusNotFound)
+	case errors.Is(err, util.ErrNotPollOwner):
+		ErrorResponse(c, http.StatusForbidden, "Only the poll owner can do this")
+		logger.LogEnd(http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
+
+// parsePollRequest reads the current user and the :id poll, responding on failure
+func parsePollRequest(c *gin.Context, logger *util.RequestLogger) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+	logger.SetUserID(userID)
+
+	pollID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_poll_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid poll ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, pollID, true
+}
+
+// GetReminder returns the reminder setting of the user's poll
+func (h *PollNotificationHandler) GetReminder(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String()})
+
+	reminder, err := h.NotificationService.GetReminder(c.Request.Context(), pollID, userID)
+	if err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		if errors.Is(err, util.ErrPollReminderNotFound) {
+			ErrorResponse(c, http.StatusNotFound, "Reminders are not set for this poll")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "get_reminder")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve reminder")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, reminder)
+	logger.LogEnd(http.StatusOK)
+}
+
+type SetReminderRequest struct {
+	HoursBefore int `json:"hours_before" binding:"required"`
+}
+
+// SetReminder emails invitees who haven't voted the given number of hours before the poll expires
+func (h *PollNotificationHandler) SetReminder(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	var req SetReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String(), "hours_before": req.HoursBefore})
+
+	reminder, err := h.NotificationService.SetReminder(c.Request.Context(), pollID, userID, req.HoursBefore)
+	if err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		if errors.Is(err, util.ErrInvalidReminderHours) {
+			ErrorResponse(c, http.StatusBadRequest, "Reminders can be sent 1 to 168 hours before the poll expires")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "set_reminder")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to set reminder")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, reminder)
+	logger.LogEnd(http.StatusOK)
+}
+
+// DeleteReminder turns reminders off for the user's poll
+func (h *PollNotificationHandler) DeleteReminder(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String()})
+
+	if err := h.NotificationService.DeleteReminder(c.Request.Context(), pollID, userID); err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		if errors.Is(err, util.ErrPollReminderNotFound) {
+			ErrorResponse(c, http.StatusNotFound, "Reminders are not set for this poll")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "delete_reminder")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to delete reminder")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Reminder deleted"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// ListInvitees lists the users invited to the user's poll
+func (h *PollNotificationHandler) ListInvitees(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String()})
+
+	invitees, err := h.NotificationService.ListInvitees(c.Request.Context(), pollID, userID)
+	if err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		logger.LogError(err, "list_invitees")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve invitees")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"invitees": invitees})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"invitee_count": len(invitees)})
+}
+
+type AddInviteesRequest struct {
+	Emails []string `json:"emails" binding:"required"`
+}
+
+// AddInvitees invites users to the user's poll by email
+func (h *PollNotificationHandler) AddInvitees(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	var req AddInviteesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String(), "email_count": len(req.Emails)})
+
+	invitees, err := h.NotificationService.AddInvitees(c.Request.Context(), pollID, userID, req.Emails)
+	if err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		if errors.Is(err, util.ErrTooManyInvitees) {
+			ErrorResponse(c, http.StatusBadRequest, "Too many emails, invite at most 100 at a time")
+			logger.LogEnd(http.StatusBadRequest)
+			return
+		}
+		logger.LogError(err, "add_invitees")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to invite users")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"invitees": invitees})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"invitee_count": len(invitees)})
+}
+
+// RemoveInvitee withdraws an invitation to the user's poll
+func (h *PollNotificationHandler) RemoveInvitee(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, pollID, ok := parsePollRequest(c, logger)
+	if !ok {
+		return
+	}
+
+	inviteeID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		logger.LogError(err, "parse_user_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid user ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"poll_id": pollID.String(), "invitee_id": inviteeID.String()})
+
+	if err := h.NotificationService.RemoveInvitee(c.Request.Context(), pollID, userID, inviteeID); err != nil {
+		if pollOwnerError(c, logger, err) {
+			return
+		}
+		if errors.Is(err, util.ErrPollInviteeNotFound) {
+			ErrorResponse(c, http.StatusNotFound, "Invitee not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "remove_invitee")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to remove invitee")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{"message": "Invitation withdrawn"})
+	logger.LogEnd(http.StatusOK)
+}
+
+func RegisterPollNotificationRoutes(r *gin.Engine, queries *repository.Queries, notificationService *service.PollNotificationService) {
+	handler := NewPollNotificationHandler(notificationService)
+
+	pollRoutes := r.Group("/polls/:id", middleware.AuthMiddleware(queries))
+
+	readRoutes := pollRoutes.Group("", middleware.RequireScope(service.ScopePollsRead))
+	{
+		readRoutes.GET("/reminder", handler.GetReminder)
+		readRoutes.GET("/invitees", handler.ListInvitees)
+	}
+
+	writeRoutes := pollRoutes.Group("", middleware.RequireScope(service.ScopePollsWrite))
+	{
+		writeRoutes.PUT("/reminder", handler.SetReminder)
+		writeRoutes.DELETE("/reminder", handler.DeleteReminder)
+		writeRoutes.POST("/invitees", handler.AddInvitees)
+		writeRoutes.DELETE("/invitees/:userId", handler.RemoveInvitee)
+	}
+}
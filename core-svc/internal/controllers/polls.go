@@ -1,13 +1,13 @@
 package controllers
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
@@ -85,12 +85,7 @@ func (h *PollsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[POLL]%s Poll created | user=%s%s%s | poll_id=%s%s%s | question=%s%s%s | options=%s%d%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, userId.String()[:8], util.ColorReset,
-		util.ColorMagenta, p.ID.String()[:8], util.ColorReset,
-		util.ColorYellow, data.Question, util.ColorReset,
-		util.ColorGreen, len(data.Options), util.ColorReset)
+	logger.Logger().Info("poll created", "poll_id", p.ID.String(), "options", len(data.Options))
 	OkResponse(c, p)
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"poll_id": p.ID})
 }
@@ -166,24 +161,26 @@ func (h *PollsHandler) GetUserPolls(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[POLLS]%s User polls retrieved | user=%s%s%s | polls_count=%s%d%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, userId.String()[:8], util.ColorReset,
-		util.ColorMagenta, len(polls), util.ColorReset)
+	logger.Logger().Debug("user polls retrieved", "polls_count", len(polls))
 	OkResponse(c, polls)
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"polls_count": len(polls)})
 }
 
-func RegisterPollsRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config, emailService *service.EmailService) {
+func RegisterPollsRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config, emailService *service.EmailService, limiter *ratelimit.Limiter) {
 
 	AuthService := service.NewAuthService(config, queries, service.NewTokenService(config), emailService)
 	handler := NewPollsHandler(queries, AuthService)
 
-	pollsRoutes := r.Group("/polls").Use(middleware.AuthMiddleware())
+	pollsRoutes := r.Group("/polls", middleware.AuthMiddleware(queries))
 
+	readRoutes := pollsRoutes.Group("", middleware.RequireScope(service.ScopePollsRead))
 	{
-		pollsRoutes.POST("", handler.Create)
-		pollsRoutes.GET("/:id", handler.Get)
-		pollsRoutes.GET("", handler.GetUserPolls)
+		readRoutes.GET("/:id", handler.Get)
+		readRoutes.GET("", handler.GetUserPolls)
+	}
+
+	writeRoutes := pollsRoutes.Group("", middleware.RequireScope(service.ScopePollsWrite))
+	{
+		writeRoutes.POST("", middleware.RateLimit(limiter, middleware.PollCreateRateLimit), handler.Create)
 	}
 }
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type RoleHandler struct {
+	RoleService *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		RoleService: roleService,
+	}
+}
+
+// ListRoles returns every role with its permissions, and the permissions that can be granted
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+	logger.LogStart()
+
+	roles, err := h.RoleService.ListRoles(c.Request.Context())
+	if err != nil {
+		logger.LogError(err, "list_roles")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to list roles")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, gin.H{
+		"roles":       roles,
+		"permissions": service.Permissions,
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+// CreateRole adds a custom role
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	var input service.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"name": input.Name, "permissions": input.Permissions})
+
+	role, err := h.RoleService.CreateRole(c.Request.Context(), actorID, input)
+	if err != nil {
+		h.handleError(c, logger, err, "create_role")
+		return
+	}
+
+	logger.Logger().Info("role created", "name", role.Name)
+
+	OkResponse(c, role)
+	logger.LogEnd(http.StatusOK)
+}
+
+// UpdateRole replaces the description and permissions of a custom role
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	name := c.Param("name")
+
+	var input service.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"name": name, "permissions": input.Permissions})
+
+	role, err := h.RoleService.UpdateRole(c.Request.Context(), actorID, name, input)
+	if err != nil {
+		h.handleError(c, logger, err, "update_role")
+		return
+	}
+
+	logger.Logger().Info("role updated", "name", role.Name)
+
+	OkResponse(c, role)
+	logger.LogEnd(http.StatusOK)
+}
+
+// DeleteRole removes a custom role that isn't assigned to anyone
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+	actorID, _ := middleware.GetUserID(c)
+	logger.SetUserID(actorID)
+
+	name := c.Param("name")
+	logger.LogStart(map[string]interface{}{"name": name})
+
+	if err := h.RoleService.DeleteRole(c.Request.Context(), actorID, name); err != nil {
+		h.handleError(c, logger, err, "delete_role")
+		return
+	}
+
+	logger.Logger().Info("role deleted", "name", name)
+
+	OkResponse(c, gin.H{"message": "Role deleted"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// handleError maps role service errors to responses
+func (h *RoleHandler) handleError(c *gin.Context, logger *util.RequestLogger, err error, context string) {
+	switch {
+	case errors.Is(err, util.ErrInvalidInput):
+		logger.LogError(err, "invalid_role_name")
+		ErrorResponse(c, http.StatusBadRequest, "Role names must be lowercase letters, digits, '-' or '_' and start with a letter")
+		logger.LogEnd(http.StatusBadRequest)
+	case errors.Is(err, util.ErrInvalidPermission):
+		logger.LogError(err, "invalid_permission")
+		ErrorResponse(c, http.StatusBadRequest, "Unknown permission")
+		logger.LogEnd(http.StatusBadRequest)
+	case errors.Is(err, util.ErrInsufficientPerms):
+		logger.LogError(err, "insufficient_permissions")
+		ErrorResponse(c, http.StatusForbidden, "You can't grant permissions you don't have")
+		logger.LogEnd(http.StatusForbidden)
+	case errors.Is(err, util.ErrBuiltInRole):
+		logger.LogError(err, "built_in_role")
+		ErrorResponse(c, http.StatusForbidden, "Built-in roles cannot be changed")
+		logger.LogEnd(http.StatusForbidden)
+	case errors.Is(err, util.ErrRoleExists):
+		logger.LogError(err, "role_exists")
+		ErrorResponse(c, http.StatusConflict, "Role already exists")
+		logger.LogEnd(http.StatusConflict)
+	case errors.Is(err, util.ErrRoleInUse):
+		logger.LogError(err, "role_in_use")
+		ErrorResponse(c, http.StatusConflict, "Role is still assigned to users")
+		logger.LogEnd(http.StatusConflict)
+	case errors.Is(err, util.ErrRoleNotFound):
+		logger.LogError(err, "role_not_found")
+		ErrorResponse(c, http.StatusNotFound, "Role not found")
+		logger.LogEnd(http.StatusNotFound)
+	default:
+		logger.LogError(err, context)
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to save role")
+		logger.LogEnd(http.StatusInternalServerError)
+	}
+}
+
+func RegisterRoleRoutes(r *gin.Engine, queries *repository.Queries) {
+	handler := NewRoleHandler(service.NewRoleService(queries))
+
+	roleRoutes := r.Group("/admin/roles").Use(middleware.AuthMiddleware(queries)).Use(middleware.DenyImpersonation()).Use(middleware.RequireScope(service.ScopeAdmin)).Use(middleware.RequireTwoFactor(queries))
+	{
+		roleRoutes.GET("", middleware.RequirePermission(queries, service.PermissionUserRead), handler.ListRoles)
+		roleRoutes.POST("", middleware.RequirePermission(queries, service.PermissionRoleManage), handler.CreateRole)
+		roleRoutes.PUT("/:name", middleware.RequirePermission(queries, service.PermissionRoleManage), handler.UpdateRole)
+		roleRoutes.DELETE("/:name", middleware.RequirePermission(queries, service.PermissionRoleManage), handler.DeleteRole)
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type SessionHandler struct {
+	SessionService *service.SessionService
+	Config         *util.Config
+}
+
+func NewSessionHandler(sessionService *service.SessionService, config *util.Config) *SessionHandler {
+	return &SessionHandler{
+		SessionService: sessionService,
+		Config:         config,
+	}
+}
+
+// SessionDTO is a signed-in device as shown to the user
+type SessionDTO struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+	// an admin signed in as the user
+	Impersonated bool `json:"impersonated"`
+}
+
+// ListSessions returns the current user's active sessions
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	currentID, _ := middleware.GetSessionID(c)
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	sessions, err := h.SessionService.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "list_sessions")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to list sessions")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	sessionDTOs := make([]SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		sessionDTOs = append(sessionDTOs, convertSession(session, currentID))
+	}
+
+	OkResponse(c, gin.H{"sessions": sessionDTOs})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"count": len(sessionDTOs)})
+}
+
+// RevokeSession signs out one of the current user's sessions
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger.LogError(err, "parse_session_id")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid session ID format")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart(map[string]interface{}{"session_id": sessionID.String()})
+
+	if err := h.SessionService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, util.ErrSessionNotFound) {
+			logger.LogError(err, "session_not_found")
+			ErrorResponse(c, http.StatusNotFound, "Session not found")
+			logger.LogEnd(http.StatusNotFound)
+			return
+		}
+		logger.LogError(err, "revoke_session")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke session")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	// revoking the session in use is a logout
+	if currentID, _ := middleware.GetSessionID(c); currentID == sessionID {
+		clearAuthCookies(c, h.Config)
+	}
+
+	OkResponse(c, gin.H{"message": "Session revoked"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// RevokeAllSessions logs the current user out everywhere, including this device
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	revoked, err := h.SessionService.RevokeAllSessions(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "revoke_all_sessions")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke sessions")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookies(c, h.Config)
+
+	OkResponse(c, gin.H{"message": "Logged out everywhere", "revoked": revoked})
+	logger.LogEnd(http.StatusOK, map[string]interface{}{"revoked": revoked})
+}
+
+func convertSession(session repository.UserSession, currentID uuid.UUID) SessionDTO {
+	return SessionDTO{
+		ID:           session.ID.String(),
+		UserAgent:    session.UserAgent,
+		IPAddress:    session.IpAddress,
+		CreatedAt:    session.CreatedAt,
+		LastSeenAt:   session.LastSeenAt,
+		Current:      session.ID == currentID,
+		Impersonated: session.ImpersonatorID.Valid,
+	}
+}
+
+func RegisterSessionRoutes(r *gin.Engine, queries *repository.Queries, config *util.Config) {
+	handler := NewSessionHandler(service.NewSessionService(queries), config)
+
+	sessionRoutes := r.Group("/user/sessions").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		sessionRoutes.GET("", handler.ListSessions)
+		sessionRoutes.DELETE("", handler.RevokeAllSessions)
+		sessionRoutes.DELETE("/:id", handler.RevokeSession)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
 
 // error response
@@ -50,3 +51,11 @@ func ErrorResponseWithData(c *gin.Context, status int, message string, data inte
 		},
 	)
 }
+
+// password policy failure, lists every rule the password broke
+func PasswordPolicyErrorResponse(c *gin.Context, policyErr *util.PasswordPolicyError) {
+	ErrorResponseWithData(c, http.StatusBadRequest, "Password does not meet the requirements", gin.H{
+		"reason":     "password_policy",
+		"violations": policyErr.Violations,
+	})
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+type TwoFactorHandler struct {
+	TwoFactorService *service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		TwoFactorService: twoFactorService,
+	}
+}
+
+// TwoFactorCodeInput carries a TOTP code (or, where accepted, a recovery code)
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Status returns the current user's 2FA state
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	status, err := h.TwoFactorService.Status(c.Request.Context(), userID)
+	if err != nil {
+		logger.LogError(err, "get_2fa_status")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to get two-factor status")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, status)
+	logger.LogEnd(http.StatusOK)
+}
+
+// Setup starts enrolment and returns the secret and otpauth:// URI for the QR code
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+
+	logger.SetUserID(userID)
+	logger.LogStart()
+
+	enrollment, err := h.TwoFactorService.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, util.ErrTwoFactorAlreadyEnabled) {
+			logger.LogError(err, "already_enabled")
+			ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+			logger.LogEnd(http.StatusConflict)
+			return
+		}
+		logger.LogError(err, "begin_enrollment")
+		ErrorResponse(c, http.StatusInternalServerError, "Failed to start two-factor setup")
+		logger.LogEnd(http.StatusInternalServerError)
+		return
+	}
+
+	OkResponse(c, enrollment)
+	logger.LogEnd(http.StatusOK)
+}
+
+// Enable confirms enrolment with a code and returns the recovery codes
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	codes, err := h.TwoFactorService.ConfirmEnrollment(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		h.handleError(c, logger, err, "enable_2fa")
+		return
+	}
+
+	logger.Logger().Info("two-factor authentication enabled")
+
+	OkResponse(c, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+	logger.LogEnd(http.StatusOK)
+}
+
+// Disable turns 2FA off; requires a TOTP or recovery code
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	if err := h.TwoFactorService.Disable(c.Request.Context(), userID, input.Code); err != nil {
+		h.handleError(c, logger, err, "disable_2fa")
+		return
+	}
+
+	logger.Logger().Info("two-factor authentication disabled")
+
+	OkResponse(c, gin.H{"message": "Two-factor authentication disabled"})
+	logger.LogEnd(http.StatusOK)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes; requires a TOTP or recovery code
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	logger := util.NewRequestLogger(c)
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		logger.LogError(err, "get_user_id")
+		ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		logger.LogEnd(http.StatusUnauthorized)
+		return
+	}
+	logger.SetUserID(userID)
+
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.LogError(err, "bind_json")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid request")
+		logger.LogEnd(http.StatusBadRequest)
+		return
+	}
+
+	logger.LogStart()
+
+	codes, err := h.TwoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		h.handleError(c, logger, err, "regenerate_recovery_codes")
+		return
+	}
+
+	OkResponse(c, gin.H{"recovery_codes": codes})
+	logger.LogEnd(http.StatusOK)
+}
+
+// handleError maps 2FA errors to responses
+func (h *TwoFactorHandler) handleError(c *gin.Context, logger *util.RequestLogger, err error, context string) {
+	switch {
+	case errors.Is(err, util.ErrInvalidTwoFactorCode):
+		logger.LogError(err, "invalid_code")
+		ErrorResponse(c, http.StatusBadRequest, "Invalid two-factor code")
+		logger.LogEnd(http.StatusBadRequest)
+	case errors.Is(err, util.ErrTwoFactorNotEnabled):
+		logger.LogError(err, "not_enabled")
+		ErrorResponse(c, http.StatusConflict, "Two-factor authentication is not set up")
+		logger.LogEnd(http.StatusConflict)
+	case errors.Is(err, util.ErrTwoFactorAlreadyEnabled):
+		logger.LogError(err, "already_enabled")
+		ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+		logger.LogEnd(http.StatusConflict)
+	case errors.Is(err, util.ErrTwoFactorRequired):
+		logger.LogError(err, "two_factor_required")
+		ErrorResponse(c, http.StatusForbidden, "Two-factor authentication is required for your role")
+		logger.LogEnd(http.StatusForbidden)
+	default:
+		logger.LogError(err, context)
+		ErrorResponse(c, http.StatusInternalServerError, "Two-factor request failed")
+		logger.LogEnd(http.StatusInternalServerError)
+	}
+}
+
+func RegisterTwoFactorRoutes(r *gin.Engine, queries *repository.Queries) {
+	handler := NewTwoFactorHandler(service.NewTwoFactorService(queries))
+
+	twoFactorRoutes := r.Group("/user/2fa").Use(middleware.AuthMiddleware(queries)).Use(middleware.RequireSessionAuth()).Use(middleware.DenyImpersonation())
+	{
+		twoFactorRoutes.GET("", handler.Status)
+		twoFactorRoutes.POST("/setup", handler.Setup)
+		twoFactorRoutes.POST("/enable", handler.Enable)
+		twoFactorRoutes.POST("/disable", handler.Disable)
+		twoFactorRoutes.POST("/recovery-codes", handler.RegenerateRecoveryCodes)
+	}
+}
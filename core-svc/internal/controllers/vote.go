@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"time"
 
@@ -11,6 +10,7 @@ import (
 	"github.com/yatochka-dev/pollex/core-svc/internal/dto"
 	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
 	"github.com/yatochka-dev/pollex/core-svc/internal/pubsub"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
@@ -22,6 +22,8 @@ type VoteHandler struct {
 
 type VoteOnPoll struct {
 	OptionId string `json:"option_id" binding:"required"`
+	// NotifyResults asks for (true) or cancels (false) the results email when the poll closes
+	NotifyResults *bool `json:"notify_results"`
 }
 
 func NewVoteHandler(svc *service.VotingService, broker *pubsub.Broker) *VoteHandler {
@@ -62,7 +64,7 @@ func (h *VoteHandler) Vote(c *gin.Context) {
 
 	logger.LogStart(map[string]interface{}{"option_id": optionId.String()})
 
-	err = h.svc.Vote(c, optionId, userId)
+	err = h.svc.Vote(c, optionId, userId, input.NotifyResults)
 	if err != nil {
 		logger.LogError(err, "vote_failed")
 		ErrorResponse(c, http.StatusInternalServerError, "vote failed")
@@ -70,10 +72,7 @@ func (h *VoteHandler) Vote(c *gin.Context) {
 		return
 	}
 
-	log.Printf("%s[VOTE]%s Vote recorded | user=%s%s%s | option=%s%s%s",
-		util.ColorGreen+util.ColorBold, util.ColorReset,
-		util.ColorCyan, userId.String()[:8], util.ColorReset,
-		util.ColorMagenta, optionId.String()[:8], util.ColorReset)
+	logger.Logger().Info("vote recorded", "option_id", optionId.String())
 	OkResponse(c, gin.H{"message": "Vote Successful!"})
 	logger.LogEnd(http.StatusOK)
 }
@@ -158,10 +157,8 @@ func (handler *VoteHandler) SubscribeVotes(c *gin.Context) {
 	events, cancel := handler.broker.Subscribe(c.Request.Context(), pollID, 32)
 	defer cancel()
 
-	log.Printf("%s[SSE]%s New subscriber | poll=%s%s%s | active=%s%d%s",
-		util.ColorBlue+util.ColorBold, util.ColorReset,
-		util.ColorMagenta, pollID[:8], util.ColorReset,
-		util.ColorCyan, handler.broker.ActiveSubscribers(pollID), util.ColorReset)
+	sseLog := logger.Logger().With("poll_id", pollID)
+	sseLog.Info("sse subscriber connected", "active", handler.broker.ActiveSubscribers(pollID))
 
 	// connection confirmation
 	c.Writer.Write([]byte(": connected\n\n"))
@@ -175,16 +172,13 @@ func (handler *VoteHandler) SubscribeVotes(c *gin.Context) {
 		select {
 		case event, ok := <-events:
 			if !ok {
-				log.Printf("%s[SSE]%s Channel closed | poll=%s%s%s",
-					util.ColorYellow+util.ColorBold, util.ColorReset,
-					util.ColorMagenta, pollID[:8], util.ColorReset)
+				sseLog.Info("sse channel closed")
 				return
 			}
 
 			msg, err := dto.FormatSSEEvent(event)
 			if err != nil {
-				log.Printf("%s[SSE ERROR]%s Failed to format event: %v",
-					util.ColorRed+util.ColorBold, util.ColorReset, err)
+				sseLog.Error("failed to format sse event", "error", err)
 				continue
 			}
 
@@ -201,9 +195,7 @@ func (handler *VoteHandler) SubscribeVotes(c *gin.Context) {
 			handler.broker.PublishViewersUpdate(pollUUID)
 
 		case <-c.Request.Context().Done():
-			log.Printf("%s[SSE]%s Client disconnected | poll=%s%s%s",
-				util.ColorYellow+util.ColorBold, util.ColorReset,
-				util.ColorMagenta, pollID[:8], util.ColorReset)
+			sseLog.Info("sse client disconnected")
 			return
 		}
 	}
@@ -255,17 +247,17 @@ func (h *VoteHandler) GetOptionVotedFor(c *gin.Context) {
 	logger.LogEnd(http.StatusOK, map[string]interface{}{"option_id": optionId.String()})
 }
 
-func RegisterVoteRoutes(r *gin.Engine, svc *service.VotingService, broker *pubsub.Broker) {
+func RegisterVoteRoutes(r *gin.Engine, svc *service.VotingService, broker *pubsub.Broker, limiter *ratelimit.Limiter) {
 	handler := NewVoteHandler(svc, broker)
 
 	voting := r.Group("/polls/votes")
 
 	voting.GET("/:pollId", handler.GetVotes)
-	voting.GET("/:pollId/subscribe", handler.SubscribeVotes)
+	voting.GET("/:pollId/subscribe", middleware.RateLimit(limiter, middleware.SubscribeRateLimit), handler.SubscribeVotes)
 
 	protected := voting.Group("/")
-	protected.Use(middleware.AuthMiddleware())
-	protected.POST("", handler.Vote)
-	protected.GET(":pollId/vote", handler.GetOptionVotedFor)
+	protected.Use(middleware.AuthMiddleware(svc.Queries))
+	protected.POST("", middleware.RequireScope(service.ScopeVotesWrite), middleware.RateLimit(limiter, middleware.VoteRateLimit), handler.Vote)
+	protected.GET(":pollId/vote", middleware.RequireScope(service.ScopeVotesRead), handler.GetOptionVotedFor)
 
 }
@@ -0,0 +1,25 @@
+-- Create refresh tokens table
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    replaced_by UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for refresh tokens
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE refresh_tokens IS 'Stores rotating refresh tokens; every login starts a new token family';
+COMMENT ON COLUMN refresh_tokens.family_id IS 'Shared by all tokens descending from the same login';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 hash of the refresh token';
+COMMENT ON COLUMN refresh_tokens.rotated_at IS 'Timestamp when the token was exchanged for a new one (null if still current)';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'Timestamp when the token was revoked by logout or reuse detection';
+COMMENT ON COLUMN refresh_tokens.replaced_by IS 'The token issued when this one was rotated';
@@ -0,0 +1,5 @@
+-- Drop refresh token family constraint
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS refresh_tokens_family_id_fkey;
+
+-- Drop user sessions table
+DROP TABLE IF EXISTS user_session;
@@ -0,0 +1,30 @@
+-- Create user sessions table (one row per login / device)
+CREATE TABLE user_session (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_user_session_user_id ON user_session(user_id);
+
+-- Backfill sessions for refresh token families issued before this migration
+INSERT INTO user_session (id, user_id, created_at, last_seen_at)
+SELECT family_id, user_id, MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+-- Each refresh token family belongs to exactly one session
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey
+    FOREIGN KEY (family_id) REFERENCES user_session(id) ON DELETE CASCADE;
+
+-- Add comments for documentation
+COMMENT ON TABLE user_session IS 'Server-side session records; the id is the refresh token family and the sid claim of access tokens';
+COMMENT ON COLUMN user_session.user_agent IS 'User agent of the client that logged in';
+COMMENT ON COLUMN user_session.ip_address IS 'Client IP of the most recent activity';
+COMMENT ON COLUMN user_session.last_seen_at IS 'Last time the session was used (updated at most once per minute)';
+COMMENT ON COLUMN user_session.revoked_at IS 'Timestamp when the session was logged out or revoked (null if active)';
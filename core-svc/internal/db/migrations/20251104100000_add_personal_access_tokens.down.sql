@@ -0,0 +1,2 @@
+-- Drop personal access tokens table
+DROP TABLE IF EXISTS personal_access_token;
@@ -0,0 +1,24 @@
+-- Create personal access tokens table
+CREATE TABLE personal_access_token (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_personal_access_token_user_id ON personal_access_token(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE personal_access_token IS 'Named, scoped API tokens for scripts and API clients (sent as Authorization: Bearer)';
+COMMENT ON COLUMN personal_access_token.name IS 'User supplied label for the token';
+COMMENT ON COLUMN personal_access_token.token_prefix IS 'First characters of the token, shown so users can tell tokens apart';
+COMMENT ON COLUMN personal_access_token.token_hash IS 'SHA-256 hash of the token';
+COMMENT ON COLUMN personal_access_token.scopes IS 'Granted scopes (e.g., polls:read, polls:write, votes:write)';
+COMMENT ON COLUMN personal_access_token.last_used_at IS 'Last time the token authenticated a request (updated at most once per minute)';
+COMMENT ON COLUMN personal_access_token.revoked_at IS 'Timestamp when the token was revoked (null if active)';
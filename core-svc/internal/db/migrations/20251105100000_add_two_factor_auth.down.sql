@@ -0,0 +1,5 @@
+-- Drop recovery codes table
+DROP TABLE IF EXISTS user_recovery_code;
+
+-- Drop TOTP enrolment table
+DROP TABLE IF EXISTS user_totp;
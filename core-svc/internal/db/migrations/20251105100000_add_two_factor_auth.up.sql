@@ -0,0 +1,29 @@
+-- Create TOTP enrolment table (one row per user)
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES app_user(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create recovery codes table
+CREATE TABLE user_recovery_code (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_recovery_code_user_id ON user_recovery_code(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_totp IS 'TOTP (RFC 6238) two-factor enrolment';
+COMMENT ON COLUMN user_totp.secret IS 'Base32 encoded shared secret';
+COMMENT ON COLUMN user_totp.enabled_at IS 'Timestamp when enrolment was confirmed with a valid code (null while pending)';
+COMMENT ON COLUMN user_totp.last_used_step IS 'Time step of the last accepted code, so a code cannot be replayed';
+
+COMMENT ON TABLE user_recovery_code IS 'Single-use recovery codes for when the authenticator is unavailable';
+COMMENT ON COLUMN user_recovery_code.code_hash IS 'SHA-256 hash of the normalized recovery code';
+COMMENT ON COLUMN user_recovery_code.used_at IS 'Timestamp when the code was used (null if unused)';
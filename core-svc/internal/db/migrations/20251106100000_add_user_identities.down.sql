@@ -0,0 +1,2 @@
+-- Drop external identities table
+DROP TABLE IF EXISTS user_identity;
@@ -0,0 +1,19 @@
+-- Create external identities table (OIDC / OAuth2 logins linked to a user)
+CREATE TABLE user_identity (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identity_user_id ON user_identity(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_identity IS 'External identities (OIDC providers) linked to a user';
+COMMENT ON COLUMN user_identity.provider IS 'Configured provider name (e.g., google)';
+COMMENT ON COLUMN user_identity.subject IS 'Stable subject identifier (sub claim) at the provider';
+COMMENT ON COLUMN user_identity.email IS 'Email reported by the provider at link time';
@@ -0,0 +1,21 @@
+-- Create magic link tokens table (passwordless email sign-in)
+CREATE TABLE magic_link_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    browser_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for magic link tokens
+CREATE INDEX idx_magic_link_tokens_user_id ON magic_link_tokens(user_id);
+CREATE INDEX idx_magic_link_tokens_expires_at ON magic_link_tokens(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE magic_link_tokens IS 'Stores one-time tokens for passwordless sign-in';
+COMMENT ON COLUMN magic_link_tokens.token_hash IS 'SHA-256 hash of the token sent by email';
+COMMENT ON COLUMN magic_link_tokens.browser_hash IS 'SHA-256 hash of the cookie set on the browser that requested the link';
+COMMENT ON COLUMN magic_link_tokens.expires_at IS 'Token expiration time (15 minutes from creation)';
+COMMENT ON COLUMN magic_link_tokens.used_at IS 'Timestamp when token was used (null if unused)';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_lockout;
+DROP TABLE IF EXISTS login_attempt;
@@ -0,0 +1,36 @@
+-- Create login attempts table (every password login, successful or not)
+CREATE TABLE login_attempt (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email TEXT NOT NULL,
+    user_id UUID REFERENCES app_user(id) ON DELETE CASCADE,
+    ip_address TEXT NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempt_ip_address_created_at ON login_attempt(ip_address, created_at);
+CREATE INDEX idx_login_attempt_user_id_created_at ON login_attempt(user_id, created_at);
+
+-- Create account lockout table (per-account failure counter and lock state)
+CREATE TABLE account_lockout (
+    user_id UUID PRIMARY KEY REFERENCES app_user(id) ON DELETE CASCADE,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    lockout_count INTEGER NOT NULL DEFAULT 0,
+    unlock_token_hash TEXT UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_lockout_locked_until ON account_lockout(locked_until);
+
+-- Add comments for documentation
+COMMENT ON TABLE login_attempt IS 'Password login attempts, used for per-IP throttling and admin review';
+COMMENT ON COLUMN login_attempt.user_id IS 'Account the email belongs to (null for unknown emails)';
+
+COMMENT ON TABLE account_lockout IS 'Failed login state per account; removed after a successful login';
+COMMENT ON COLUMN account_lockout.failed_attempts IS 'Consecutive failed logins since the last success or lockout';
+COMMENT ON COLUMN account_lockout.locked_until IS 'Logins are refused until this time (null if not locked)';
+COMMENT ON COLUMN account_lockout.lockout_count IS 'Lockouts since the last successful login, doubles the lockout duration';
+COMMENT ON COLUMN account_lockout.unlock_token_hash IS 'SHA-256 hash of the token in the unlock email';
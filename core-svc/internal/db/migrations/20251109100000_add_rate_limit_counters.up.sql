@@ -0,0 +1,16 @@
+-- Create rate limit counters table. UNLOGGED: counters are cheap to lose on a crash
+-- and skipping the WAL keeps the per-request writes fast
+CREATE UNLOGGED TABLE rate_limit_counter (
+    key TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX idx_rate_limit_counter_expires_at ON rate_limit_counter(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE rate_limit_counter IS 'Fixed window request counters for the shared rate limiter';
+COMMENT ON COLUMN rate_limit_counter.key IS 'Policy name and client key (IP, user or token)';
+COMMENT ON COLUMN rate_limit_counter.expires_at IS 'When the counter no longer affects rate limiting (two windows after it starts)';
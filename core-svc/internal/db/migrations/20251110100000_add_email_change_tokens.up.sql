@@ -0,0 +1,21 @@
+-- Create email change tokens table (self-service email change)
+CREATE TABLE email_change_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for email change tokens
+CREATE INDEX idx_email_change_tokens_user_id ON email_change_tokens(user_id);
+CREATE INDEX idx_email_change_tokens_expires_at ON email_change_tokens(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE email_change_tokens IS 'Stores pending email address changes until the new address is confirmed';
+COMMENT ON COLUMN email_change_tokens.new_email IS 'The address the user asked to switch to';
+COMMENT ON COLUMN email_change_tokens.token_hash IS 'SHA-256 hash of the token sent to the new address';
+COMMENT ON COLUMN email_change_tokens.expires_at IS 'Token expiration time (24 hours from creation)';
+COMMENT ON COLUMN email_change_tokens.used_at IS 'Timestamp when the change was confirmed (null if pending)';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS account_deletion_request;
+
+-- anonymised ballots can't be attributed to anyone again
+DELETE FROM votes WHERE user_id IS NULL;
+ALTER TABLE votes ALTER COLUMN user_id SET NOT NULL;
+
+COMMENT ON COLUMN votes.user_id IS NULL;
@@ -0,0 +1,20 @@
+-- Votes can outlive their voter as anonymised ballots
+ALTER TABLE votes ALTER COLUMN user_id DROP NOT NULL;
+
+COMMENT ON COLUMN votes.user_id IS 'The voter (null for anonymised ballots kept after the voter deleted their account)';
+
+-- Create account deletion requests table (self-service deletion with a grace period)
+CREATE TABLE account_deletion_request (
+    user_id UUID PRIMARY KEY REFERENCES app_user(id) ON DELETE CASCADE,
+    keep_votes BOOLEAN NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create index for finding due deletions
+CREATE INDEX idx_account_deletion_request_scheduled_for ON account_deletion_request(scheduled_for);
+
+-- Add comments for documentation
+COMMENT ON TABLE account_deletion_request IS 'Accounts scheduled for deletion; the user can cancel until scheduled_for';
+COMMENT ON COLUMN account_deletion_request.keep_votes IS 'Keep votes on other users polls as anonymised ballots instead of removing them';
+COMMENT ON COLUMN account_deletion_request.scheduled_for IS 'When the account will be deleted (end of the grace period)';
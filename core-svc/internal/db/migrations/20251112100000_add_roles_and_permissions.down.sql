@@ -0,0 +1,12 @@
+ALTER TABLE app_user DROP CONSTRAINT IF EXISTS app_user_role_fkey;
+
+-- Moderators and custom roles fall back to regular users
+UPDATE app_user SET role = 'user' WHERE role NOT IN ('user', 'admin');
+
+CREATE TYPE user_role AS ENUM ('user', 'admin');
+
+ALTER TABLE app_user ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE app_user ALTER COLUMN role TYPE user_role USING role::user_role;
+ALTER TABLE app_user ALTER COLUMN role SET DEFAULT 'user';
+
+DROP TABLE IF EXISTS app_role;
@@ -0,0 +1,29 @@
+-- Roles become rows instead of an enum so admins can define custom roles
+CREATE TABLE app_role (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    built_in BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Built-in roles; their permissions are defined in code
+INSERT INTO app_role (name, description, built_in) VALUES
+    ('user', 'Regular account', true),
+    ('moderator', 'Moderates polls and reviews the audit log', true),
+    ('admin', 'Full access', true);
+
+-- Switch app_user.role from the enum to a reference to app_role
+ALTER TABLE app_user ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE app_user ALTER COLUMN role TYPE TEXT USING role::text;
+ALTER TABLE app_user ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE app_user ADD CONSTRAINT app_user_role_fkey FOREIGN KEY (role) REFERENCES app_role(name);
+
+DROP TYPE user_role;
+
+-- Add comments for documentation
+COMMENT ON TABLE app_role IS 'Roles that can be assigned to users; each grants a set of permissions';
+COMMENT ON COLUMN app_role.permissions IS 'Permissions granted by a custom role (e.g. poll.close.any); ignored for built-in roles';
+COMMENT ON COLUMN app_role.built_in IS 'Built-in roles (user, moderator, admin) cannot be edited or deleted';
@@ -0,0 +1,15 @@
+-- Create user suspensions table (one suspension per user, replaced when suspended again;
+-- the history is in the audit log)
+CREATE TABLE user_suspension (
+    user_id UUID PRIMARY KEY REFERENCES app_user(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    suspended_by UUID REFERENCES app_user(id) ON DELETE SET NULL,
+    suspended_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_suspension IS 'Suspended accounts; the user cannot sign in or use the API while suspended, their content is kept';
+COMMENT ON COLUMN user_suspension.reason IS 'Why the account was suspended, shown to the user';
+COMMENT ON COLUMN user_suspension.suspended_by IS 'The admin or moderator who suspended the account';
+COMMENT ON COLUMN user_suspension.suspended_until IS 'When the suspension ends (null for a permanent ban)';
@@ -0,0 +1,3 @@
+ALTER TABLE user_session
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS impersonator_id;
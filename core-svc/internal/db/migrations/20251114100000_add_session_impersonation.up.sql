@@ -0,0 +1,8 @@
+-- Impersonation sessions: an admin acting as another user for a limited time
+ALTER TABLE user_session
+    ADD COLUMN impersonator_id UUID REFERENCES app_user(id) ON DELETE CASCADE,
+    ADD COLUMN expires_at TIMESTAMPTZ;
+
+-- Add comments for documentation
+COMMENT ON COLUMN user_session.impersonator_id IS 'The admin acting as the user (null for the user''s own sessions)';
+COMMENT ON COLUMN user_session.expires_at IS 'When the session ends on its own (null for sessions that last until revoked)';
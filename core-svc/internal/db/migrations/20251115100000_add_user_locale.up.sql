@@ -0,0 +1,5 @@
+-- Language of the emails sent to the user; the supported locales are defined in code
+ALTER TABLE app_user ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
+
+-- Add comments for documentation
+COMMENT ON COLUMN app_user.locale IS 'Preferred language of emails (e.g. en, ru)';
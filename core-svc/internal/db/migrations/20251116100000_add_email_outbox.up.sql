@@ -0,0 +1,36 @@
+-- Create email outbox table (emails are written in the same transaction as the change that
+-- triggers them and delivered by a background worker)
+CREATE TABLE email_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES app_user(id) ON DELETE CASCADE,
+    template TEXT NOT NULL,
+    sender TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    last_error TEXT,
+    provider_message_id TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create index for the worker picking up due emails
+CREATE INDEX idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status = 'pending';
+-- Create index for listing emails by status
+CREATE INDEX idx_email_outbox_status_created_at ON email_outbox(status, created_at DESC);
+
+-- Add comments for documentation
+COMMENT ON TABLE email_outbox IS 'Emails waiting for or done with delivery by the outbox worker';
+COMMENT ON COLUMN email_outbox.template IS 'Name of the template the email was rendered from';
+COMMENT ON COLUMN email_outbox.html_body IS 'Rendered HTML body (cleared once sent, it may contain one-time links)';
+COMMENT ON COLUMN email_outbox.text_body IS 'Rendered plain text body (cleared once sent, it may contain one-time links)';
+COMMENT ON COLUMN email_outbox.status IS 'pending until delivered (sent) or out of attempts (failed)';
+COMMENT ON COLUMN email_outbox.attempts IS 'Delivery attempts made so far';
+COMMENT ON COLUMN email_outbox.next_attempt_at IS 'When the worker may try the email next (also the lease while an attempt is running)';
+COMMENT ON COLUMN email_outbox.last_error IS 'Error of the most recent failed attempt';
+COMMENT ON COLUMN email_outbox.provider_message_id IS 'Message ID returned by the mail provider';
@@ -0,0 +1,5 @@
+-- Reply-To address of the email; empty when replies aren't read
+ALTER TABLE email_outbox ADD COLUMN reply_to TEXT NOT NULL DEFAULT '';
+
+-- Add comments for documentation
+COMMENT ON COLUMN email_outbox.reply_to IS 'Reply-To address (empty when replies are not read)';
@@ -0,0 +1,2 @@
+ALTER TABLE email_outbox DROP COLUMN IF EXISTS unsubscribe_url;
+DROP TABLE IF EXISTS notification_preference;
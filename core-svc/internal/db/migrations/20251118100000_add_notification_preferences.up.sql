@@ -0,0 +1,18 @@
+-- Create notification preferences table (one row per user and category the user changed;
+-- categories without a row use the default from code)
+CREATE TABLE notification_preference (
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category)
+);
+
+-- Notification emails carry a one-click unsubscribe link (RFC 8058)
+ALTER TABLE email_outbox ADD COLUMN unsubscribe_url TEXT NOT NULL DEFAULT '';
+
+-- Add comments for documentation
+COMMENT ON TABLE notification_preference IS 'Per-user opt-outs (and opt-ins) for notification email categories';
+COMMENT ON COLUMN notification_preference.category IS 'Notification category (e.g. poll_results, poll_reminders)';
+COMMENT ON COLUMN notification_preference.enabled IS 'Whether emails of this category are sent to the user';
+COMMENT ON COLUMN email_outbox.unsubscribe_url IS 'One-click unsubscribe URL sent in List-Unsubscribe (empty for transactional emails)';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS poll_results_notice;
+DROP TABLE IF EXISTS poll_reminder;
+DROP TABLE IF EXISTS poll_invitee;
+DROP TABLE IF EXISTS poll_result_subscription;
@@ -0,0 +1,44 @@
+-- Voters who asked to be emailed the results of a poll when it closes
+CREATE TABLE poll_result_subscription (
+    poll_id UUID NOT NULL REFERENCES poll(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (poll_id, user_id)
+);
+
+-- Users the owner invited to a poll; they are reminded before it closes
+CREATE TABLE poll_invitee (
+    poll_id UUID NOT NULL REFERENCES poll(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE INDEX idx_poll_invitee_user_id ON poll_invitee(user_id);
+
+-- Reminder settings of polls whose owner turned reminders on
+CREATE TABLE poll_reminder (
+    poll_id UUID PRIMARY KEY REFERENCES poll(id) ON DELETE CASCADE,
+    hours_before INTEGER NOT NULL CHECK (hours_before > 0),
+    sent_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Closed polls whose results were emailed; reopening a poll removes its row
+CREATE TABLE poll_results_notice (
+    poll_id UUID PRIMARY KEY REFERENCES poll(id) ON DELETE CASCADE,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Polls that closed before results emails existed are not announced
+INSERT INTO poll_results_notice (poll_id)
+SELECT id FROM poll
+WHERE closed OR (expires_at IS NOT NULL AND expires_at <= NOW());
+
+-- Add comments for documentation
+COMMENT ON TABLE poll_result_subscription IS 'Voters who opted in to the results email of a poll';
+COMMENT ON TABLE poll_invitee IS 'Users invited to vote on a poll';
+COMMENT ON TABLE poll_reminder IS 'Reminder emails sent to invitees who have not voted before a poll expires';
+COMMENT ON COLUMN poll_reminder.hours_before IS 'How many hours before expires_at the reminder is sent';
+COMMENT ON COLUMN poll_reminder.sent_at IS 'When the reminder was sent (null until then; cleared when the expiration changes)';
+COMMENT ON TABLE poll_results_notice IS 'Closed polls whose results email was sent';
@@ -0,0 +1,17 @@
+-- Failed second-factor attempts per MFA challenge token (the token's jti); a row is only
+-- created once a code is wrong
+CREATE TABLE mfa_challenge (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_challenge_expires_at ON mfa_challenge(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE mfa_challenge IS 'Failed 2FA codes entered against one MFA challenge token';
+COMMENT ON COLUMN mfa_challenge.id IS 'jti of the MFA challenge token';
+COMMENT ON COLUMN mfa_challenge.failed_attempts IS 'Wrong codes entered with this token; the token is refused once the limit is reached';
+COMMENT ON COLUMN mfa_challenge.expires_at IS 'Expiry of the MFA challenge token, after which the row can be deleted';
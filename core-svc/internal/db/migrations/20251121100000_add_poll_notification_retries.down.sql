@@ -0,0 +1,13 @@
+-- unsent notices are dropped so their polls are announced again
+DELETE FROM poll_results_notice WHERE sent_at IS NULL;
+
+ALTER TABLE poll_results_notice
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts,
+    ALTER COLUMN sent_at SET NOT NULL;
+
+ALTER TABLE poll_reminder
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts;
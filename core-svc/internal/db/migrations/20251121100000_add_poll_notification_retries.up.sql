@@ -0,0 +1,21 @@
+-- A poll whose emails fail is retried with backoff instead of blocking the polls after it
+ALTER TABLE poll_reminder
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+ALTER TABLE poll_results_notice
+    ALTER COLUMN sent_at DROP NOT NULL,
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+-- Add comments for documentation
+COMMENT ON COLUMN poll_reminder.attempts IS 'Failed attempts to send the reminder; reset when the reminder or the expiration changes';
+COMMENT ON COLUMN poll_reminder.last_error IS 'Error of the last failed attempt';
+COMMENT ON COLUMN poll_reminder.next_attempt_at IS 'Earliest time of the next attempt after a failure';
+
+COMMENT ON COLUMN poll_results_notice.sent_at IS 'When the results email was sent (null while failed attempts are retried)';
+COMMENT ON COLUMN poll_results_notice.attempts IS 'Failed attempts to send the results email';
+COMMENT ON COLUMN poll_results_notice.last_error IS 'Error of the last failed attempt';
+COMMENT ON COLUMN poll_results_notice.next_attempt_at IS 'Earliest time of the next attempt after a failure';
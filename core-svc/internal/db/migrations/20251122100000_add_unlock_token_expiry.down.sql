@@ -0,0 +1,2 @@
+ALTER TABLE account_lockout
+    DROP COLUMN IF EXISTS unlock_expires_at;
@@ -0,0 +1,15 @@
+-- An unlock link only works while its lock lasts, so an old email can't be replayed
+ALTER TABLE account_lockout
+    ADD COLUMN unlock_expires_at TIMESTAMPTZ;
+
+UPDATE account_lockout
+SET unlock_expires_at = locked_until
+WHERE unlock_token_hash IS NOT NULL;
+
+UPDATE account_lockout
+SET unlock_token_hash = NULL,
+    unlock_expires_at = NULL
+WHERE unlock_expires_at IS NULL OR unlock_expires_at <= NOW();
+
+-- Add comments for documentation
+COMMENT ON COLUMN account_lockout.unlock_expires_at IS 'The unlock token is refused after this time (the end of the lock)';
@@ -0,0 +1,23 @@
+-- name: UpsertAccountDeletionRequest :one
+INSERT INTO account_deletion_request (user_id, keep_votes, scheduled_for)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET keep_votes = EXCLUDED.keep_votes
+RETURNING user_id, keep_votes, scheduled_for, created_at;
+
+-- name: GetAccountDeletionRequest :one
+SELECT user_id, keep_votes, scheduled_for, created_at
+FROM account_deletion_request
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: DeleteAccountDeletionRequest :execrows
+DELETE FROM account_deletion_request
+WHERE user_id = $1;
+
+-- name: ListDueAccountDeletionRequests :many
+SELECT user_id, keep_votes, scheduled_for, created_at
+FROM account_deletion_request
+WHERE scheduled_for <= NOW()
+ORDER BY scheduled_for
+LIMIT $1;
@@ -0,0 +1,47 @@
+-- name: GetAccountLockout :one
+SELECT user_id, failed_attempts, last_failed_at, locked_until, lockout_count, unlock_token_hash, created_at, updated_at, unlock_expires_at
+FROM account_lockout
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: GetAccountLockoutByUnlockTokenHash :one
+SELECT user_id, failed_attempts, last_failed_at, locked_until, lockout_count, unlock_token_hash, created_at, updated_at, unlock_expires_at
+FROM account_lockout
+WHERE unlock_token_hash = $1
+  AND unlock_expires_at > NOW()
+LIMIT 1;
+
+-- name: RecordFailedLogin :one
+INSERT INTO account_lockout (user_id, failed_attempts, last_failed_at)
+VALUES ($1, 1, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET failed_attempts = account_lockout.failed_attempts + 1,
+    last_failed_at = NOW(),
+    unlock_token_hash = CASE WHEN account_lockout.unlock_expires_at > NOW() THEN account_lockout.unlock_token_hash END,
+    unlock_expires_at = CASE WHEN account_lockout.unlock_expires_at > NOW() THEN account_lockout.unlock_expires_at END,
+    updated_at = NOW()
+RETURNING user_id, failed_attempts, last_failed_at, locked_until, lockout_count, unlock_token_hash, created_at, updated_at, unlock_expires_at;
+
+-- name: LockAccount :one
+UPDATE account_lockout
+SET locked_until = $2,
+    unlock_token_hash = $3,
+    unlock_expires_at = $2,
+    lockout_count = lockout_count + 1,
+    failed_attempts = 0,
+    updated_at = NOW()
+WHERE user_id = $1
+RETURNING user_id, failed_attempts, last_failed_at, locked_until, lockout_count, unlock_token_hash, created_at, updated_at, unlock_expires_at;
+
+-- name: ClearAccountLockout :execrows
+DELETE FROM account_lockout
+WHERE user_id = $1;
+
+-- name: ListAccountLockouts :many
+SELECT al.user_id, u.name, u.email, al.failed_attempts, al.last_failed_at, al.locked_until, al.lockout_count, al.updated_at
+FROM account_lockout al
+JOIN app_user u ON u.id = al.user_id
+WHERE al.locked_until > NOW()
+   OR al.failed_attempts > 0
+ORDER BY al.updated_at DESC
+LIMIT $1 OFFSET $2;
@@ -0,0 +1,30 @@
+-- name: ListRoles :many
+SELECT id, name, description, permissions, built_in, created_at, updated_at
+FROM app_role
+ORDER BY built_in DESC, name;
+
+-- name: GetRoleByName :one
+SELECT id, name, description, permissions, built_in, created_at, updated_at
+FROM app_role
+WHERE name = $1
+LIMIT 1;
+
+-- name: CreateRole :one
+INSERT INTO app_role (name, description, permissions)
+VALUES ($1, $2, $3)
+RETURNING id, name, description, permissions, built_in, created_at, updated_at;
+
+-- Built-in roles are defined in code and can't be changed
+-- name: UpdateRole :one
+UPDATE app_role
+SET description = $2,
+    permissions = $3,
+    updated_at = NOW()
+WHERE name = $1
+  AND NOT built_in
+RETURNING id, name, description, permissions, built_in, created_at, updated_at;
+
+-- name: DeleteRole :execrows
+DELETE FROM app_role
+WHERE name = $1
+  AND NOT built_in;
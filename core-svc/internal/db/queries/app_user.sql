@@ -4,7 +4,7 @@ INSERT INTO app_user (name, email, password_hash)
 VALUES ($1, $2, $3) RETURNING id, name, email, role, email_verified_at, created_at;
 
 -- name: GetUserByID :one
-SELECT id, name, email, role, email_verified_at, created_at FROM app_user
+SELECT id, name, email, role, email_verified_at, created_at, locale FROM app_user
 WHERE id = $1 LIMIT 1;
 
 -- name: GetPasswordHashByEmail :one
@@ -36,6 +36,14 @@ SET name = $2
 WHERE id = $1
 RETURNING id, name, email, role, email_verified_at, created_at;
 
+-- Swap in a confirmed new email; confirming it proves ownership, so it counts as verified
+-- name: UpdateUserEmail :one
+UPDATE app_user
+SET email = $2,
+    email_verified_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, role, email_verified_at, created_at;
+
 -- Admin: Update user password hash
 -- name: UpdateUserPasswordHash :exec
 UPDATE app_user
@@ -50,10 +58,6 @@ DELETE FROM app_user WHERE id = $1;
 -- name: CountUsersByRole :one
 SELECT COUNT(*) FROM app_user WHERE role = $1;
 
--- Admin: Check if user is admin
--- name: IsUserAdmin :one
-SELECT role = 'admin' AS is_admin FROM app_user WHERE id = $1;
-
 -- Admin: Get user with password hash (for authentication)
 -- name: GetUserWithPasswordByID :one
 SELECT id, name, email, role, password_hash, email_verified_at, created_at
@@ -80,3 +84,12 @@ SET email_verified_at = CASE
 END
 WHERE id = $1
 RETURNING id, name, email, role, email_verified_at, created_at;
+
+-- name: GetUserLocale :one
+SELECT locale FROM app_user
+WHERE id = $1 LIMIT 1;
+
+-- name: UpdateUserLocale :exec
+UPDATE app_user
+SET locale = $2
+WHERE id = $1;
@@ -93,3 +93,11 @@ SELECT
 FROM audit_log al
 LEFT JOIN app_user u ON al.actor_user_id = u.id
 WHERE al.id = $1;
+
+-- Everything the user did or that was done to their account (data export)
+-- name: ListAuditLogsForUser :many
+SELECT id, actor_user_id, action, subject_type, subject_id, meta, created_at
+FROM audit_log
+WHERE actor_user_id = sqlc.arg(user_id)
+   OR (subject_type = 'user' AND subject_id = sqlc.arg(user_id))
+ORDER BY created_at DESC;
@@ -0,0 +1,26 @@
+-- name: CreateEmailChangeToken :one
+INSERT INTO email_change_tokens (user_id, new_email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, new_email, token_hash, expires_at, used_at, created_at;
+
+-- name: GetEmailChangeTokenByHash :one
+SELECT id, user_id, new_email, token_hash, expires_at, used_at, created_at
+FROM email_change_tokens
+WHERE token_hash = $1
+LIMIT 1;
+
+-- name: MarkEmailChangeTokenUsed :execrows
+UPDATE email_change_tokens
+SET used_at = NOW()
+WHERE id = $1
+  AND used_at IS NULL;
+
+-- name: CountRecentEmailChangeTokens :one
+SELECT COUNT(*)
+FROM email_change_tokens
+WHERE user_id = $1
+  AND created_at > $2;
+
+-- name: DeleteExpiredEmailChangeTokens :exec
+DELETE FROM email_change_tokens
+WHERE expires_at < NOW();
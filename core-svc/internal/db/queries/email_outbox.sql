@@ -0,0 +1,68 @@
+-- name: EnqueueEmail :one
+INSERT INTO email_outbox (user_id, template, sender, reply_to, recipient, subject, html_body, text_body, unsubscribe_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id;
+
+-- Leases due emails by moving next_attempt_at to lease_until, so other workers skip them
+-- while they are being sent and pick them up again if this worker dies mid-attempt
+-- name: ClaimDueEmails :many
+UPDATE email_outbox
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM email_outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, template, sender, recipient, subject, html_body, text_body, status, attempts, next_attempt_at, last_attempt_at, last_error, provider_message_id, sent_at, created_at, reply_to, unsubscribe_url;
+
+-- name: MarkEmailSent :exec
+UPDATE email_outbox
+SET status = 'sent',
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    last_error = NULL,
+    provider_message_id = $2,
+    sent_at = NOW(),
+    html_body = '',
+    text_body = ''
+WHERE id = $1;
+
+-- name: RecordEmailFailure :exec
+UPDATE email_outbox
+SET status = $2,
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    last_error = $3,
+    next_attempt_at = $4
+WHERE id = $1;
+
+-- name: GetEmailOutbox :one
+SELECT id, user_id, template, sender, recipient, subject, html_body, text_body, status, attempts, next_attempt_at, last_attempt_at, last_error, provider_message_id, sent_at, created_at, reply_to, unsubscribe_url
+FROM email_outbox
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListEmailOutboxByStatus :many
+SELECT id, user_id, template, sender, recipient, subject, html_body, text_body, status, attempts, next_attempt_at, last_attempt_at, last_error, provider_message_id, sent_at, created_at, reply_to, unsubscribe_url
+FROM email_outbox
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountEmailOutboxByStatus :one
+SELECT COUNT(*) FROM email_outbox
+WHERE status = $1;
+
+-- Puts a failed email back in the queue with a fresh set of attempts
+-- name: RetryEmail :execrows
+UPDATE email_outbox
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW()
+WHERE id = $1 AND status = 'failed';
+
+-- name: DeleteSentEmailsBefore :execrows
+DELETE FROM email_outbox
+WHERE status = 'sent' AND sent_at < $1;
@@ -49,3 +49,9 @@ FROM email_verify_tokens
 WHERE user_id = $1
   AND used_at IS NULL
   AND expires_at > NOW();
+
+-- name: CountRecentEmailVerifyTokens :one
+SELECT COUNT(*)
+FROM email_verify_tokens
+WHERE user_id = $1
+  AND created_at > $2;
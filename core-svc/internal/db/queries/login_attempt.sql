@@ -0,0 +1,17 @@
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempt (email, user_id, ip_address, succeeded)
+VALUES ($1, $2, $3, $4);
+
+-- name: CountRecentFailedLoginAttemptsByIP :one
+SELECT COUNT(*)
+FROM login_attempt
+WHERE ip_address = $1
+  AND succeeded = FALSE
+  AND created_at > $2;
+
+-- name: ListRecentLoginAttemptsByUserID :many
+SELECT id, email, user_id, ip_address, succeeded, created_at
+FROM login_attempt
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
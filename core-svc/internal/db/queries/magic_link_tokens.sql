@@ -0,0 +1,26 @@
+-- name: CreateMagicLinkToken :one
+INSERT INTO magic_link_tokens (user_id, token_hash, browser_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, token_hash, browser_hash, expires_at, used_at, created_at;
+
+-- name: GetMagicLinkTokenByHash :one
+SELECT id, user_id, token_hash, browser_hash, expires_at, used_at, created_at
+FROM magic_link_tokens
+WHERE token_hash = $1
+LIMIT 1;
+
+-- name: MarkMagicLinkTokenUsed :execrows
+UPDATE magic_link_tokens
+SET used_at = NOW()
+WHERE id = $1
+  AND used_at IS NULL;
+
+-- name: CountRecentMagicLinkTokens :one
+SELECT COUNT(*)
+FROM magic_link_tokens
+WHERE user_id = $1
+  AND created_at > $2;
+
+-- name: DeleteExpiredMagicLinkTokens :exec
+DELETE FROM magic_link_tokens
+WHERE expires_at < NOW();
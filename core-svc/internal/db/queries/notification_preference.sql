@@ -0,0 +1,18 @@
+-- name: ListNotificationPreferences :many
+SELECT user_id, category, enabled, updated_at
+FROM notification_preference
+WHERE user_id = $1
+ORDER BY category;
+
+-- name: GetNotificationPreference :one
+SELECT enabled
+FROM notification_preference
+WHERE user_id = $1 AND category = $2
+LIMIT 1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preference (user_id, category, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, category) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = NOW();
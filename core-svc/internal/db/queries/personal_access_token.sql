@@ -0,0 +1,29 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_token (user_id, name, token_prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM personal_access_token
+WHERE token_hash = $1
+LIMIT 1;
+
+-- name: ListPersonalAccessTokensByUserID :many
+SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM personal_access_token
+WHERE user_id = $1
+  AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_token
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_token
+SET revoked_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
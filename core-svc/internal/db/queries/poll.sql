@@ -61,8 +61,11 @@ SET closed = true
 WHERE id = $1
 RETURNING *;
 
--- Admin: Reopen a poll
+-- Admin: Reopen a poll; its results are emailed again when it closes
 -- name: ReopenPoll :one
+WITH cleared_notice AS (
+    DELETE FROM poll_results_notice WHERE poll_id = $1
+)
 UPDATE poll
 SET closed = false
 WHERE id = $1
@@ -117,8 +120,13 @@ WHERE id = $1;
 -- name: GetPollOwnerID :one
 SELECT user_id FROM poll WHERE id = $1;
 
--- Update poll expiration
+-- Update poll expiration; the reminder is sent again for the new time
 -- name: UpdatePollExpiration :one
+WITH reset_reminder AS (
+    UPDATE poll_reminder
+    SET sent_at = NULL, attempts = 0, last_error = NULL, next_attempt_at = NULL
+    WHERE poll_id = $1
+)
 UPDATE poll
 SET expires_at = $2
 WHERE id = $1
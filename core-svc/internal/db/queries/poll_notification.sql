@@ -0,0 +1,130 @@
+-- Invite the users with the given emails; the owner and unknown emails are skipped
+-- name: AddPollInvitees :execrows
+INSERT INTO poll_invitee (poll_id, user_id)
+SELECT sqlc.arg(poll_id), u.id
+FROM app_user u
+WHERE u.email = ANY(sqlc.arg(emails)::text[])
+  AND u.id <> (SELECT p.user_id FROM poll p WHERE p.id = sqlc.arg(poll_id))
+ON CONFLICT (poll_id, user_id) DO NOTHING;
+
+-- Marks the reminder of one poll that is due as sent and returns the poll with its failed
+-- attempts; concurrent workers skip reminders another worker is sending, and failed
+-- reminders wait for their next attempt
+-- name: ClaimDuePollReminder :one
+UPDATE poll_reminder
+SET sent_at = NOW()
+WHERE poll_id = (
+    SELECT r.poll_id
+    FROM poll_reminder r
+    JOIN poll p ON p.id = r.poll_id
+    WHERE r.sent_at IS NULL
+      AND r.attempts < sqlc.arg(max_attempts)
+      AND (r.next_attempt_at IS NULL OR r.next_attempt_at <= NOW())
+      AND NOT p.closed
+      AND p.expires_at > NOW()
+      AND p.expires_at - make_interval(hours => r.hours_before) <= NOW()
+    ORDER BY p.expires_at
+    LIMIT 1
+    FOR UPDATE OF r SKIP LOCKED
+)
+RETURNING poll_id, attempts;
+
+-- Marks one closed poll whose results were not emailed yet as announced and returns it
+-- with its failed attempts; concurrent workers skip polls another worker is announcing,
+-- and failed announcements wait for their next attempt
+-- name: ClaimPollResultsNotice :one
+INSERT INTO poll_results_notice (poll_id)
+SELECT p.id
+FROM poll p
+LEFT JOIN poll_results_notice n ON n.poll_id = p.id
+WHERE p.closed
+  AND (n.poll_id IS NULL
+       OR (n.sent_at IS NULL
+           AND n.attempts < sqlc.arg(max_attempts)
+           AND n.next_attempt_at <= NOW()))
+ORDER BY p.created_at
+LIMIT 1
+FOR UPDATE OF p SKIP LOCKED
+ON CONFLICT (poll_id) DO UPDATE
+SET sent_at = NOW()
+RETURNING poll_id, attempts;
+
+-- name: DeletePollInvitee :execrows
+DELETE FROM poll_invitee
+WHERE poll_id = $1 AND user_id = $2;
+
+-- name: DeletePollReminder :execrows
+DELETE FROM poll_reminder
+WHERE poll_id = $1;
+
+-- name: DeletePollResultSubscription :exec
+DELETE FROM poll_result_subscription
+WHERE poll_id = $1 AND user_id = $2;
+
+-- name: GetPollReminder :one
+SELECT * FROM poll_reminder
+WHERE poll_id = $1 LIMIT 1;
+
+-- name: ListPollInvitees :many
+SELECT u.id AS user_id, u.name, u.email, i.created_at,
+       EXISTS(
+           SELECT 1 FROM votes v
+           WHERE v.poll_id = i.poll_id AND v.user_id = i.user_id
+       ) AS voted
+FROM poll_invitee i
+JOIN app_user u ON u.id = i.user_id
+WHERE i.poll_id = $1
+ORDER BY i.created_at, u.email;
+
+-- Invitees who have not voted yet
+-- name: ListPollInviteesToRemind :many
+SELECT u.id AS user_id, u.name, u.email
+FROM poll_invitee i
+JOIN app_user u ON u.id = i.user_id
+WHERE i.poll_id = $1
+  AND NOT EXISTS (
+      SELECT 1 FROM votes v
+      WHERE v.poll_id = i.poll_id AND v.user_id = i.user_id
+  );
+
+-- name: ListPollResultSubscribers :many
+SELECT u.id AS user_id, u.name, u.email
+FROM poll_result_subscription s
+JOIN app_user u ON u.id = s.user_id
+WHERE s.poll_id = $1;
+
+-- Puts a claimed reminder back with the error and the time of the next attempt
+-- name: RecordPollReminderFailure :exec
+UPDATE poll_reminder
+SET sent_at = NULL,
+    attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE poll_id = $1;
+
+-- Puts a claimed results notice back with the error and the time of the next attempt
+-- name: RecordPollResultsNoticeFailure :exec
+UPDATE poll_results_notice
+SET sent_at = NULL,
+    attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE poll_id = $1;
+
+-- name: SubscribeToPollResults :exec
+INSERT INTO poll_result_subscription (poll_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (poll_id, user_id) DO NOTHING;
+
+-- Turn reminders on or change when they are sent; a changed reminder is sent again
+-- name: UpsertPollReminder :one
+INSERT INTO poll_reminder (poll_id, hours_before)
+VALUES ($1, $2)
+ON CONFLICT (poll_id) DO UPDATE
+SET hours_before = EXCLUDED.hours_before,
+    sent_at = NULL,
+    attempts = 0,
+    last_error = NULL,
+    next_attempt_at = NULL,
+    updated_at = NOW()
+RETURNING *;
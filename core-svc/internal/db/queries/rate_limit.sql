@@ -0,0 +1,16 @@
+-- name: IncrementRateLimitCounter :one
+INSERT INTO rate_limit_counter (key, window_start, count, expires_at)
+VALUES ($1, $2, 1, $3)
+ON CONFLICT (key, window_start) DO UPDATE
+SET count = rate_limit_counter.count + 1
+RETURNING count;
+
+-- name: GetRateLimitCount :one
+SELECT count
+FROM rate_limit_counter
+WHERE key = $1
+  AND window_start = $2;
+
+-- name: DeleteExpiredRateLimitCounters :exec
+DELETE FROM rate_limit_counter
+WHERE expires_at < NOW();
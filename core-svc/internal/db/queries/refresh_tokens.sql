@@ -0,0 +1,41 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, replaced_by, created_at;
+
+-- name: GetRefreshTokenByHash :one
+SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, replaced_by, created_at
+FROM refresh_tokens
+WHERE token_hash = $1
+LIMIT 1;
+
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW(),
+    replaced_by = $2
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokensByUserID :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeOtherRefreshTokensByUserID :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND family_id <> $2
+  AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :exec
+DELETE FROM refresh_tokens
+WHERE expires_at < NOW();
@@ -0,0 +1,67 @@
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret,
+    enabled_at = NULL,
+    last_used_step = 0,
+    created_at = NOW()
+RETURNING user_id, secret, enabled_at, last_used_step, created_at;
+
+-- name: GetUserTOTP :one
+SELECT user_id, secret, enabled_at, last_used_step, created_at
+FROM user_totp
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: EnableUserTOTP :exec
+UPDATE user_totp
+SET enabled_at = NOW()
+WHERE user_id = $1;
+
+-- name: UpdateUserTOTPLastUsedStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1
+  AND last_used_step < $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: InsertRecoveryCodes :copyfrom
+INSERT INTO user_recovery_code (user_id, code_hash) VALUES ($1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_code
+SET used_at = NOW()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM user_recovery_code
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesByUserID :exec
+DELETE FROM user_recovery_code
+WHERE user_id = $1;
+
+-- name: GetMFAChallengeFailedAttempts :one
+SELECT failed_attempts
+FROM mfa_challenge
+WHERE id = $1
+LIMIT 1;
+
+-- name: RecordMFAChallengeFailure :one
+INSERT INTO mfa_challenge (id, user_id, failed_attempts, expires_at)
+VALUES ($1, $2, 1, $3)
+ON CONFLICT (id) DO UPDATE
+SET failed_attempts = mfa_challenge.failed_attempts + 1
+RETURNING failed_attempts;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM mfa_challenge
+WHERE expires_at < NOW();
@@ -0,0 +1,27 @@
+-- name: GetUserIdentity :one
+SELECT id, user_id, provider, subject, email, created_at, last_login_at
+FROM user_identity
+WHERE provider = $1
+  AND subject = $2
+LIMIT 1;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identity (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, provider, subject, email, created_at, last_login_at;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identity
+SET last_login_at = NOW()
+WHERE id = $1;
+
+-- name: ListUserIdentitiesByUserID :many
+SELECT id, user_id, provider, subject, email, created_at, last_login_at
+FROM user_identity
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identity
+WHERE id = $1
+  AND user_id = $2;
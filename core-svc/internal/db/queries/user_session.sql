@@ -0,0 +1,50 @@
+-- name: CreateUserSession :one
+INSERT INTO user_session (user_id, user_agent, ip_address)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_id, expires_at;
+
+-- Impersonation sessions expire on their own and never get a refresh token
+-- name: CreateImpersonationSession :one
+INSERT INTO user_session (user_id, user_agent, ip_address, impersonator_id, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_id, expires_at;
+
+-- name: GetUserSession :one
+SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_id, expires_at
+FROM user_session
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListActiveUserSessions :many
+SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at, impersonator_id, expires_at
+FROM user_session
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY last_seen_at DESC;
+
+-- name: TouchUserSession :exec
+UPDATE user_session
+SET last_seen_at = NOW(),
+    ip_address = $2
+WHERE id = $1;
+
+-- name: RevokeUserSession :execrows
+UPDATE user_session
+SET revoked_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
+
+-- name: RevokeAllUserSessions :execrows
+UPDATE user_session
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserSessions :execrows
+UPDATE user_session
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND id <> $2
+  AND revoked_at IS NULL;
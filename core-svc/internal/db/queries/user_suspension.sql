@@ -0,0 +1,22 @@
+-- name: UpsertUserSuspension :one
+INSERT INTO user_suspension (user_id, reason, suspended_by, suspended_until)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET reason = EXCLUDED.reason,
+    suspended_by = EXCLUDED.suspended_by,
+    suspended_until = EXCLUDED.suspended_until,
+    created_at = NOW()
+RETURNING user_id, reason, suspended_by, suspended_until, created_at;
+
+-- Suspensions whose end date has passed no longer apply
+-- name: GetActiveUserSuspension :one
+SELECT user_id, reason, suspended_by, suspended_until, created_at
+FROM user_suspension
+WHERE user_id = $1
+  AND (suspended_until IS NULL OR suspended_until > NOW())
+LIMIT 1;
+
+-- name: DeleteUserSuspension :execrows
+DELETE FROM user_suspension
+WHERE user_id = $1
+  AND (suspended_until IS NULL OR suspended_until > NOW());
@@ -29,3 +29,22 @@ GROUP BY option_id;
 
 -- name: GetUserOptionIdByPollId :one
 SELECT option_id FROM votes WHERE poll_id = $1 AND user_id = $2;
+
+-- name: ListVotesByUserID :many
+SELECT v.id, v.poll_id, p.question, v.option_id, po.label AS option_label, v.created_at
+FROM votes v
+JOIN poll p ON p.id = v.poll_id
+JOIN poll_option po ON po.id = v.option_id
+WHERE v.user_id = $1
+ORDER BY v.created_at DESC;
+
+-- Detach the user's votes on other users' polls, keeping them as anonymous ballots
+-- name: AnonymizeVotesByUserID :execrows
+UPDATE votes
+SET user_id = NULL
+WHERE user_id = $1
+  AND poll_id NOT IN (SELECT id FROM poll WHERE user_id = $1);
+
+-- name: DeleteVotesByUserID :execrows
+DELETE FROM votes
+WHERE user_id = $1;
@@ -43,7 +43,7 @@ SELECT COUNT(*) FROM app_user WHERE role = $1
 `
 
 // Admin: Count users by role
-func (q *Queries) CountUsersByRole(ctx context.Context, role UserRole) (int64, error) {
+func (q *Queries) CountUsersByRole(ctx context.Context, role string) (int64, error) {
 	row := q.db.QueryRow(ctx, countUsersByRole, role)
 	var count int64
 	err := row.Scan(&count)
@@ -65,7 +65,7 @@ type CreateUserRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -120,7 +120,7 @@ type GetUserByEmailRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -140,7 +140,7 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, role, email_verified_at, created_at FROM app_user
+SELECT id, name, email, role, email_verified_at, created_at, locale FROM app_user
 WHERE id = $1 LIMIT 1
 `
 
@@ -148,9 +148,10 @@ type GetUserByIDRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	Locale          string             `json:"locale"`
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
@@ -163,10 +164,23 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow
 		&i.Role,
 		&i.EmailVerifiedAt,
 		&i.CreatedAt,
+		&i.Locale,
 	)
 	return i, err
 }
 
+const getUserLocale = `-- name: GetUserLocale :one
+SELECT locale FROM app_user
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserLocale(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getUserLocale, id)
+	var locale string
+	err := row.Scan(&locale)
+	return locale, err
+}
+
 const getUserWithPasswordByID = `-- name: GetUserWithPasswordByID :one
 SELECT id, name, email, role, password_hash, email_verified_at, created_at
 FROM app_user
@@ -177,7 +191,7 @@ type GetUserWithPasswordByIDRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	PasswordHash    string             `json:"password_hash"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
@@ -199,18 +213,6 @@ func (q *Queries) GetUserWithPasswordByID(ctx context.Context, id uuid.UUID) (Ge
 	return i, err
 }
 
-const isUserAdmin = `-- name: IsUserAdmin :one
-SELECT role = 'admin' AS is_admin FROM app_user WHERE id = $1
-`
-
-// Admin: Check if user is admin
-func (q *Queries) IsUserAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
-	row := q.db.QueryRow(ctx, isUserAdmin, id)
-	var is_admin bool
-	err := row.Scan(&is_admin)
-	return is_admin, err
-}
-
 const listAllUsers = `-- name: ListAllUsers :many
 SELECT id, name, email, role, email_verified_at, created_at
 FROM app_user
@@ -227,7 +229,7 @@ type ListAllUsersRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -280,7 +282,7 @@ type SetEmailVerificationStatusRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -300,6 +302,43 @@ func (q *Queries) SetEmailVerificationStatus(ctx context.Context, arg SetEmailVe
 	return i, err
 }
 
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE app_user
+SET email = $2,
+    email_verified_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, role, email_verified_at, created_at
+`
+
+type UpdateUserEmailParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+type UpdateUserEmailRow struct {
+	ID              uuid.UUID          `json:"id"`
+	Name            string             `json:"name"`
+	Email           string             `json:"email"`
+	Role            string             `json:"role"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
+// Swap in a confirmed new email; confirming it proves ownership, so it counts as verified
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (UpdateUserEmailRow, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i UpdateUserEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const updateUserName = `-- name: UpdateUserName :one
 UPDATE app_user
 SET name = $2
@@ -316,7 +355,7 @@ type UpdateUserNameRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -336,6 +375,22 @@ func (q *Queries) UpdateUserName(ctx context.Context, arg UpdateUserNameParams)
 	return i, err
 }
 
+const updateUserLocale = `-- name: UpdateUserLocale :exec
+UPDATE app_user
+SET locale = $2
+WHERE id = $1
+`
+
+type UpdateUserLocaleParams struct {
+	ID     uuid.UUID `json:"id"`
+	Locale string    `json:"locale"`
+}
+
+func (q *Queries) UpdateUserLocale(ctx context.Context, arg UpdateUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, updateUserLocale, arg.ID, arg.Locale)
+	return err
+}
+
 const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :exec
 UPDATE app_user
 SET password_hash = $2
@@ -362,14 +417,14 @@ RETURNING id, name, email, role, email_verified_at, created_at
 
 type UpdateUserRoleParams struct {
 	ID   uuid.UUID `json:"id"`
-	Role UserRole  `json:"role"`
+	Role string    `json:"role"`
 }
 
 type UpdateUserRoleRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -398,3 +398,40 @@ func (q *Queries) ListAuditLogsBySubject(ctx context.Context, arg ListAuditLogsB
 	}
 	return items, nil
 }
+
+const listAuditLogsForUser = `-- name: ListAuditLogsForUser :many
+SELECT id, actor_user_id, action, subject_type, subject_id, meta, created_at
+FROM audit_log
+WHERE actor_user_id = $1
+   OR (subject_type = 'user' AND subject_id = $1)
+ORDER BY created_at DESC
+`
+
+// Everything the user did or that was done to their account (data export)
+func (q *Queries) ListAuditLogsForUser(ctx context.Context, userID uuid.UUID) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorUserID,
+			&i.Action,
+			&i.SubjectType,
+			&i.SubjectID,
+			&i.Meta,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
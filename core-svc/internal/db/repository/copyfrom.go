@@ -42,3 +42,36 @@ func (r iteratorForInsertPollOptions) Err() error {
 func (q *Queries) InsertPollOptions(ctx context.Context, arg []InsertPollOptionsParams) (int64, error) {
 	return q.db.CopyFrom(ctx, []string{"poll_option"}, []string{"poll_id", "label"}, &iteratorForInsertPollOptions{rows: arg})
 }
+
+// iteratorForInsertRecoveryCodes implements pgx.CopyFromSource.
+type iteratorForInsertRecoveryCodes struct {
+	rows                 []InsertRecoveryCodesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertRecoveryCodes) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertRecoveryCodes) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].UserID,
+		r.rows[0].CodeHash,
+	}, nil
+}
+
+func (r iteratorForInsertRecoveryCodes) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertRecoveryCodes(ctx context.Context, arg []InsertRecoveryCodesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"user_recovery_code"}, []string{"user_id", "code_hash"}, &iteratorForInsertRecoveryCodes{rows: arg})
+}
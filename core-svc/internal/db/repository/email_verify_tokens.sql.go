@@ -13,6 +13,25 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countRecentEmailVerifyTokens = `-- name: CountRecentEmailVerifyTokens :one
+SELECT COUNT(*)
+FROM email_verify_tokens
+WHERE user_id = $1
+  AND created_at > $2
+`
+
+type CountRecentEmailVerifyTokensParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CountRecentEmailVerifyTokens(ctx context.Context, arg CountRecentEmailVerifyTokensParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentEmailVerifyTokens, arg.UserID, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countUnusedTokensByUserID = `-- name: CountUnusedTokensByUserID :one
 SELECT COUNT(*)
 FROM email_verify_tokens
@@ -164,7 +183,7 @@ type SetUserEmailVerifiedRow struct {
 	ID              uuid.UUID          `json:"id"`
 	Name            string             `json:"name"`
 	Email           string             `json:"email"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 }
@@ -5,54 +5,51 @@
 package repository
 
 import (
-	"database/sql/driver"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type UserRole string
-
-const (
-	UserRoleUser  UserRole = "user"
-	UserRoleAdmin UserRole = "admin"
-)
-
-func (e *UserRole) Scan(src interface{}) error {
-	switch s := src.(type) {
-	case []byte:
-		*e = UserRole(s)
-	case string:
-		*e = UserRole(s)
-	default:
-		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
-	}
-	return nil
-}
-
-type NullUserRole struct {
-	UserRole UserRole `json:"user_role"`
-	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
+// Accounts scheduled for deletion; the user can cancel until scheduled_for
+type AccountDeletionRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Keep votes on other users polls as anonymised ballots instead of removing them
+	KeepVotes bool `json:"keep_votes"`
+	// When the account will be deleted (end of the grace period)
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
-// Scan implements the Scanner interface.
-func (ns *NullUserRole) Scan(value interface{}) error {
-	if value == nil {
-		ns.UserRole, ns.Valid = "", false
-		return nil
-	}
-	ns.Valid = true
-	return ns.UserRole.Scan(value)
+// Failed login state per account; removed after a successful login
+type AccountLockout struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Consecutive failed logins since the last success or lockout
+	FailedAttempts int32              `json:"failed_attempts"`
+	LastFailedAt   pgtype.Timestamptz `json:"last_failed_at"`
+	// Logins are refused until this time (null if not locked)
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	// Lockouts since the last successful login, doubles the lockout duration
+	LockoutCount int32 `json:"lockout_count"`
+	// SHA-256 hash of the token in the unlock email
+	UnlockTokenHash pgtype.Text `json:"unlock_token_hash"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	// The unlock token is refused after this time (the end of the lock)
+	UnlockExpiresAt pgtype.Timestamptz `json:"unlock_expires_at"`
 }
 
-// Value implements the driver Valuer interface.
-func (ns NullUserRole) Value() (driver.Value, error) {
-	if !ns.Valid {
-		return nil, nil
-	}
-	return string(ns.UserRole), nil
+// Roles that can be assigned to users; each grants a set of permissions
+type AppRole struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	// Permissions granted by a custom role (e.g. poll.close.any); ignored for built-in roles
+	Permissions []string `json:"permissions"`
+	// Built-in roles (user, moderator, admin) cannot be edited or deleted
+	BuiltIn   bool      `json:"built_in"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type AppUser struct {
@@ -61,8 +58,10 @@ type AppUser struct {
 	Email           string             `json:"email"`
 	PasswordHash    string             `json:"password_hash"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	Role            UserRole           `json:"role"`
+	Role            string             `json:"role"`
 	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	// Preferred language of emails (e.g. en, ru)
+	Locale string `json:"locale"`
 }
 
 // Tracks all administrative actions for accountability and debugging
@@ -81,6 +80,53 @@ type AuditLog struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Stores pending email address changes until the new address is confirmed
+type EmailChangeToken struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// The address the user asked to switch to
+	NewEmail string `json:"new_email"`
+	// SHA-256 hash of the token sent to the new address
+	TokenHash string `json:"token_hash"`
+	// Token expiration time (24 hours from creation)
+	ExpiresAt time.Time `json:"expires_at"`
+	// Timestamp when the change was confirmed (null if pending)
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Emails waiting for or done with delivery by the outbox worker
+type EmailOutbox struct {
+	ID     uuid.UUID   `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+	// Name of the template the email was rendered from
+	Template  string `json:"template"`
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject"`
+	// Rendered HTML body (cleared once sent, it may contain one-time links)
+	HtmlBody string `json:"html_body"`
+	// Rendered plain text body (cleared once sent, it may contain one-time links)
+	TextBody string `json:"text_body"`
+	// pending until delivered (sent) or out of attempts (failed)
+	Status string `json:"status"`
+	// Delivery attempts made so far
+	Attempts int32 `json:"attempts"`
+	// When the worker may try the email next (also the lease while an attempt is running)
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	LastAttemptAt pgtype.Timestamptz `json:"last_attempt_at"`
+	// Error of the most recent failed attempt
+	LastError pgtype.Text `json:"last_error"`
+	// Message ID returned by the mail provider
+	ProviderMessageID pgtype.Text        `json:"provider_message_id"`
+	SentAt            pgtype.Timestamptz `json:"sent_at"`
+	CreatedAt         time.Time          `json:"created_at"`
+	// Reply-To address (empty when replies are not read)
+	ReplyTo string `json:"reply_to"`
+	// One-click unsubscribe URL sent in List-Unsubscribe (empty for transactional emails)
+	UnsubscribeUrl string `json:"unsubscribe_url"`
+}
+
 // Stores one-time tokens for email verification
 type EmailVerifyToken struct {
 	ID     uuid.UUID `json:"id"`
@@ -94,6 +140,54 @@ type EmailVerifyToken struct {
 	CreatedAt time.Time          `json:"created_at"`
 }
 
+// Password login attempts, used for per-IP throttling and admin review
+type LoginAttempt struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+	// Account the email belongs to (null for unknown emails)
+	UserID    pgtype.UUID `json:"user_id"`
+	IpAddress string      `json:"ip_address"`
+	Succeeded bool        `json:"succeeded"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Stores one-time tokens for passwordless sign-in
+type MagicLinkToken struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// SHA-256 hash of the token sent by email
+	TokenHash string `json:"token_hash"`
+	// SHA-256 hash of the cookie set on the browser that requested the link
+	BrowserHash string `json:"browser_hash"`
+	// Token expiration time (15 minutes from creation)
+	ExpiresAt time.Time `json:"expires_at"`
+	// Timestamp when token was used (null if unused)
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Failed 2FA codes entered against one MFA challenge token
+type MfaChallenge struct {
+	// jti of the MFA challenge token
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Wrong codes entered with this token; the token is refused once the limit is reached
+	FailedAttempts int32 `json:"failed_attempts"`
+	// Expiry of the MFA challenge token, after which the row can be deleted
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Per-user opt-outs (and opt-ins) for notification email categories
+type NotificationPreference struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Notification category (e.g. poll_results, poll_reminders)
+	Category string `json:"category"`
+	// Whether emails of this category are sent to the user
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // Stores one-time tokens for password reset
 type PasswordResetToken struct {
 	ID     uuid.UUID `json:"id"`
@@ -107,6 +201,26 @@ type PasswordResetToken struct {
 	CreatedAt time.Time          `json:"created_at"`
 }
 
+// Named, scoped API tokens for scripts and API clients (sent as Authorization: Bearer)
+type PersonalAccessToken struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// User supplied label for the token
+	Name string `json:"name"`
+	// First characters of the token, shown so users can tell tokens apart
+	TokenPrefix string `json:"token_prefix"`
+	// SHA-256 hash of the token
+	TokenHash string `json:"token_hash"`
+	// Granted scopes (e.g., polls:read, polls:write, votes:write)
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Last time the token authenticated a request (updated at most once per minute)
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	// Timestamp when the token was revoked (null if active)
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
 type Poll struct {
 	ID        uuid.UUID          `json:"id"`
 	Question  string             `json:"question"`
@@ -117,6 +231,13 @@ type Poll struct {
 	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 }
 
+// Users invited to vote on a poll
+type PollInvitee struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type PollOption struct {
 	ID        uuid.UUID          `json:"id"`
 	PollID    uuid.UUID          `json:"poll_id"`
@@ -126,10 +247,143 @@ type PollOption struct {
 	VoteCount int32 `json:"vote_count"`
 }
 
+// Reminder emails sent to invitees who have not voted before a poll expires
+type PollReminder struct {
+	PollID uuid.UUID `json:"poll_id"`
+	// How many hours before expires_at the reminder is sent
+	HoursBefore int32 `json:"hours_before"`
+	// When the reminder was sent (null until then; cleared when the expiration changes)
+	SentAt    pgtype.Timestamptz `json:"sent_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	// Failed attempts to send the reminder; reset when the reminder or the expiration changes
+	Attempts int32 `json:"attempts"`
+	// Error of the last failed attempt
+	LastError pgtype.Text `json:"last_error"`
+	// Earliest time of the next attempt after a failure
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+// Voters who opted in to the results email of a poll
+type PollResultSubscription struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Closed polls whose results email was sent
+type PollResultsNotice struct {
+	PollID uuid.UUID `json:"poll_id"`
+	// When the results email was sent (null while failed attempts are retried)
+	SentAt pgtype.Timestamptz `json:"sent_at"`
+	// Failed attempts to send the results email
+	Attempts int32 `json:"attempts"`
+	// Error of the last failed attempt
+	LastError pgtype.Text `json:"last_error"`
+	// Earliest time of the next attempt after a failure
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+// Fixed window request counters for the shared rate limiter
+type RateLimitCounter struct {
+	// Policy name and client key (IP, user or token)
+	Key         string    `json:"key"`
+	WindowStart time.Time `json:"window_start"`
+	Count       int32     `json:"count"`
+	// When the counter no longer affects rate limiting (two windows after it starts)
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Stores rotating refresh tokens; every login starts a new token family
+type RefreshToken struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Shared by all tokens descending from the same login
+	FamilyID uuid.UUID `json:"family_id"`
+	// SHA-256 hash of the refresh token
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Timestamp when the token was exchanged for a new one (null if still current)
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
+	// Timestamp when the token was revoked by logout or reuse detection
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	// The token issued when this one was rotated
+	ReplacedBy pgtype.UUID `json:"replaced_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// External identities (OIDC providers) linked to a user
+type UserIdentity struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Configured provider name (e.g., google)
+	Provider string `json:"provider"`
+	// Stable subject identifier (sub claim) at the provider
+	Subject string `json:"subject"`
+	// Email reported by the provider at link time
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// Single-use recovery codes for when the authenticator is unavailable
+type UserRecoveryCode struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// SHA-256 hash of the normalized recovery code
+	CodeHash string `json:"code_hash"`
+	// Timestamp when the code was used (null if unused)
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Server-side session records; the id is the refresh token family and the sid claim of access tokens
+type UserSession struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// User agent of the client that logged in
+	UserAgent string `json:"user_agent"`
+	// Client IP of the most recent activity
+	IpAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+	// Last time the session was used (updated at most once per minute)
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Timestamp when the session was logged out or revoked (null if active)
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	// The admin acting as the user (null for the user's own sessions)
+	ImpersonatorID pgtype.UUID `json:"impersonator_id"`
+	// When the session ends on its own (null for sessions that last until revoked)
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+// Suspended accounts; the user cannot sign in or use the API while suspended, their content is kept
+type UserSuspension struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Why the account was suspended, shown to the user
+	Reason string `json:"reason"`
+	// The admin or moderator who suspended the account
+	SuspendedBy pgtype.UUID `json:"suspended_by"`
+	// When the suspension ends (null for a permanent ban)
+	SuspendedUntil pgtype.Timestamptz `json:"suspended_until"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// TOTP (RFC 6238) two-factor enrolment
+type UserTotp struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Base32 encoded shared secret
+	Secret string `json:"secret"`
+	// Timestamp when enrolment was confirmed with a valid code (null while pending)
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+	// Time step of the last accepted code, so a code cannot be replayed
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Vote struct {
-	ID        uuid.UUID          `json:"id"`
-	PollID    uuid.UUID          `json:"poll_id"`
-	UserID    uuid.UUID          `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	PollID uuid.UUID `json:"poll_id"`
+	// The voter (null for anonymised ballots kept after the voter deleted their account)
+	UserID    pgtype.UUID        `json:"user_id"`
 	OptionID  uuid.UUID          `json:"option_id"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
@@ -549,13 +549,16 @@ func (q *Queries) PollHasVotes(ctx context.Context, pollID uuid.UUID) (bool, err
 }
 
 const reopenPoll = `-- name: ReopenPoll :one
+WITH cleared_notice AS (
+    DELETE FROM poll_results_notice WHERE poll_id = $1
+)
 UPDATE poll
 SET closed = false
 WHERE id = $1
 RETURNING id, question, created_at, user_id, closed, expires_at
 `
 
-// Admin: Reopen a poll
+// Admin: Reopen a poll; its results are emailed again when it closes
 func (q *Queries) ReopenPoll(ctx context.Context, id uuid.UUID) (Poll, error) {
 	row := q.db.QueryRow(ctx, reopenPoll, id)
 	var i Poll
@@ -571,6 +574,11 @@ func (q *Queries) ReopenPoll(ctx context.Context, id uuid.UUID) (Poll, error) {
 }
 
 const updatePollExpiration = `-- name: UpdatePollExpiration :one
+WITH reset_reminder AS (
+    UPDATE poll_reminder
+    SET sent_at = NULL, attempts = 0, last_error = NULL, next_attempt_at = NULL
+    WHERE poll_id = $1
+)
 UPDATE poll
 SET expires_at = $2
 WHERE id = $1
@@ -582,7 +590,7 @@ type UpdatePollExpirationParams struct {
 	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 }
 
-// Update poll expiration
+// Update poll expiration; the reminder is sent again for the new time
 func (q *Queries) UpdatePollExpiration(ctx context.Context, arg UpdatePollExpirationParams) (Poll, error) {
 	row := q.db.QueryRow(ctx, updatePollExpiration, arg.ID, arg.ExpiresAt)
 	var i Poll
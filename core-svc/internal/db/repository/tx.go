@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// beginner is implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx (where Begin starts a savepoint)
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// InTx runs fn with queries bound to a new transaction, committing if fn returns nil and
+// rolling back otherwise. Called on queries that are already in a transaction it nests as a
+// savepoint, so services can compose transactional helpers.
+func (q *Queries) InTx(ctx context.Context, fn func(*Queries) error) error {
+	db, ok := q.db.(beginner)
+	if !ok {
+		return errors.New("repository: database handle cannot start transactions")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback(ctx)
+
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -9,8 +9,25 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeVotesByUserID = `-- name: AnonymizeVotesByUserID :execrows
+UPDATE votes
+SET user_id = NULL
+WHERE user_id = $1
+  AND poll_id NOT IN (SELECT id FROM poll WHERE user_id = $1)
+`
+
+// Detach the user's votes on other users' polls, keeping them as anonymous ballots
+func (q *Queries) AnonymizeVotesByUserID(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, anonymizeVotesByUserID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createVote = `-- name: CreateVote :one
 INSERT INTO votes (poll_id, user_id, option_id)
 SELECT po.poll_id, $1, $2
@@ -30,8 +47,8 @@ RETURNING id, poll_id
 `
 
 type CreateVoteParams struct {
-	UserID   uuid.UUID `json:"user_id"`
-	OptionID uuid.UUID `json:"option_id"`
+	UserID   pgtype.UUID `json:"user_id"`
+	OptionID uuid.UUID   `json:"option_id"`
 }
 
 type CreateVoteRow struct {
@@ -47,13 +64,26 @@ func (q *Queries) CreateVote(ctx context.Context, arg CreateVoteParams) (CreateV
 	return i, err
 }
 
+const deleteVotesByUserID = `-- name: DeleteVotesByUserID :execrows
+DELETE FROM votes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteVotesByUserID(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteVotesByUserID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getUserOptionIdByPollId = `-- name: GetUserOptionIdByPollId :one
 SELECT option_id FROM votes WHERE poll_id = $1 AND user_id = $2
 `
 
 type GetUserOptionIdByPollIdParams struct {
-	PollID uuid.UUID `json:"poll_id"`
-	UserID uuid.UUID `json:"user_id"`
+	PollID uuid.UUID   `json:"poll_id"`
+	UserID pgtype.UUID `json:"user_id"`
 }
 
 func (q *Queries) GetUserOptionIdByPollId(ctx context.Context, arg GetUserOptionIdByPollIdParams) (uuid.UUID, error) {
@@ -111,3 +141,48 @@ func (q *Queries) ListVotesByPollId(ctx context.Context, pollID uuid.UUID) ([]Li
 	}
 	return items, nil
 }
+
+const listVotesByUserID = `-- name: ListVotesByUserID :many
+SELECT v.id, v.poll_id, p.question, v.option_id, po.label AS option_label, v.created_at
+FROM votes v
+JOIN poll p ON p.id = v.poll_id
+JOIN poll_option po ON po.id = v.option_id
+WHERE v.user_id = $1
+ORDER BY v.created_at DESC
+`
+
+type ListVotesByUserIDRow struct {
+	ID          uuid.UUID          `json:"id"`
+	PollID      uuid.UUID          `json:"poll_id"`
+	Question    string             `json:"question"`
+	OptionID    uuid.UUID          `json:"option_id"`
+	OptionLabel string             `json:"option_label"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) ListVotesByUserID(ctx context.Context, userID pgtype.UUID) ([]ListVotesByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listVotesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVotesByUserIDRow
+	for rows.Next() {
+		var i ListVotesByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PollID,
+			&i.Question,
+			&i.OptionID,
+			&i.OptionLabel,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// FileMailer writes every message to an .eml file instead of sending it, so links in
+// development mail can be opened from the file
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{
+		dir: dir,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) (string, error) {
+	messageID, err := newMessageID(msg.From)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	raw, err := encode(msg, messageID, now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	// sortable by time; the Message-ID part keeps names unique
+	id := strings.Trim(messageID, "<>")
+	id, _, _ = strings.Cut(id, "@")
+	path := filepath.Join(m.dir, fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000"), id))
+
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return "", err
+	}
+
+	util.Logger(ctx).Info("email written to file", "path", path, "to", msg.To, "subject", msg.Subject)
+	return messageID, nil
+}
@@ -0,0 +1,63 @@
+// Package mailer delivers email through a pluggable transport.
+//
+// The driver is picked with MAIL_DRIVER: "resend" (the Resend API), "smtp" (any relay,
+// with STARTTLS or implicit TLS and optional auth), "file" (writes each message as an .eml
+// file, for development) or "memory" (keeps messages in process, for tests).
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// Drivers selectable with MAIL_DRIVER
+const (
+	DriverResend = "resend"
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message is an email with a plain text and an HTML body
+type Message struct {
+	// From is an RFC 5322 address, e.g. "Pollex <no-reply@example.com>"
+	From string
+	To   []string
+	// ReplyTo is optional
+	ReplyTo string
+	Subject string
+	HTML    string
+	Text    string
+	// Headers are extra header fields, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	// Send delivers the message and returns its message ID
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// New builds the mailer selected by the config
+func New(config *util.Config) (Mailer, error) {
+	switch config.MailDriver {
+	case DriverResend:
+		if config.ResendAPIKey == "" {
+			return nil, fmt.Errorf("MAIL_DRIVER=%s requires RESEND_API_KEY", DriverResend)
+		}
+		return NewResendMailer(config.ResendAPIKey), nil
+	case DriverSMTP:
+		if config.SMTP.Host == "" {
+			return nil, fmt.Errorf("MAIL_DRIVER=%s requires SMTP_HOST", DriverSMTP)
+		}
+		return NewSMTPMailer(config.SMTP), nil
+	case DriverFile:
+		return NewFileMailer(config.MailFileDir), nil
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", config.MailDriver)
+	}
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages in memory so tests can assert on them
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(_ context.Context, msg Message) (string, error) {
+	messageID, err := newMessageID(msg.From)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return "", m.err
+	}
+
+	msg.To = slices.Clone(msg.To)
+	msg.Headers = maps.Clone(msg.Headers)
+	m.messages = append(m.messages, msg)
+	return messageID, nil
+}
+
+// FailWith makes the following sends fail with err, like a provider outage; nil makes
+// them succeed again
+func (m *MemoryMailer) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.messages)
+}
+
+// Last returns the most recently sent message
+func (m *MemoryMailer) Last() (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return Message{}, false
+	}
+	return m.messages[len(m.messages)-1], true
+}
+
+// Reset forgets the sent messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,102 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func newMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(address.Address, "@"); ok {
+			domain = host
+		}
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain), nil
+}
+
+// encode renders the message as a MIME multipart/alternative email (RFC 5322)
+func encode(msg Message, messageID string, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	// plain text first: clients show the last alternative they support
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, value)
+	}
+	header("From", msg.From)
+	header("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	for _, name := range slices.Sorted(maps.Keys(msg.Headers)) {
+		header(textproto.CanonicalMIMEHeaderKey(name), msg.Headers[name])
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// addressOf returns the bare address of an RFC 5322 address
+func addressOf(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	return parsed.Address, nil
+}
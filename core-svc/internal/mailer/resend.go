@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/resend/resend-go/v2"
+)
+
+// ResendMailer sends through the Resend API
+type ResendMailer struct {
+	client *resend.Client
+}
+
+func NewResendMailer(apiKey string) *ResendMailer {
+	return &ResendMailer{
+		client: resend.NewClient(apiKey),
+	}
+}
+
+func (m *ResendMailer) Send(ctx context.Context, msg Message) (string, error) {
+	sent, err := m.client.Emails.SendWithContext(ctx, &resend.SendEmailRequest{
+		From:    msg.From,
+		To:      msg.To,
+		ReplyTo: msg.ReplyTo,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+		Headers: msg.Headers,
+	})
+	if err != nil {
+		return "", err
+	}
+	return sent.Id, nil
+}
@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// upper bound for one delivery when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends through an SMTP relay, one connection per message
+type SMTPMailer struct {
+	config util.SMTPConfig
+}
+
+func NewSMTPMailer(config util.SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{
+		config: config,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) (string, error) {
+	from, err := addressOf(msg.From)
+	if err != nil {
+		return "", err
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		address, err := addressOf(to)
+		if err != nil {
+			return "", err
+		}
+		recipients = append(recipients, address)
+	}
+
+	messageID, err := newMessageID(msg.From)
+	if err != nil {
+		return "", err
+	}
+	raw, err := encode(msg, messageID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if m.config.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection (except to localhost)
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return "", err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return "", err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return "", err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	// the relay accepted the message with the end of DATA; failing now would make the outbox
+	// send it again
+	if err := client.Quit(); err != nil {
+		util.Logger(ctx).Warn("smtp quit failed after the message was accepted", "message_id", messageID, "error", err)
+	}
+	return messageID, nil
+}
+
+// dial connects to the relay and sets up TLS as configured
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	tlsConfig := &tls.Config{ServerName: m.config.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{}
+
+	var conn net.Conn
+	var err error
+	if m.config.TLS == util.SMTPTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// net/smtp doesn't take a context, so the deadline covers the whole conversation
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.config.TLS == util.SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
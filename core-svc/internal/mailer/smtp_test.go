@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// serveSMTP answers one SMTP conversation, accepting the message and then dropping the
+// connection instead of answering QUIT. It returns the listener address and a channel that
+// receives the message data.
+func serveSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 relay.test ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 relay.test")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 queued")
+				received <- data.String()
+			case cmd == "QUIT":
+				// the relay goes away without answering
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func TestSMTPSendSucceedsWhenQuitFailsAfterData(t *testing.T) {
+	addr, received := serveSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNumber, _ := strconv.Atoi(port)
+
+	m := NewSMTPMailer(util.SMTPConfig{Host: host, Port: portNumber, TLS: util.SMTPTLSNone})
+	messageID, err := m.Send(t.Context(), Message{
+		From:    "Pollex <noreply@pollex.test>",
+		To:      []string{"user@pollex.test"},
+		Subject: "Hello",
+		Text:    "Hi there",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v, want the accepted message reported as sent", err)
+	}
+	if messageID == "" {
+		t.Error("no message ID returned")
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "Subject: Hello") {
+			t.Errorf("relay received %q", data)
+		}
+	default:
+		t.Error("relay received no message")
+	}
+}
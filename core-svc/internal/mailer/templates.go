@@ -0,0 +1,238 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email templates live in templates/<locale>/<name>.html and <name>.txt. The .txt file
+// defines "subject" and the plain text "content", the .html file the HTML "content". Both
+// are wrapped in the shared layout.html / layout.txt, and each locale's common files
+// define the phrases the layouts and templates share (greeting, footer, ...).
+//
+// HTML is rendered with html/template, so data is escaped; subjects and plain text bodies
+// use text/template. A template missing in a locale falls back to DefaultLocale.
+
+//go:embed templates
+var templateFS embed.FS
+
+// Template names
+const (
+	TemplateVerifyEmail              = "verify_email"
+	TemplatePasswordReset            = "password_reset"
+	TemplateMagicLink                = "magic_link"
+	TemplateAccountLocked            = "account_locked"
+	TemplateEmailChangeConfirm       = "email_change_confirm"
+	TemplateEmailChangeNotice        = "email_change_notice"
+	TemplateAccountDeletionScheduled = "account_deletion_scheduled"
+	TemplatePollResults              = "poll_results"
+	TemplatePollReminder             = "poll_reminder"
+)
+
+// TemplateNames lists every email template
+var TemplateNames = []string{
+	TemplateVerifyEmail,
+	TemplatePasswordReset,
+	TemplateMagicLink,
+	TemplateAccountLocked,
+	TemplateEmailChangeConfirm,
+	TemplateEmailChangeNotice,
+	TemplateAccountDeletionScheduled,
+	TemplatePollResults,
+	TemplatePollReminder,
+}
+
+// DefaultLocale is used for users without a supported language preference
+const DefaultLocale = "en"
+
+// Locales lists the supported email languages
+var Locales = []string{"en", "ru"}
+
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Data is the template data; Render adds "Locale" and "Brand"
+type Data map[string]any
+
+// Branding is the product identity shown in every email
+type Branding struct {
+	Name string
+	// Color is a CSS hex colour for buttons and links
+	Color   string
+	LogoURL string
+	// URL is the web app, linked from the logo
+	URL string
+	// ReplyTo is set when replies are read; otherwise the footer asks not to reply
+	ReplyTo string
+}
+
+// Rendered is a rendered email
+type Rendered struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type templateSet struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// parsed templates by locale and name
+var templates = mustParseTemplates()
+
+// NormalizeLocale maps a language tag ("ru-RU", "EN") to a supported locale, or "" if the
+// language isn't supported
+func NormalizeLocale(locale string) string {
+	language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(locale)), "-")
+	language, _, _ = strings.Cut(language, "_")
+	if slices.Contains(Locales, language) {
+		return language
+	}
+	return ""
+}
+
+// Render renders the named template in the locale, falling back to DefaultLocale
+func Render(name, locale string, brand Branding, data Data) (Rendered, error) {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	set, ok := templates[locale][name]
+	if !ok {
+		locale = DefaultLocale
+		if set, ok = templates[locale][name]; !ok {
+			return Rendered{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+		}
+	}
+
+	data = maps.Clone(data)
+	if data == nil {
+		data = Data{}
+	}
+	data["Locale"] = locale
+	data["Brand"] = brand
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Rendered{}, err
+	}
+	if err := set.text.ExecuteTemplate(&text, "layout", data); err != nil {
+		return Rendered{}, err
+	}
+	if err := set.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Rendered{}, err
+	}
+
+	return Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+func mustParseTemplates() map[string]map[string]templateSet {
+	parsed := make(map[string]map[string]templateSet, len(Locales))
+
+	for _, locale := range Locales {
+		parsed[locale] = make(map[string]templateSet, len(TemplateNames))
+		funcs := localeFuncs(locale)
+
+		for _, name := range TemplateNames {
+			base := "templates/" + locale + "/" + name
+			if _, err := fs.Stat(templateFS, base+".html"); err != nil {
+				continue
+			}
+
+			html := htmltemplate.Must(htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).ParseFS(templateFS,
+				"templates/layout.html",
+				"templates/"+locale+"/common.html",
+				base+".html",
+			))
+			text := texttemplate.Must(texttemplate.New(name).Funcs(funcs).ParseFS(templateFS,
+				"templates/layout.txt",
+				"templates/"+locale+"/common.txt",
+				base+".txt",
+			))
+
+			parsed[locale][name] = templateSet{html: html, text: text}
+		}
+	}
+
+	return parsed
+}
+
+// localeFuncs formats durations and dates in the locale
+func localeFuncs(locale string) texttemplate.FuncMap {
+	switch locale {
+	case "ru":
+		return texttemplate.FuncMap{
+			"duration": formatDurationRU,
+			"datetime": formatDateTimeRU,
+		}
+	default:
+		return texttemplate.FuncMap{
+			"duration": formatDurationEN,
+			"datetime": formatDateTimeEN,
+		}
+	}
+}
+
+// formatDurationEN renders whole hours or minutes ("15 minutes", "2 hours")
+func formatDurationEN(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return pluralEN(int(d.Hours()), "hour", "hours")
+	}
+	return pluralEN(int(d.Minutes()), "minute", "minutes")
+}
+
+func pluralEN(n int, one, other string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, other)
+}
+
+func formatDateTimeEN(t time.Time) string {
+	return t.UTC().Format("January 2, 2006 at 15:04 UTC")
+}
+
+// formatDurationRU renders whole hours or minutes in the accusative ("на 15 минут")
+func formatDurationRU(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return pluralRU(int(d.Hours()), "час", "часа", "часов")
+	}
+	return pluralRU(int(d.Minutes()), "минуту", "минуты", "минут")
+}
+
+// pluralRU picks the Russian plural form: one (1, 21), few (2-4, 22-24) or many (5-20, 25)
+func pluralRU(n int, one, few, many string) string {
+	form := many
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		form = one
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		form = few
+	}
+	return fmt.Sprintf("%d %s", n, form)
+}
+
+// month names in the genitive, as used in dates
+var monthsRU = [...]string{
+	"января", "февраля", "марта", "апреля", "мая", "июня",
+	"июля", "августа", "сентября", "октября", "ноября", "декабря",
+}
+
+func formatDateTimeRU(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%d %s %d в %s UTC", t.Day(), monthsRU[t.Month()-1], t.Year(), t.Format("15:04"))
+}
@@ -3,34 +3,184 @@ package middleware
 import (
 	"errors"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
 
-// auth middleware - checks cookie and validates token
-func AuthMiddleware() gin.HandlerFunc {
+// auth middleware - authenticates the request with, in order:
+//   - Authorization: Bearer pollex_pat_... (personal access token, limited to its scopes)
+//   - Authorization: Bearer <access token>
+//   - the pollex.session cookie
+//
+// Access tokens must belong to a session that hasn't been revoked, and suspended users are
+// rejected however they authenticate. Requests made with an impersonation token are written
+// to the audit log once they complete.
+func AuthMiddleware(queries *repository.Queries) gin.HandlerFunc {
+	config := util.NewConfig()
+	sessions := service.NewSessionService(queries)
+	personalTokens := service.NewPersonalAccessTokenService(queries)
+	suspensions := service.NewSuspensionService(queries)
+	impersonations := service.NewImpersonationService(queries, config)
+	svc := service.NewTokenService(config)
+
 	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("pollex.session")
-		if err != nil || tokenStr == "" {
+
+		tokenStr := svc.ExtractToken(c)
+		if tokenStr != "" && service.IsPersonalAccessToken(tokenStr) {
+			pat, err := personalTokens.Authenticate(c.Request.Context(), tokenStr)
+			if err != nil {
+				if !errors.Is(err, util.ErrInvalidAccessToken) {
+					util.Logger(c.Request.Context()).Error("failed to authenticate personal access token", "error", err)
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
+				return
+			}
+			if abortIfSuspended(c, suspensions, pat.UserID) {
+				return
+			}
+			c.Set("userID", pat.UserID)
+			c.Set("tokenID", pat.ID)
+			c.Set("tokenScopes", pat.Scopes)
+			c.Request = c.Request.WithContext(util.WithUserID(c.Request.Context(), pat.UserID))
+			c.Next()
+			return
+		}
+
+		if tokenStr == "" {
+			tokenStr, _ = c.Cookie("pollex.session")
+		}
+		if tokenStr == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
 			return
 		}
-		config := util.NewConfig()
-		svc := service.NewTokenService(config)
+
 		// verify token
 		claims, err := svc.ExtractTokenData(tokenStr)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
 			return
 		}
+		// tokens issued before sessions existed carry no session
+		if claims.SessionID == uuid.Nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
+			return
+		}
+		if err := sessions.ValidateSession(c.Request.Context(), claims.SessionID, claims.ID, c.ClientIP()); err != nil {
+			if !errors.Is(err, util.ErrSessionRevoked) {
+				util.Logger(c.Request.Context()).Error("failed to validate session", "error", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Session has been revoked"})
+			return
+		}
+		if abortIfSuspended(c, suspensions, claims.ID) {
+			return
+		}
 		c.Set("userID", claims.ID)
+		c.Set("sessionID", claims.SessionID)
+		c.Request = c.Request.WithContext(util.WithUserID(c.Request.Context(), claims.ID))
+
+		if claims.Actor == nil {
+			c.Next()
+			return
+		}
+
+		impersonatorID := claims.Actor.ID
+		c.Set("impersonatorID", impersonatorID)
+		c.Request = c.Request.WithContext(util.WithImpersonatorID(c.Request.Context(), impersonatorID))
+		c.Next()
+
+		if err := impersonations.LogRequest(c.Request.Context(), impersonatorID, claims.ID, c.Request.Method, c.Request.URL.Path, c.Writer.Status()); err != nil {
+			util.Logger(c.Request.Context()).Error("failed to log impersonated request", "error", err)
+		}
+	}
+}
+
+// abortIfSuspended rejects the request with 403 while the user is suspended
+func abortIfSuspended(c *gin.Context, suspensions *service.SuspensionService, userID uuid.UUID) bool {
+	err := suspensions.CheckNotSuspended(c.Request.Context(), userID)
+	if err == nil {
+		return false
+	}
+
+	var suspended *service.AccountSuspendedError
+	if errors.As(err, &suspended) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "Account suspended",
+			"data": gin.H{
+				"reason":            "account_suspended",
+				"suspension_reason": suspended.Reason,
+				"suspended_until":   suspended.Until,
+			},
+		})
+		return true
+	}
+
+	util.Logger(c.Request.Context()).Error("failed to check suspension", "error", err)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to check account status"})
+	return true
+}
+
+// RequireScope rejects personal access tokens that weren't granted the scope.
+// Session (cookie or access token) requests carry the user's full access and always pass.
+// Must be used after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isPAT := GetTokenScopes(c)
+		if isPAT && !slices.Contains(scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "Token is missing required scope: " + scope,
+			})
+			return
+		}
 		c.Next()
 	}
 }
 
+// RequireSessionAuth rejects personal access tokens, for endpoints that manage credentials.
+// Must be used after AuthMiddleware.
+func RequireSessionAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isPAT := GetTokenScopes(c); isPAT {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "This endpoint can't be used with a personal access token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation rejects requests made while impersonating a user, for endpoints that
+// manage the account's credentials or need the admin's own privileges.
+// Must be used after AuthMiddleware.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetImpersonatorID(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "This endpoint can't be used while impersonating a user",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// gets the scopes of the personal access token used for the request;
+// ok is false when the request was authenticated with a session
+func GetTokenScopes(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get("tokenScopes")
+	if !exists {
+		return nil, false
+	}
+	scopes, ok := value.([]string)
+	return scopes, ok
+}
+
 // gets userID from context
 func GetUserID(c *gin.Context) (uuid.UUID, error) {
 	id, _ := c.Get("userID")
@@ -41,3 +191,25 @@ func GetUserID(c *gin.Context) (uuid.UUID, error) {
 	}
 	return userId, nil
 }
+
+// gets the current session ID from context
+func GetSessionID(c *gin.Context) (uuid.UUID, error) {
+	id, _ := c.Get("sessionID")
+
+	sessionID, ok := id.(uuid.UUID)
+	if !ok {
+		return uuid.Nil, errors.New("invalid session ID")
+	}
+	return sessionID, nil
+}
+
+// gets the admin impersonating the current user;
+// ok is false for requests the user made themselves
+func GetImpersonatorID(c *gin.Context) (uuid.UUID, bool) {
+	id, exists := c.Get("impersonatorID")
+	if !exists {
+		return uuid.Nil, false
+	}
+	impersonatorID, ok := id.(uuid.UUID)
+	return impersonatorID, ok
+}
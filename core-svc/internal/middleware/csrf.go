@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// CSRF protection uses the double-submit cookie pattern: the pollex.csrf cookie is readable by
+// the web app, which echoes it in the X-CSRF-Token header. Another site can make the browser
+// send the cookie but can't read it, so it can't forge the header.
+const (
+	CSRFCookieName = "pollex.csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// cookies that authenticate a request; only requests carrying one of them need a CSRF token
+var csrfAuthCookies = []string{"pollex.session", "pollex.refresh"}
+
+// CSRF rejects state-changing requests that are authenticated by cookie and don't echo the
+// CSRF cookie in the X-CSRF-Token header. Bearer token requests are exempt, browsers never
+// attach those on their own. The cookie is issued on the first request that lacks it.
+func CSRF(config *util.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cookieToken, _ := c.Cookie(CSRFCookieName)
+
+		if !isSafeMethod(c.Request.Method) && !hasBearerToken(c) && hasAuthCookie(c) {
+			headerToken := c.GetHeader(CSRFHeaderName)
+			if cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+				util.Logger(c.Request.Context()).Warn("csrf token missing or invalid",
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+				)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Invalid or missing CSRF token"})
+				return
+			}
+		}
+
+		if cookieToken == "" {
+			token, err := IssueCSRFToken(c, config)
+			if err != nil {
+				util.Logger(c.Request.Context()).Error("failed to issue csrf token", "error", err)
+			}
+			cookieToken = token
+		}
+
+		c.Set("csrfToken", cookieToken)
+		c.Next()
+	}
+}
+
+// IssueCSRFToken sets a fresh CSRF cookie and returns its value
+func IssueCSRFToken(c *gin.Context, config *util.Config) (string, error) {
+	token, _, err := service.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	// not HttpOnly: the web app has to read it to send it back in the header
+	c.SetSameSite(config.CookieSameSite)
+	c.SetCookie(CSRFCookieName, token, int(config.RefreshTokenTTL().Seconds()),
+		"/", config.CookieDomain, config.CookieSecure, false)
+
+	return token, nil
+}
+
+// GetCSRFToken gets the request's CSRF token from context
+func GetCSRFToken(c *gin.Context) string {
+	return c.GetString("csrfToken")
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// hasBearerToken mirrors TokenService.ExtractToken: with a Bearer token the auth cookies are ignored
+func hasBearerToken(c *gin.Context) bool {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && strings.TrimSpace(token) != ""
+}
+
+func hasAuthCookie(c *gin.Context) bool {
+	for _, name := range csrfAuthCookies {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// RateLimitKey selects what a route's requests are counted against
+type RateLimitKey int
+
+const (
+	// RateLimitByIP counts requests per client IP
+	RateLimitByIP RateLimitKey = iota
+	// RateLimitByUser counts requests per personal access token or user, and per IP for
+	// anonymous requests. Must be used after AuthMiddleware.
+	RateLimitByUser
+)
+
+// RouteLimit is the rate limit of a route
+type RouteLimit struct {
+	Policy ratelimit.Policy
+	Key    RateLimitKey
+}
+
+// route limits, applied with RateLimit when registering the routes
+var (
+	LoginRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "login", Limit: 10, Window: time.Minute},
+		Key:    RateLimitByIP,
+	}
+	MagicLinkRequestRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "magic_link_request", Limit: 10, Window: time.Hour},
+		Key:    RateLimitByIP,
+	}
+	MagicLinkVerifyRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "magic_link_verify", Limit: 10, Window: time.Minute},
+		Key:    RateLimitByIP,
+	}
+	RegisterRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "register", Limit: 5, Window: time.Hour},
+		Key:    RateLimitByIP,
+	}
+	VoteRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "vote", Limit: 30, Window: time.Minute},
+		Key:    RateLimitByUser,
+	}
+	PollCreateRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "poll_create", Limit: 20, Window: time.Hour},
+		Key:    RateLimitByUser,
+	}
+	SubscribeRateLimit = RouteLimit{
+		Policy: ratelimit.Policy{Name: "sse_subscribe", Limit: 30, Window: time.Minute},
+		Key:    RateLimitByIP,
+	}
+)
+
+// RateLimit rejects requests over the route's limit with 429 and sets the RateLimit-* headers.
+// A nil limiter disables rate limiting; store errors let the request through.
+func RateLimit(limiter *ratelimit.Limiter, limit RouteLimit) gin.HandlerFunc {
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	policyHeader := fmt.Sprintf("%d;w=%d", limit.Policy.Limit, int(limit.Policy.Window.Seconds()))
+
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), limit.Policy, rateLimitKey(c, limit.Key))
+		if err != nil {
+			util.Logger(c.Request.Context()).Error("rate limiter failed", "policy", limit.Policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"message": "Too many requests, please try again later",
+				"data":    gin.H{"reason": "rate_limited", "retry_after": retryAfter},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context, key RateLimitKey) string {
+	if key == RateLimitByUser {
+		if tokenID, ok := c.Get("tokenID"); ok {
+			if id, ok := tokenID.(uuid.UUID); ok {
+				return "token:" + id.String()
+			}
+		}
+		if userID, err := GetUserID(c); err == nil {
+			return "user:" + userID.String()
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
+)
+
+// newEngine builds a router the way main does, trusting only the given proxies
+func newEngine(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	return r
+}
+
+func keyFor(t *testing.T, r *gin.Engine, remoteAddr string, headers map[string]string) string {
+	t.Helper()
+	var key string
+	r.GET("/key", func(c *gin.Context) {
+		key = rateLimitKey(c, RateLimitByIP)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return key
+}
+
+func TestRateLimitKeyIgnoresForgedForwardedHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{"no headers", nil},
+		{"X-Forwarded-For", map[string]string{"X-Forwarded-For": "198.51.100.1"}},
+		{"X-Real-IP", map[string]string{"X-Real-IP": "198.51.100.2"}},
+		{"both", map[string]string{"X-Forwarded-For": "198.51.100.3, 198.51.100.4", "X-Real-IP": "198.51.100.5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := keyFor(t, newEngine(t, nil), "203.0.113.7:51234", tt.headers)
+			if key != "ip:203.0.113.7" {
+				t.Errorf("key = %q, want ip:203.0.113.7", key)
+			}
+		})
+	}
+}
+
+func TestRateLimitKeyUsesForwardedHeaderFromTrustedProxy(t *testing.T) {
+	r := newEngine(t, []string{"10.0.0.0/8"})
+
+	key := keyFor(t, r, "10.1.2.3:51234", map[string]string{"X-Forwarded-For": "198.51.100.1"})
+	if key != "ip:198.51.100.1" {
+		t.Errorf("key = %q, want ip:198.51.100.1", key)
+	}
+}
+
+func TestRateLimitCannotBeBypassedByRotatingForwardedFor(t *testing.T) {
+	r := newEngine(t, nil)
+	limit := RouteLimit{
+		Policy: ratelimit.Policy{Name: "test", Limit: 3, Window: time.Minute},
+		Key:    RateLimitByIP,
+	}
+	r.POST("/login", RateLimit(ratelimit.New(ratelimit.NewMemoryStore()), limit), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	var codes []int
+	for i := range 5 {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i+1))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	want := []int{200, 200, 200, 429, 429}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("status codes = %v, want %v", codes, want)
+		}
+	}
+}
@@ -1,16 +1,46 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/service"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
 
-// RequireRole middleware ensures the user has the specified role
-func RequireRole(queries *repository.Queries, requiredRole repository.UserRole) gin.HandlerFunc {
+// RequirePermission middleware ensures the user's role grants every listed permission
+func RequirePermission(queries *repository.Queries, permissions ...string) gin.HandlerFunc {
+	roles := service.NewRoleService(queries)
+
+	return func(c *gin.Context) {
+		granted, ok := loadPermissions(c, roles)
+		if !ok {
+			return
+		}
+
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"message":  "Insufficient permissions",
+					"required": permission,
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireTwoFactor middleware enforces the 2FA policy: users whose role requires 2FA
+// can't use the routes until they have enrolled
+func RequireTwoFactor(queries *repository.Queries) gin.HandlerFunc {
+	twoFactor := service.NewTwoFactorService(queries)
+
 	return func(c *gin.Context) {
 		userId, err := GetUserID(c)
 		if err != nil {
@@ -24,28 +54,34 @@ func RequireRole(queries *repository.Queries, requiredRole repository.UserRole)
 			return
 		}
 
-		if user.Role != requiredRole {
+		if !service.IsTwoFactorRequired(user.Role) {
+			c.Next()
+			return
+		}
+
+		enabled, err := twoFactor.IsEnabled(c.Request.Context(), userId)
+		if err != nil {
+			util.Logger(c.Request.Context()).Error("failed to check 2fa status", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to check two-factor status"})
+			return
+		}
+
+		if !enabled {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"message":  "Insufficient permissions",
-				"required": requiredRole,
-				"current":  user.Role,
+				"message": "Two-factor authentication must be enabled for this account",
+				"data":    gin.H{"reason": "two_factor_required"},
 			})
 			return
 		}
 
-		// Store role in context for later use
-		c.Set("userRole", user.Role)
 		c.Next()
 	}
 }
 
-// RequireAdmin middleware ensures the user is an admin
-func RequireAdmin(queries *repository.Queries) gin.HandlerFunc {
-	return RequireRole(queries, repository.UserRoleAdmin)
-}
+// RequireOwnerOrPermission checks if the user is either the owner of the resource or has the permission
+func RequireOwnerOrPermission(queries *repository.Queries, permission string, getResourceOwnerID func(*gin.Context) (uuid.UUID, error)) gin.HandlerFunc {
+	roles := service.NewRoleService(queries)
 
-// RequireOwnerOrAdmin checks if the user is either the owner of the resource or an admin
-func RequireOwnerOrAdmin(queries *repository.Queries, getResourceOwnerID func(*gin.Context) (uuid.UUID, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userId, err := GetUserID(c)
 		if err != nil {
@@ -67,53 +103,63 @@ func RequireOwnerOrAdmin(queries *repository.Queries, getResourceOwnerID func(*g
 			return
 		}
 
-		// Check if user is admin
-		user, err := queries.GetUserByID(c.Request.Context(), userId)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+		granted, ok := loadPermissions(c, roles)
+		if !ok {
 			return
 		}
 
-		if user.Role != repository.UserRoleAdmin {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Access denied - must be owner or admin"})
+		if !slices.Contains(granted, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Access denied - must be owner or have " + permission})
 			return
 		}
 
 		c.Set("isOwner", false)
-		c.Set("userRole", user.Role)
 		c.Next()
 	}
 }
 
-// GetUserRole retrieves the user's role from context (must be set by RequireRole middleware)
-func GetUserRole(c *gin.Context) (repository.UserRole, bool) {
-	role, exists := c.Get("userRole")
-	if !exists {
-		return "", false
+// loadPermissions resolves the user's permissions once per request and stores them in
+// context. It aborts the request and returns false on failure.
+func loadPermissions(c *gin.Context, roles *service.RoleService) ([]string, bool) {
+	if granted, ok := GetPermissions(c); ok {
+		return granted, true
 	}
-	userRole, ok := role.(repository.UserRole)
-	return userRole, ok
-}
 
-// IsAdmin checks if the current user is an admin
-func IsAdmin(c *gin.Context, queries *repository.Queries) bool {
 	userId, err := GetUserID(c)
 	if err != nil {
-		return false
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return nil, false
 	}
 
-	isAdmin, err := queries.IsUserAdmin(c.Request.Context(), userId)
+	granted, err := roles.UserPermissions(c.Request.Context(), userId)
 	if err != nil {
-		return false
+		if errors.Is(err, util.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+			return nil, false
+		}
+		util.Logger(c.Request.Context()).Error("failed to load permissions", "error", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to check permissions"})
+		return nil, false
 	}
 
-	return isAdmin
+	c.Set("userPermissions", granted)
+	return granted, true
+}
+
+// GetPermissions retrieves the user's permissions from context (set by RequirePermission)
+func GetPermissions(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get("userPermissions")
+	if !exists {
+		return nil, false
+	}
+	granted, ok := value.([]string)
+	return granted, ok
 }
 
 // PreventLastAdminDemotion prevents demoting or deleting the last admin
 func PreventLastAdminDemotion(c *gin.Context, queries *repository.Queries, targetUserId uuid.UUID) error {
 	// Count current admins
-	adminCount, err := queries.CountUsersByRole(c.Request.Context(), repository.UserRoleAdmin)
+	adminCount, err := queries.CountUsersByRole(c.Request.Context(), service.RoleAdmin)
 	if err != nil {
 		return err
 	}
@@ -125,7 +171,7 @@ func PreventLastAdminDemotion(c *gin.Context, queries *repository.Queries, targe
 			return err
 		}
 
-		if targetUser.Role == repository.UserRoleAdmin {
+		if targetUser.Role == service.RoleAdmin {
 			return util.ErrLastAdmin
 		}
 	}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// max length accepted for a client supplied request ID
+const maxRequestIDLength = 128
+
+// characters accepted in a client supplied request ID; it ends up in logs, response headers
+// and audit entries, so anything else is replaced with a generated ID
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// RequestID propagates X-Request-ID (or generates one) and stores it with the route in the request context
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(util.RequestIDHeader)
+		if len(requestID) > maxRequestIDLength || !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		// tag the request span so traces can be found by request ID
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request_id", requestID))
+
+		ctx := util.WithRequestID(c.Request.Context(), requestID)
+		ctx = util.WithRoute(ctx, c.FullPath())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Set("requestID", requestID)
+		c.Header(util.RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// GetRequestID gets the request ID from context
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("requestID")
+}
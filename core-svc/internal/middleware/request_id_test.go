@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+func TestRequestIDAcceptsOnlySafeClientIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientID string
+		wantKept bool
+	}{
+		{"uuid", "3f1c2a9e-5b7d-4c1e-9a8f-0d2b6e4c7a10", true},
+		{"dots and underscores", "edge.req_42", true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"spaces", "req 42", false},
+		{"log injection", "req\" level=ERROR msg=\"forged", false},
+		{"markup", "<script>", false},
+		{"non-ascii", "réq-42", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestID())
+			var seen string
+			r.GET("/", func(c *gin.Context) { seen = GetRequestID(c) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(util.RequestIDHeader, tt.clientID)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if echoed := w.Header().Get(util.RequestIDHeader); echoed != seen {
+				t.Errorf("response header %q, context %q", echoed, seen)
+			}
+			if tt.wantKept {
+				if seen != tt.clientID {
+					t.Errorf("request ID = %q, want the client's %q", seen, tt.clientID)
+				}
+				return
+			}
+			if _, err := uuid.Parse(seen); err != nil {
+				t.Errorf("request ID = %q, want a generated UUID", seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// how often the memory store drops counters of finished windows
+const memorySweepInterval = time.Minute
+
+// MemoryStore keeps counters in process memory. Limits are per instance,
+// so use the Postgres store when running more than one replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type memoryCounter struct {
+	windowStart time.Time
+	window      time.Duration
+	current     int64
+	previous    int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters:  make(map[string]*memoryCounter),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(windowStart)
+
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryCounter{windowStart: windowStart, window: window}
+		s.counters[key] = counter
+	}
+
+	if !counter.windowStart.Equal(windowStart) {
+		// carry the count over only if the stored window is the one right before
+		if counter.windowStart.Add(window).Equal(windowStart) {
+			counter.previous = counter.current
+		} else {
+			counter.previous = 0
+		}
+		counter.current = 0
+		counter.windowStart = windowStart
+		counter.window = window
+	}
+
+	counter.current++
+	return counter.current, counter.previous, nil
+}
+
+// sweep removes counters that can no longer affect a result; must hold s.mu
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, counter := range s.counters {
+		if now.Sub(counter.windowStart) >= 2*counter.window {
+			delete(s.counters, key)
+		}
+	}
+}
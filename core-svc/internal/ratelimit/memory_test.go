@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreCarriesOnlyTheAdjacentWindow(t *testing.T) {
+	s := NewMemoryStore()
+	s.lastSweep = windowStart
+	window := time.Minute
+
+	for range 3 {
+		s.Increment(t.Context(), "key", windowStart, window)
+	}
+
+	tests := []struct {
+		name         string
+		windowStart  time.Time
+		wantCurrent  int64
+		wantPrevious int64
+	}{
+		{"same window", windowStart, 4, 0},
+		{"next window", windowStart.Add(window), 1, 4},
+		{"window after a gap", windowStart.Add(3 * window), 1, 0},
+	}
+	for _, tt := range tests {
+		current, previous, err := s.Increment(t.Context(), "key", tt.windowStart, window)
+		if err != nil {
+			t.Fatalf("%s: Increment: %v", tt.name, err)
+		}
+		if current != tt.wantCurrent || previous != tt.wantPrevious {
+			t.Errorf("%s: current/previous = %d/%d, want %d/%d", tt.name, current, previous, tt.wantCurrent, tt.wantPrevious)
+		}
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := NewMemoryStore()
+	s.lastSweep = windowStart
+
+	s.Increment(t.Context(), "expired", windowStart, time.Minute)
+	s.Increment(t.Context(), "long window", windowStart, 10*time.Minute)
+	s.Increment(t.Context(), "previous window", windowStart.Add(time.Minute), time.Minute)
+
+	// too soon after the last sweep: nothing is dropped yet
+	s.Increment(t.Context(), "trigger", windowStart.Add(memorySweepInterval-time.Second), time.Second)
+	if _, ok := s.counters["expired"]; !ok {
+		t.Fatal("swept before memorySweepInterval passed")
+	}
+
+	s.Increment(t.Context(), "trigger", windowStart.Add(2*time.Minute), time.Minute)
+
+	for key, wantKept := range map[string]bool{
+		"expired":         false,
+		"long window":     true,
+		"previous window": true,
+		"trigger":         true,
+	} {
+		if _, kept := s.counters[key]; kept != wantKept {
+			t.Errorf("counter %q kept = %v, want %v", key, kept, wantKept)
+		}
+	}
+}
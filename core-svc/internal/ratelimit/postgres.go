@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// how often the Postgres store deletes expired counters
+const postgresSweepInterval = 5 * time.Minute
+
+// PostgresStore keeps counters in the rate_limit_counter table so limits are shared across replicas
+type PostgresStore struct {
+	queries *repository.Queries
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(queries *repository.Queries) *PostgresStore {
+	return &PostgresStore{
+		queries:   queries,
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *PostgresStore) Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int64, int64, error) {
+	s.sweep(ctx)
+
+	current, err := s.queries.IncrementRateLimitCounter(ctx, repository.IncrementRateLimitCounterParams{
+		Key:         key,
+		WindowStart: windowStart,
+		// kept while it can still be the previous window
+		ExpiresAt: windowStart.Add(2 * window),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	previous, err := s.queries.GetRateLimitCount(ctx, repository.GetRateLimitCountParams{
+		Key:         key,
+		WindowStart: windowStart.Add(-window),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, err
+	}
+
+	return int64(current), int64(previous), nil
+}
+
+// sweep deletes expired counters at most once per postgresSweepInterval
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < postgresSweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	if err := s.queries.DeleteExpiredRateLimitCounters(ctx); err != nil {
+		util.Logger(ctx).Warn("failed to delete expired rate limit counters", "error", err)
+	}
+}
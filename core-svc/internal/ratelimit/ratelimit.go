@@ -0,0 +1,98 @@
+// Package ratelimit implements sliding window rate limiting over pluggable counter stores.
+//
+// Counts are kept in fixed windows; a request is measured against the current window's count
+// plus the previous window's count weighted by how much of it still overlaps the sliding window.
+// This smooths out bursts at window boundaries without storing every request.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy allows Limit requests per Window. Name separates the counters of different policies.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the outcome of checking a request against a policy
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the current window ends
+	ResetAfter time.Duration
+	// RetryAfter is the time until a request would be allowed again (zero when allowed)
+	RetryAfter time.Duration
+}
+
+// Store keeps per-key request counts in fixed windows
+type Store interface {
+	// Increment records a hit for key in the window starting at windowStart and returns
+	// the counts of that window and of the window before it
+	Increment(ctx context.Context, key string, windowStart time.Time, window time.Duration) (current, previous int64, err error)
+}
+
+// Limiter checks requests against policies
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow records a request for key and reports whether it is within the policy.
+// Rejected requests are counted too, so clients that keep retrying stay limited.
+func (l *Limiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	now := l.now()
+	windowStart := now.Truncate(policy.Window)
+	elapsed := now.Sub(windowStart)
+
+	current, previous, err := l.store.Increment(ctx, policy.Name+":"+key, windowStart, policy.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	overlap := 1 - float64(elapsed)/float64(policy.Window)
+	estimate := float64(previous)*overlap + float64(current)
+
+	result := Result{
+		Allowed:    estimate <= float64(policy.Limit),
+		Limit:      policy.Limit,
+		Remaining:  max(policy.Limit-int(math.Ceil(estimate)), 0),
+		ResetAfter: policy.Window - elapsed,
+	}
+	if !result.Allowed {
+		result.RetryAfter = retryAfter(policy, elapsed, current, previous)
+	}
+	return result, nil
+}
+
+// retryAfter estimates when the weighted count drops enough to allow the next request
+func retryAfter(policy Policy, elapsed time.Duration, current, previous int64) time.Duration {
+	limit := float64(policy.Limit)
+	window := float64(policy.Window)
+
+	// still room in this window for one more request once more of the previous window slides out
+	if float64(current+1) <= limit && previous > 0 {
+		wait := window*(1-(limit-float64(current+1))/float64(previous)) - float64(elapsed)
+		return time.Duration(math.Max(wait, 0))
+	}
+
+	// otherwise wait for the next window, where this window's count becomes the weighted one
+	wait := policy.Window - elapsed
+	if current > 0 && limit > 1 {
+		wait += time.Duration(math.Max(window*(1-(limit-1)/float64(current)), 0))
+	} else {
+		wait += policy.Window
+	}
+	return wait
+}
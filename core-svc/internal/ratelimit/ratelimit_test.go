@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{Name: "test", Limit: 10, Window: time.Minute}
+
+// windowStart is aligned to testPolicy.Window
+var windowStart = time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+
+// fixedStore returns the same counts for every hit
+type fixedStore struct {
+	current, previous int64
+	key               string
+}
+
+func (s *fixedStore) Increment(_ context.Context, key string, _ time.Time, _ time.Duration) (int64, int64, error) {
+	s.key = key
+	return s.current, s.previous, nil
+}
+
+// newTestLimiter returns a limiter over store whose clock is set with the returned func
+func newTestLimiter(store Store) (*Limiter, func(time.Time)) {
+	l := New(store)
+	now := windowStart
+	l.now = func() time.Time { return now }
+	return l, func(t time.Time) { now = t }
+}
+
+func TestAllowWeightsThePreviousWindow(t *testing.T) {
+	tests := []struct {
+		name              string
+		elapsed           time.Duration
+		current, previous int64
+		wantAllowed       bool
+		wantRemaining     int
+	}{
+		{"empty previous window", 59 * time.Second, 10, 0, true, 0},
+		{"over the limit alone", 59 * time.Second, 11, 0, false, 0},
+		{"previous window counts fully at the boundary", 0, 1, 10, false, 0},
+		{"half of the previous window still overlaps", 30 * time.Second, 5, 10, true, 0},
+		{"one more than the weighted limit", 30 * time.Second, 6, 10, false, 0},
+		{"a quarter of the previous window overlaps", 45 * time.Second, 3, 8, true, 5},
+		{"weighted count is rounded up", 45 * time.Second, 3, 9, true, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fixedStore{current: tt.current, previous: tt.previous}
+			l, setNow := newTestLimiter(store)
+			setNow(windowStart.Add(tt.elapsed))
+
+			result, err := l.Allow(t.Context(), testPolicy, "1.2.3.4")
+			if err != nil {
+				t.Fatalf("Allow: %v", err)
+			}
+			if result.Allowed != tt.wantAllowed || result.Remaining != tt.wantRemaining {
+				t.Errorf("allowed/remaining = %v/%d, want %v/%d", result.Allowed, result.Remaining, tt.wantAllowed, tt.wantRemaining)
+			}
+			if want := testPolicy.Window - tt.elapsed; result.ResetAfter != want {
+				t.Errorf("ResetAfter = %v, want %v", result.ResetAfter, want)
+			}
+			if result.Allowed && result.RetryAfter != 0 {
+				t.Errorf("RetryAfter = %v on an allowed request", result.RetryAfter)
+			}
+			if store.key != "test:1.2.3.4" {
+				t.Errorf("key = %q, want it prefixed with the policy name", store.key)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name              string
+		limit             int
+		elapsed           time.Duration
+		current, previous int64
+		want              time.Duration
+	}{
+		// 10*(1-42/60) + 7 = 10 at 42s
+		{"room once the previous window slides out", 10, 30 * time.Second, 6, 10, 12 * time.Second},
+		// next window: 8*(1-30/60) + 1 = 5 at 30s into it
+		{"this window is full", 5, 50 * time.Second, 8, 0, 40 * time.Second},
+		// with a limit of 1 any hit in the previous window blocks the whole next one
+		{"limit of one", 1, 20 * time.Second, 2, 0, 100 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := Policy{Name: "test", Limit: tt.limit, Window: time.Minute}
+			if got := retryAfter(policy, tt.elapsed, tt.current, tt.previous); got != tt.want {
+				t.Errorf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// RetryAfter is exactly when the next request gets through
+func TestRetryAfterIsWhenTheNextRequestIsAllowed(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 5, Window: time.Minute}
+	blockedAt := windowStart.Add(50 * time.Second)
+
+	// a limiter that refused its last request at blockedAt
+	exhausted := func() (*Limiter, func(time.Time), Result) {
+		l, setNow := newTestLimiter(NewMemoryStore())
+		setNow(blockedAt)
+		var result Result
+		for range policy.Limit + 3 {
+			result, _ = l.Allow(t.Context(), policy, "key")
+		}
+		return l, setNow, result
+	}
+
+	l, setNow, blocked := exhausted()
+	if blocked.Allowed || blocked.RetryAfter <= 0 {
+		t.Fatalf("allowed/retry after = %v/%v, want a refusal with a wait", blocked.Allowed, blocked.RetryAfter)
+	}
+	retryAt := blockedAt.Add(blocked.RetryAfter)
+
+	setNow(retryAt.Add(-time.Second))
+	if result, _ := l.Allow(t.Context(), policy, "key"); result.Allowed {
+		t.Error("allowed a second before RetryAfter")
+	}
+
+	// a fresh limiter, as the early request above was counted too
+	l, setNow, _ = exhausted()
+	setNow(retryAt)
+	if result, _ := l.Allow(t.Context(), policy, "key"); !result.Allowed {
+		t.Errorf("refused after RetryAfter (%v)", blocked.RetryAfter)
+	}
+}
+
+func TestWindowBoundary(t *testing.T) {
+	l, setNow := newTestLimiter(NewMemoryStore())
+
+	setNow(windowStart.Add(59 * time.Second))
+	for i := range testPolicy.Limit {
+		if result, _ := l.Allow(t.Context(), testPolicy, "key"); !result.Allowed {
+			t.Fatalf("request %d of %d refused", i+1, testPolicy.Limit)
+		}
+	}
+	if result, _ := l.Allow(t.Context(), testPolicy, "key"); result.Allowed {
+		t.Fatal("request over the limit allowed")
+	}
+
+	// a new window doesn't hand out a fresh limit right away
+	setNow(windowStart.Add(time.Minute))
+	if result, _ := l.Allow(t.Context(), testPolicy, "key"); result.Allowed {
+		t.Error("burst allowed right after the window boundary")
+	}
+
+	// once a whole window has passed the old hits no longer count
+	setNow(windowStart.Add(3 * time.Minute))
+	if result, _ := l.Allow(t.Context(), testPolicy, "key"); !result.Allowed || result.Remaining != testPolicy.Limit-1 {
+		t.Errorf("after an idle window: allowed/remaining = %v/%d, want true/%d", result.Allowed, result.Remaining, testPolicy.Limit-1)
+	}
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+	"github.com/yatochka-dev/pollex/core-svc/internal/telemetry"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// how long a scheduled deletion can still be cancelled
+	AccountDeletionGracePeriod = 14 * 24 * time.Hour
+	// a session this young may schedule a deletion without the password, for accounts
+	// created through OIDC that never had one
+	accountDeletionRecentSignIn = 10 * time.Minute
+	// due deletions processed per worker run
+	accountDeletionBatchSize = 50
+)
+
+// AccountService covers the user's own account data: export, self-service deletion and
+// the email language
+type AccountService struct {
+	Queries      *repository.Queries
+	EmailService *EmailService
+	AuditService *AuditService
+}
+
+func NewAccountService(queries *repository.Queries, emailService *EmailService) *AccountService {
+	return &AccountService{
+		Queries:      queries,
+		EmailService: emailService,
+		AuditService: NewAuditService(queries),
+	}
+}
+
+// DataExport is everything Pollex stores about a user, in a machine-readable form
+type DataExport struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Profile    ExportProfile      `json:"profile"`
+	Polls      []ExportPoll       `json:"polls"`
+	Votes      []ExportVote       `json:"votes"`
+	AuditLog   []ExportAuditEntry `json:"audit_log"`
+}
+
+type ExportProfile struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       *time.Time `json:"created_at"`
+	Locale          string     `json:"locale"`
+}
+
+type ExportPoll struct {
+	ID        string             `json:"id"`
+	Question  string             `json:"question"`
+	Closed    bool               `json:"closed"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+	CreatedAt *time.Time         `json:"created_at"`
+	Options   []ExportPollOption `json:"options"`
+}
+
+type ExportPollOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Votes int64  `json:"votes"`
+}
+
+type ExportVote struct {
+	PollID       string     `json:"poll_id"`
+	PollQuestion string     `json:"poll_question"`
+	OptionID     string     `json:"option_id"`
+	OptionLabel  string     `json:"option_label"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+type ExportAuditEntry struct {
+	ID          string          `json:"id"`
+	ActorUserID string          `json:"actor_user_id"`
+	Action      string          `json:"action"`
+	SubjectType string          `json:"subject_type"`
+	SubjectID   *string         `json:"subject_id"`
+	Meta        json.RawMessage `json:"meta"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// timestamptzPtr converts a nullable timestamp for JSON output
+func timestamptzPtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
+
+// ExportData collects the user's profile, polls (with options and results), votes and
+// the audit entries they are the actor or subject of
+func (s *AccountService) ExportData(ctx context.Context, userID uuid.UUID) (_ DataExport, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AccountService.ExportData", attribute.String("user.id", userID.String()))
+	defer telemetry.EndSpan(span, &err)
+
+	user, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return DataExport{}, util.ErrUserNotFound
+		}
+		return DataExport{}, err
+	}
+
+	export := DataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportProfile{
+			ID:              user.ID.String(),
+			Name:            user.Name,
+			Email:           user.Email,
+			Role:            user.Role,
+			EmailVerifiedAt: timestamptzPtr(user.EmailVerifiedAt),
+			CreatedAt:       timestamptzPtr(user.CreatedAt),
+			Locale:          user.Locale,
+		},
+		Polls:    []ExportPoll{},
+		Votes:    []ExportVote{},
+		AuditLog: []ExportAuditEntry{},
+	}
+
+	polls, err := s.Queries.GetPollsByUserID(ctx, userID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	for _, poll := range polls {
+		exportPoll, err := s.exportPoll(ctx, poll)
+		if err != nil {
+			return DataExport{}, err
+		}
+		export.Polls = append(export.Polls, exportPoll)
+	}
+
+	votes, err := s.Queries.ListVotesByUserID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return DataExport{}, err
+	}
+	for _, vote := range votes {
+		export.Votes = append(export.Votes, ExportVote{
+			PollID:       vote.PollID.String(),
+			PollQuestion: vote.Question,
+			OptionID:     vote.OptionID.String(),
+			OptionLabel:  vote.OptionLabel,
+			CreatedAt:    timestamptzPtr(vote.CreatedAt),
+		})
+	}
+
+	entries, err := s.Queries.ListAuditLogsForUser(ctx, userID)
+	if err != nil {
+		return DataExport{}, err
+	}
+	for _, entry := range entries {
+		var subjectID *string
+		if entry.SubjectID.Valid {
+			id := uuid.UUID(entry.SubjectID.Bytes).String()
+			subjectID = &id
+		}
+		export.AuditLog = append(export.AuditLog, ExportAuditEntry{
+			ID:          entry.ID.String(),
+			ActorUserID: entry.ActorUserID.String(),
+			Action:      entry.Action,
+			SubjectType: entry.SubjectType,
+			SubjectID:   subjectID,
+			Meta:        json.RawMessage(entry.Meta),
+			CreatedAt:   entry.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// exportPoll adds the options and their vote counts to a poll
+func (s *AccountService) exportPoll(ctx context.Context, poll repository.Poll) (ExportPoll, error) {
+	options, err := s.Queries.ListOptionsByPollID(ctx, poll.ID)
+	if err != nil {
+		return ExportPoll{}, err
+	}
+	counts, err := s.Queries.ListVotesByPollId(ctx, poll.ID)
+	if err != nil {
+		return ExportPoll{}, err
+	}
+
+	votesByOption := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		votesByOption[count.OptionID] = count.VoteCount
+	}
+
+	exportPoll := ExportPoll{
+		ID:        poll.ID.String(),
+		Question:  poll.Question,
+		Closed:    poll.Closed,
+		ExpiresAt: timestamptzPtr(poll.ExpiresAt),
+		CreatedAt: timestamptzPtr(poll.CreatedAt),
+		Options:   make([]ExportPollOption, 0, len(options)),
+	}
+	for _, option := range options {
+		exportPoll.Options = append(exportPoll.Options, ExportPollOption{
+			ID:    option.ID.String(),
+			Label: option.Label,
+			Votes: votesByOption[option.ID],
+		})
+	}
+	return exportPoll, nil
+}
+
+// WriteZip writes the export as a ZIP bundle with one JSON file per section
+func (e DataExport) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", struct {
+			ExportedAt time.Time     `json:"exported_at"`
+			Profile    ExportProfile `json:"profile"`
+		}{e.ExportedAt, e.Profile}},
+		{"polls.json", e.Polls},
+		{"votes.json", e.Votes},
+		{"audit_log.json", e.AuditLog},
+	}
+
+	for _, file := range files {
+		fw, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: e.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// AccountDeletionInput schedules the user's account for deletion. KeepVotes keeps their votes
+// on other people's polls as anonymised ballots; otherwise the votes are removed.
+// Password may be left out right after signing in (see accountDeletionRecentSignIn).
+type AccountDeletionInput struct {
+	Password  string `json:"password"`
+	KeepVotes bool   `json:"keep_votes"`
+}
+
+// ScheduleDeletion schedules the account for deletion after the grace period. Scheduling again
+// only updates the vote choice; the original date stays.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID, sessionID uuid.UUID, input AccountDeletionInput) (repository.AccountDeletionRequest, error) {
+	user, err := s.Queries.GetUserWithPasswordByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.AccountDeletionRequest{}, util.ErrUserNotFound
+		}
+		return repository.AccountDeletionRequest{}, err
+	}
+
+	if input.Password != "" {
+		same, err := util.VerifyPassword(input.Password, user.PasswordHash)
+		if err != nil {
+			return repository.AccountDeletionRequest{}, err
+		}
+		if !same {
+			return repository.AccountDeletionRequest{}, util.ErrInvalidCredentials
+		}
+	} else {
+		session, err := s.Queries.GetUserSession(ctx, sessionID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return repository.AccountDeletionRequest{}, err
+		}
+		if err != nil || session.UserID != userID || time.Since(session.CreatedAt) > accountDeletionRecentSignIn {
+			return repository.AccountDeletionRequest{}, util.ErrRecentSignInRequired
+		}
+	}
+
+	if err := checkLastAdmin(ctx, s.Queries, user.Role); err != nil {
+		return repository.AccountDeletionRequest{}, err
+	}
+
+	var request repository.AccountDeletionRequest
+	err = s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		request, err = q.UpsertAccountDeletionRequest(ctx, repository.UpsertAccountDeletionRequestParams{
+			UserID:       userID,
+			KeepVotes:    input.KeepVotes,
+			ScheduledFor: time.Now().Add(AccountDeletionGracePeriod),
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.EmailService.WithQueries(q).SendAccountDeletionScheduledEmail(ctx, userID, user.Email, user.Name, request.ScheduledFor)
+	})
+	if err != nil {
+		return repository.AccountDeletionRequest{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, userID, AuditActionUserDeletionSchedule, "user", userID, map[string]interface{}{
+		"scheduled_for": request.ScheduledFor,
+		"keep_votes":    request.KeepVotes,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log account deletion request", "error", err)
+	}
+
+	return request, nil
+}
+
+// GetDeletion returns the user's pending deletion request
+func (s *AccountService) GetDeletion(ctx context.Context, userID uuid.UUID) (repository.AccountDeletionRequest, error) {
+	request, err := s.Queries.GetAccountDeletionRequest(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.AccountDeletionRequest{}, util.ErrDeletionNotScheduled
+		}
+		return repository.AccountDeletionRequest{}, err
+	}
+	return request, nil
+}
+
+// CancelDeletion cancels a pending deletion during the grace period
+func (s *AccountService) CancelDeletion(ctx context.Context, userID uuid.UUID) error {
+	deleted, err := s.Queries.DeleteAccountDeletionRequest(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return util.ErrDeletionNotScheduled
+	}
+
+	if err := s.AuditService.LogEntry(ctx, userID, AuditActionUserDeletionCancel, "user", userID); err != nil {
+		util.Logger(ctx).Error("failed to log account deletion cancel", "error", err)
+	}
+	return nil
+}
+
+// UpdateLocale sets the language of the user's emails and returns the normalized locale
+func (s *AccountService) UpdateLocale(ctx context.Context, userID uuid.UUID, locale string) (string, error) {
+	normalized := mailer.NormalizeLocale(locale)
+	if normalized == "" {
+		return "", util.ErrUnsupportedLocale
+	}
+
+	if err := s.Queries.UpdateUserLocale(ctx, repository.UpdateUserLocaleParams{
+		ID:     userID,
+		Locale: normalized,
+	}); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// checkLastAdmin refuses to delete the only admin
+func checkLastAdmin(ctx context.Context, q *repository.Queries, role string) error {
+	if role != RoleAdmin {
+		return nil
+	}
+	adminCount, err := q.CountUsersByRole(ctx, RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if adminCount <= 1 {
+		return util.ErrLastAdmin
+	}
+	return nil
+}
+
+// PurgeDueAccounts deletes the accounts whose grace period has ended and returns how many were deleted
+func (s *AccountService) PurgeDueAccounts(ctx context.Context) (_ int, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "AccountService.PurgeDueAccounts")
+	defer telemetry.EndSpan(span, &err)
+
+	due, err := s.Queries.ListDueAccountDeletionRequests(ctx, accountDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, request := range due {
+		if err := s.purgeAccount(ctx, request); err != nil {
+			// keep going; the request stays and is retried on the next run
+			util.Logger(ctx).Error("failed to delete account", "user_id", request.UserID.String(), "error", err)
+			continue
+		}
+		purged++
+	}
+
+	span.SetAttributes(attribute.Int("accounts.purged", purged))
+	return purged, nil
+}
+
+// purgeAccount deletes one account. Votes on the user's own polls go with the polls; votes on
+// other polls are anonymised or removed as the user chose. Deleting the user cascades to
+// everything else, including the deletion request. It all happens in one transaction, so a
+// failure leaves the account and its votes untouched for the next run.
+func (s *AccountService) purgeAccount(ctx context.Context, request repository.AccountDeletionRequest) error {
+	var votes int64
+	err := s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		user, err := q.GetUserByID(ctx, request.UserID)
+		if err != nil {
+			return err
+		}
+		// the user may have been promoted since scheduling
+		if err := checkLastAdmin(ctx, q, user.Role); err != nil {
+			return err
+		}
+
+		voter := pgtype.UUID{Bytes: request.UserID, Valid: true}
+		if request.KeepVotes {
+			votes, err = q.AnonymizeVotesByUserID(ctx, voter)
+		} else {
+			votes, err = q.DeleteVotesByUserID(ctx, voter)
+		}
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteUser(ctx, request.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	util.Logger(ctx).Info("account deleted",
+		"user_id", request.UserID.String(),
+		"keep_votes", request.KeepVotes,
+		"votes", votes,
+	)
+	return nil
+}
+
+// RunDeletionWorker purges due accounts every interval until ctx is cancelled
+func (s *AccountService) RunDeletionWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDueAccounts(ctx); err != nil {
+			util.Logger(ctx).Error("account deletion run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
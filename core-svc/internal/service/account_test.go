@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+func TestScheduleDeletionConfirmation(t *testing.T) {
+	passwordHash, err := util.HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, sessionID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		password string
+		session  any // GetUserSession result
+		wantErr  error
+	}{
+		{"password", "correct horse battery staple", nil, nil},
+		{"wrong password", "guess", nil, util.ErrInvalidCredentials},
+		{"recent sign-in", "", repository.UserSession{ID: sessionID, UserID: userID, CreatedAt: time.Now().Add(-time.Minute)}, nil},
+		{"old session", "", repository.UserSession{ID: sessionID, UserID: userID, CreatedAt: time.Now().Add(-time.Hour)}, util.ErrRecentSignInRequired},
+		{"someone else's session", "", repository.UserSession{ID: sessionID, UserID: uuid.New(), CreatedAt: time.Now()}, util.ErrRecentSignInRequired},
+		{"unknown session", "", nil, util.ErrRecentSignInRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			db.returns("GetUserWithPasswordByID", repository.GetUserWithPasswordByIDRow{
+				ID:           userID,
+				Email:        "user@pollex.test",
+				Role:         RoleUser,
+				PasswordHash: passwordHash,
+			})
+			db.returns("GetUserSession", tt.session)
+			db.on("UpsertAccountDeletionRequest", func([]any) (any, error) {
+				return repository.AccountDeletionRequest{UserID: userID, ScheduledFor: time.Now().Add(AccountDeletionGracePeriod)}, nil
+			})
+			db.returns("GetUserLocale", "en")
+			db.on("EnqueueEmail", func([]any) (any, error) { return uuid.New(), nil })
+			db.returns("CreateAuditLog", repository.AuditLog{})
+			queries := db.queries()
+			s := NewAccountService(queries, NewEmailService(queries, newTestConfig(t)))
+
+			_, err := s.ScheduleDeletion(t.Context(), userID, sessionID, AccountDeletionInput{Password: tt.password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			scheduled := len(db.called("UpsertAccountDeletionRequest")) == 1
+			if scheduled != (tt.wantErr == nil) {
+				t.Errorf("deletion scheduled = %v, want %v", scheduled, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -3,24 +3,27 @@ package service
 import (
 	"context"
 	"errors"
-	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // AdminService handles administrative operations
 type AdminService struct {
 	Queries      *repository.Queries
 	AuditService *AuditService
+	RoleService  *RoleService
 }
 
 func NewAdminService(queries *repository.Queries, auditService *AuditService) *AdminService {
 	return &AdminService{
 		Queries:      queries,
 		AuditService: auditService,
+		RoleService:  NewRoleService(queries),
 	}
 }
 
@@ -65,27 +68,41 @@ func (s *AdminService) ListUsers(ctx context.Context, input ListUsersInput) (*Li
 type UpdateUserRoleInput struct {
 	ActorUserID  uuid.UUID
 	TargetUserID uuid.UUID
-	NewRole      repository.UserRole
+	NewRole      string
 }
 
-// UpdateUserRole changes a user's role
+// UpdateUserRole changes a user's role. The actor must hold every permission of both the
+// current and the new role, so nobody can promote past or demote above themselves.
 func (s *AdminService) UpdateUserRole(ctx context.Context, input UpdateUserRoleInput) (*repository.UpdateUserRoleRow, error) {
+	targetUser, err := s.Queries.GetUserByID(ctx, input.TargetUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, util.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	newPermissions, err := s.RoleService.RolePermissions(ctx, input.NewRole)
+	if err != nil {
+		return nil, err
+	}
+	currentPermissions, err := s.RoleService.RolePermissions(ctx, targetUser.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RoleService.EnsureGrantable(ctx, input.ActorUserID, append(newPermissions, currentPermissions...)); err != nil {
+		return nil, err
+	}
+
 	// Prevent demoting the last admin
-	if input.NewRole == repository.UserRoleUser {
-		adminCount, err := s.Queries.CountUsersByRole(ctx, repository.UserRoleAdmin)
+	if targetUser.Role == RoleAdmin && input.NewRole != RoleAdmin {
+		adminCount, err := s.Queries.CountUsersByRole(ctx, RoleAdmin)
 		if err != nil {
 			return nil, err
 		}
 
 		if adminCount <= 1 {
-			targetUser, err := s.Queries.GetUserByID(ctx, input.TargetUserID)
-			if err != nil {
-				return nil, err
-			}
-
-			if targetUser.Role == repository.UserRoleAdmin {
-				return nil, util.ErrLastAdmin
-			}
+			return nil, util.ErrLastAdmin
 		}
 	}
 
@@ -100,7 +117,7 @@ func (s *AdminService) UpdateUserRole(ctx context.Context, input UpdateUserRoleI
 
 	// Log the action
 	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserRoleChange, input.TargetUserID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log role change: %v", err)
+		util.Logger(ctx).Error("failed to log role change", "error", err)
 	}
 
 	return &updatedUser, nil
@@ -125,7 +142,7 @@ func (s *AdminService) UpdateUserName(ctx context.Context, input UpdateUserNameI
 
 	// Log the action
 	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserUpdate, input.TargetUserID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log name update: %v", err)
+		util.Logger(ctx).Error("failed to log name update", "error", err)
 	}
 
 	return &updatedUser, nil
@@ -140,12 +157,17 @@ type ResetUserPasswordInput struct {
 
 // ResetUserPassword resets a user's password (admin action)
 func (s *AdminService) ResetUserPassword(ctx context.Context, input ResetUserPasswordInput) error {
-	if input.NewPassword == "" {
-		return errors.New("password cannot be empty")
+	user, err := s.Queries.GetUserByID(ctx, input.TargetUserID)
+	if err != nil {
+		return util.ErrUserNotFound
+	}
+
+	if err := util.ValidatePassword(input.NewPassword, user.Name, user.Email); err != nil {
+		return err
 	}
 
 	// Hash the new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := util.HashPassword(input.NewPassword)
 	if err != nil {
 		return err
 	}
@@ -153,7 +175,7 @@ func (s *AdminService) ResetUserPassword(ctx context.Context, input ResetUserPas
 	// Update the password
 	err = s.Queries.UpdateUserPasswordHash(ctx, repository.UpdateUserPasswordHashParams{
 		ID:           input.TargetUserID,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 	})
 	if err != nil {
 		return err
@@ -161,7 +183,7 @@ func (s *AdminService) ResetUserPassword(ctx context.Context, input ResetUserPas
 
 	// Log the action
 	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserPasswordReset, input.TargetUserID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log password reset: %v", err)
+		util.Logger(ctx).Error("failed to log password reset", "error", err)
 	}
 
 	return nil
@@ -181,8 +203,8 @@ func (s *AdminService) DeleteUser(ctx context.Context, input DeleteUserInput) er
 		return util.ErrUserNotFound
 	}
 
-	if targetUser.Role == repository.UserRoleAdmin {
-		adminCount, err := s.Queries.CountUsersByRole(ctx, repository.UserRoleAdmin)
+	if targetUser.Role == RoleAdmin {
+		adminCount, err := s.Queries.CountUsersByRole(ctx, RoleAdmin)
 		if err != nil {
 			return err
 		}
@@ -194,7 +216,7 @@ func (s *AdminService) DeleteUser(ctx context.Context, input DeleteUserInput) er
 
 	// Log the action before deletion
 	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserDelete, input.TargetUserID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log user deletion: %v", err)
+		util.Logger(ctx).Error("failed to log user deletion", "error", err)
 	}
 
 	// Delete the user
@@ -206,6 +228,124 @@ func (s *AdminService) DeleteUser(ctx context.Context, input DeleteUserInput) er
 	return nil
 }
 
+// ForceLogoutInput contains parameters for signing a user out everywhere
+type ForceLogoutInput struct {
+	ActorUserID  uuid.UUID
+	TargetUserID uuid.UUID
+}
+
+// ForceLogout revokes all of a user's sessions and returns how many were active
+func (s *AdminService) ForceLogout(ctx context.Context, input ForceLogoutInput) (int64, error) {
+	if _, err := s.Queries.GetUserByID(ctx, input.TargetUserID); err != nil {
+		return 0, util.ErrUserNotFound
+	}
+
+	revoked, err := NewSessionService(s.Queries).RevokeAllSessions(ctx, input.TargetUserID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, input.ActorUserID, AuditActionUserForceLogout, "user", input.TargetUserID, map[string]interface{}{
+		"revoked_sessions": revoked,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log force logout", "error", err)
+	}
+
+	return revoked, nil
+}
+
+// ListLockoutsInput contains pagination parameters
+type ListLockoutsInput struct {
+	Limit  int32
+	Offset int32
+}
+
+// ListLockouts returns accounts that are locked or have recent failed logins
+func (s *AdminService) ListLockouts(ctx context.Context, input ListLockoutsInput) ([]repository.ListAccountLockoutsRow, error) {
+	lockouts, err := s.Queries.ListAccountLockouts(ctx, repository.ListAccountLockoutsParams{
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if lockouts == nil {
+		lockouts = []repository.ListAccountLockoutsRow{}
+	}
+	return lockouts, nil
+}
+
+// maxLockoutAttempts is how many recent login attempts GetLockout returns
+const maxLockoutAttempts = 20
+
+// LockoutStatus is a user's failed login state and recent login attempts
+type LockoutStatus struct {
+	Locked         bool                      `json:"locked"`
+	LockedUntil    pgtype.Timestamptz        `json:"locked_until"`
+	FailedAttempts int32                     `json:"failed_attempts"`
+	LastFailedAt   pgtype.Timestamptz        `json:"last_failed_at"`
+	LockoutCount   int32                     `json:"lockout_count"`
+	RecentAttempts []repository.LoginAttempt `json:"recent_attempts"`
+}
+
+// GetLockout returns a user's lockout status
+func (s *AdminService) GetLockout(ctx context.Context, userID uuid.UUID) (*LockoutStatus, error) {
+	if _, err := s.Queries.GetUserByID(ctx, userID); err != nil {
+		return nil, util.ErrUserNotFound
+	}
+
+	status := &LockoutStatus{}
+
+	state, err := s.Queries.GetAccountLockout(ctx, userID)
+	switch {
+	case err == nil:
+		status.Locked = state.LockedUntil.Valid && time.Now().Before(state.LockedUntil.Time)
+		status.LockedUntil = state.LockedUntil
+		status.FailedAttempts = state.FailedAttempts
+		status.LastFailedAt = state.LastFailedAt
+		status.LockoutCount = state.LockoutCount
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	attempts, err := s.Queries.ListRecentLoginAttemptsByUserID(ctx, repository.ListRecentLoginAttemptsByUserIDParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Limit:  maxLockoutAttempts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []repository.LoginAttempt{}
+	}
+	status.RecentAttempts = attempts
+
+	return status, nil
+}
+
+// ClearLockoutInput contains parameters for clearing a user's lockout
+type ClearLockoutInput struct {
+	ActorUserID  uuid.UUID
+	TargetUserID uuid.UUID
+}
+
+// ClearLockout unlocks an account and resets its failed login counter
+func (s *AdminService) ClearLockout(ctx context.Context, input ClearLockoutInput) error {
+	if _, err := s.Queries.GetUserByID(ctx, input.TargetUserID); err != nil {
+		return util.ErrUserNotFound
+	}
+
+	if _, err := s.Queries.ClearAccountLockout(ctx, input.TargetUserID); err != nil {
+		return err
+	}
+
+	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserLockoutClear, input.TargetUserID); err != nil {
+		util.Logger(ctx).Error("failed to log lockout clear", "error", err)
+	}
+
+	return nil
+}
+
 // ToggleEmailVerificationInput contains parameters for toggling email verification
 type ToggleEmailVerificationInput struct {
 	ActorUserID  uuid.UUID
@@ -220,9 +360,8 @@ func (s *AdminService) ToggleEmailVerification(ctx context.Context, input Toggle
 	if !input.Verified {
 		action = "user.unverify_email"
 	}
-	log.Printf("%v", input.Verified)
 	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditAction(action), input.TargetUserID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log email verification toggle: %v", err)
+		util.Logger(ctx).Error("failed to log email verification toggle", "error", err)
 	}
 
 	// Update verification status
@@ -315,7 +454,7 @@ func (s *AdminService) ClosePoll(ctx context.Context, input ClosePollInput) (*re
 
 	// Log the action
 	if err := s.AuditService.LogPollAction(ctx, input.ActorUserID, AuditActionPollClose, input.PollID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log poll closure: %v", err)
+		util.Logger(ctx).Error("failed to log poll closure", "error", err)
 	}
 
 	return &poll, nil
@@ -336,7 +475,7 @@ func (s *AdminService) ReopenPoll(ctx context.Context, input ReopenPollInput) (*
 
 	// Log the action
 	if err := s.AuditService.LogPollAction(ctx, input.ActorUserID, AuditActionPollReopen, input.PollID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log poll reopen: %v", err)
+		util.Logger(ctx).Error("failed to log poll reopen", "error", err)
 	}
 
 	return &poll, nil
@@ -352,7 +491,7 @@ type DeletePollInput struct {
 func (s *AdminService) DeletePoll(ctx context.Context, input DeletePollInput) error {
 	// Log the action before deletion
 	if err := s.AuditService.LogPollAction(ctx, input.ActorUserID, AuditActionPollDelete, input.PollID); err != nil {
-		log.Printf("[AUDIT ERROR] Failed to log poll deletion: %v", err)
+		util.Logger(ctx).Error("failed to log poll deletion", "error", err)
 	}
 
 	// Delete the poll
@@ -2,11 +2,12 @@ package service
 
 import (
 	"context"
-	"log"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
 
 // AuditService handles audit logging for admin actions
@@ -30,18 +31,55 @@ const (
 	AuditActionUserDelete        AuditAction = "user.delete"
 	AuditActionUserRoleChange    AuditAction = "user.role_change"
 	AuditActionUserPasswordReset AuditAction = "user.password_reset"
+	AuditActionUserForceLogout   AuditAction = "user.force_logout"
+	AuditActionUserLockoutClear  AuditAction = "user.lockout_clear"
+
+	AuditActionUserTwoFactorEnable  AuditAction = "user.2fa_enable"
+	AuditActionUserTwoFactorDisable AuditAction = "user.2fa_disable"
+	AuditActionUserIdentityLink     AuditAction = "user.identity_link"
+	AuditActionUserEmailChange      AuditAction = "user.email_change"
+	AuditActionUserDeletionSchedule AuditAction = "user.deletion_schedule"
+	AuditActionUserDeletionCancel   AuditAction = "user.deletion_cancel"
+	AuditActionUserSuspend          AuditAction = "user.suspend"
+	AuditActionUserUnsuspend        AuditAction = "user.unsuspend"
+
+	// Impersonation actions
+	AuditActionImpersonationStart   AuditAction = "impersonation.start"
+	AuditActionImpersonationEnd     AuditAction = "impersonation.end"
+	AuditActionImpersonationRequest AuditAction = "impersonation.request"
 
 	// Poll actions
 	AuditActionPollClose  AuditAction = "poll.close"
 	AuditActionPollReopen AuditAction = "poll.reopen"
 	AuditActionPollDelete AuditAction = "poll.delete"
 
+	// Role actions
+	AuditActionRoleCreate AuditAction = "role.create"
+	AuditActionRoleUpdate AuditAction = "role.update"
+	AuditActionRoleDelete AuditAction = "role.delete"
+
+	// Email actions
+	AuditActionEmailRetry AuditAction = "email.retry"
+
+	// Auth actions
+	AuditActionAuthRefreshReuse   AuditAction = "auth.refresh_token_reuse"
+	AuditActionAuthMagicLinkLogin AuditAction = "auth.magic_link_login"
+	AuditActionAuthAccountLocked  AuditAction = "auth.account_locked"
+	AuditActionAuthAccountUnlock  AuditAction = "auth.account_unlock"
+
 	// Admin actions
 	AuditActionAdminLogin AuditAction = "admin.login"
 )
 
 // LogEntry creates a new audit log entry
 func (s *AuditService) LogEntry(ctx context.Context, actorUserID uuid.UUID, action AuditAction, subjectType string, subjectID uuid.UUID) error {
+	return s.LogEntryWithMeta(ctx, actorUserID, action, subjectType, subjectID, nil)
+}
+
+// LogEntryWithMeta creates a new audit log entry with additional context data.
+// The request ID from ctx is always added to meta so entries can be correlated with logs,
+// and so are the admin and the user when the request was made while impersonating.
+func (s *AuditService) LogEntryWithMeta(ctx context.Context, actorUserID uuid.UUID, action AuditAction, subjectType string, subjectID uuid.UUID, meta map[string]interface{}) error {
 	var pgSubjectID pgtype.UUID
 	if subjectID != uuid.Nil {
 		pgSubjectID = pgtype.UUID{
@@ -55,23 +93,53 @@ func (s *AuditService) LogEntry(ctx context.Context, actorUserID uuid.UUID, acti
 		}
 	}
 
-	log.Printf("[AUDIT] Creating audit log: actor=%s, action=%s, subject_type=%s, subject_id=%s",
-		actorUserID, action, subjectType, subjectID)
+	if requestID := util.RequestIDFromContext(ctx); requestID != "" {
+		if meta == nil {
+			meta = make(map[string]interface{}, 1)
+		}
+		meta["request_id"] = requestID
+	}
+
+	if impersonatorID, ok := util.ImpersonatorIDFromContext(ctx); ok {
+		if meta == nil {
+			meta = make(map[string]interface{}, 2)
+		}
+		meta["impersonator_id"] = impersonatorID.String()
+		if userID, ok := util.UserIDFromContext(ctx); ok {
+			meta["impersonated_user_id"] = userID.String()
+		}
+	}
+
+	var metaJSON []byte
+	if len(meta) > 0 {
+		var err error
+		metaJSON, err = json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger := util.Logger(ctx).With(
+		"actor", actorUserID.String(),
+		"action", string(action),
+		"subject_type", subjectType,
+		"subject_id", subjectID.String(),
+	)
 
 	_, err := s.Queries.CreateAuditLog(ctx, repository.CreateAuditLogParams{
 		ActorUserID: actorUserID,
 		Action:      string(action),
 		SubjectType: subjectType,
 		SubjectID:   pgSubjectID,
-		Meta:        nil,
+		Meta:        metaJSON,
 	})
 
 	if err != nil {
-		log.Printf("[AUDIT] Failed to create audit log: %v", err)
+		logger.Error("failed to create audit log", "error", err)
 		return err
 	}
 
-	log.Printf("[AUDIT] Successfully created audit log for action: %s", action)
+	logger.Debug("audit log created")
 	return nil
 }
 
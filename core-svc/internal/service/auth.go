@@ -1,17 +1,29 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
 )
 
 // auth service struct
 type AuthService struct {
-	Config       *util.Config
-	Queries      *repository.Queries
-	TokenService *TokenService
-	EmailService *EmailService
+	Config            *util.Config
+	Queries           *repository.Queries
+	TokenService      *TokenService
+	EmailService      *EmailService
+	AuditService      *AuditService
+	SessionService    *SessionService
+	TwoFactorService  *TwoFactorService
+	LoginProtection   *LoginProtectionService
+	SuspensionService *SuspensionService
 }
 
 // constructor
@@ -22,10 +34,15 @@ func NewAuthService(
 	emailService *EmailService,
 ) *AuthService {
 	return &AuthService{
-		Config:       config,
-		Queries:      queries,
-		TokenService: tokenService,
-		EmailService: emailService,
+		Config:            config,
+		Queries:           queries,
+		TokenService:      tokenService,
+		EmailService:      emailService,
+		AuditService:      NewAuditService(queries),
+		SessionService:    NewSessionService(queries),
+		TwoFactorService:  NewTwoFactorService(queries),
+		LoginProtection:   NewLoginProtectionService(queries, emailService),
+		SuspensionService: NewSuspensionService(queries),
 	}
 }
 
@@ -52,28 +69,188 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginResult is either a token pair or, for accounts with 2FA, a challenge to complete with a code
+type LoginResult struct {
+	Tokens TokenPair
+	// MFAToken is set instead of Tokens when a second factor is needed (see CompleteTwoFactorLogin)
+	MFAToken string
+	// TwoFactorSetupRequired flags accounts whose role requires 2FA but haven't enrolled yet
+	TwoFactorSetupRequired bool
+}
+
 // login logic, token issue
-func (a *AuthService) Login(c *gin.Context, input LoginInput) (string, error) {
+func (a *AuthService) Login(c *gin.Context, input LoginInput) (LoginResult, error) {
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+
+	if err := a.LoginProtection.CheckIP(ctx, ipAddress); err != nil {
+		return LoginResult{}, err
+	}
+
 	query, err := a.Queries.GetPasswordHashByEmail(c, input.Email)
 
 	if err != nil {
-		return "", util.ErrInvalidCredentials
+		a.recordLoginFailure(ctx, input.Email, uuid.Nil, ipAddress)
+		return LoginResult{}, util.ErrInvalidCredentials
 	}
 
-	same, err := util.VerifyPassword(input.Password, query.PasswordHash)
+	// a locked account is refused before the password is checked
+	if err := a.LoginProtection.CheckAccount(ctx, query.ID); err != nil {
+		return LoginResult{}, err
+	}
 
+	same, err := util.VerifyPassword(input.Password, query.PasswordHash)
+	if err != nil {
+		// a corrupt or unsupported stored hash is our fault, not a wrong password
+		return LoginResult{}, err
+	}
 	if !same {
-		return "", util.ErrInvalidCredentials
+		a.recordLoginFailure(ctx, input.Email, query.ID, ipAddress)
+		return LoginResult{}, util.ErrInvalidCredentials
 	}
 
-	token, err := a.TokenService.GenerateToken(AuthTokenData{ID: query.ID})
+	// upgrade legacy (bcrypt) or outdated hashes while we have the plaintext
+	if util.PasswordNeedsRehash(query.PasswordHash) {
+		a.rehashPassword(ctx, query.ID, input.Password)
+	}
 
+	result, err := a.completeLogin(c, query.ID)
 	if err != nil {
-		// Token generation is internal error, not user fault
-		return "", err
+		return LoginResult{}, err
+	}
+
+	// with 2FA the failures are only reset once the code is right too (see
+	// CompleteTwoFactorLogin), so wrong codes keep counting toward a lockout
+	if result.MFAToken == "" {
+		a.recordLoginSuccess(ctx, input.Email, query.ID, ipAddress)
 	}
 
-	return token, nil
+	return result, nil
+}
+
+// rehashPassword stores a new hash with the current algorithm and parameters;
+// failures are logged and retried on the next login
+func (a *AuthService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	passwordHash, err := util.HashPassword(password)
+	if err != nil {
+		util.Logger(ctx).Error("failed to rehash password", "error", err)
+		return
+	}
+
+	if err := a.Queries.UpdateUserPasswordHash(ctx, repository.UpdateUserPasswordHashParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to store rehashed password", "error", err)
+		return
+	}
+
+	util.Logger(ctx).Info("password rehashed", "user_id", userID.String())
+}
+
+// recordLoginFailure tracks a failed login; tracking errors don't change the login response
+func (a *AuthService) recordLoginFailure(ctx context.Context, email string, userID uuid.UUID, ipAddress string) {
+	if err := a.LoginProtection.RecordFailure(ctx, email, userID, ipAddress); err != nil {
+		util.Logger(ctx).Error("failed to record login attempt", "error", err)
+	}
+}
+
+// recordLoginSuccess tracks a successful login; tracking errors don't change the login response
+func (a *AuthService) recordLoginSuccess(ctx context.Context, email string, userID uuid.UUID, ipAddress string) {
+	if err := a.LoginProtection.RecordSuccess(ctx, email, userID, ipAddress); err != nil {
+		util.Logger(ctx).Error("failed to record login attempt", "error", err)
+	}
+}
+
+// completeLogin finishes a login once the user is identified (password or OIDC):
+// accounts with 2FA get an MFA challenge, everyone else gets a new session
+func (a *AuthService) completeLogin(c *gin.Context, userID uuid.UUID) (LoginResult, error) {
+	// checked before the MFA challenge so a suspended user isn't asked for a code
+	if err := a.SuspensionService.CheckNotSuspended(c.Request.Context(), userID); err != nil {
+		return LoginResult{}, err
+	}
+
+	twoFactorEnabled, err := a.TwoFactorService.IsEnabled(c.Request.Context(), userID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	if twoFactorEnabled {
+		mfaToken, err := a.TokenService.GenerateMFAToken(userID)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return LoginResult{MFAToken: mfaToken}, nil
+	}
+
+	// Token generation is internal error, not user fault
+	tokens, err := a.GenerateTokens(c.Request.Context(), userID, sessionMetaFromRequest(c))
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	result := LoginResult{Tokens: tokens}
+
+	// let the client prompt enrolment; admin routes stay closed until then
+	if user, err := a.Queries.GetUserByID(c.Request.Context(), userID); err == nil {
+		result.TwoFactorSetupRequired = IsTwoFactorRequired(user.Role)
+	}
+
+	return result, nil
+}
+
+// TwoFactorLoginInput completes a login that returned an MFA challenge
+type TwoFactorLoginInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// a TOTP code or a recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+// CompleteTwoFactorLogin checks the second factor and issues tokens. Wrong codes count toward
+// the account lockout, and a challenge token is refused after a few of them.
+func (a *AuthService) CompleteTwoFactorLogin(c *gin.Context, input TwoFactorLoginInput) (TokenPair, error) {
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+
+	challenge, err := a.TokenService.ParseMFAToken(input.MFAToken)
+	if err != nil {
+		return TokenPair{}, util.ErrInvalidCredentials
+	}
+
+	if err := a.LoginProtection.CheckIP(ctx, ipAddress); err != nil {
+		return TokenPair{}, err
+	}
+	if err := a.LoginProtection.CheckAccount(ctx, challenge.UserID); err != nil {
+		return TokenPair{}, err
+	}
+	if err := a.LoginProtection.CheckMFAChallenge(ctx, challenge); err != nil {
+		return TokenPair{}, err
+	}
+
+	user, err := a.Queries.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return TokenPair{}, util.ErrInvalidCredentials
+	}
+
+	if err := a.TwoFactorService.Verify(ctx, challenge.UserID, input.Code); err != nil {
+		if errors.Is(err, util.ErrInvalidTwoFactorCode) {
+			if err := a.LoginProtection.RecordMFAFailure(ctx, challenge, user.Email, ipAddress); err != nil {
+				util.Logger(ctx).Error("failed to record login attempt", "error", err)
+			}
+		}
+		return TokenPair{}, err
+	}
+
+	a.recordLoginSuccess(ctx, user.Email, challenge.UserID, ipAddress)
+
+	return a.GenerateTokens(ctx, challenge.UserID, sessionMetaFromRequest(c))
+}
+
+func sessionMetaFromRequest(c *gin.Context) SessionMeta {
+	return SessionMeta{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
 }
 
 // registration input struct
@@ -86,6 +263,10 @@ type RegisterInput struct {
 // register user
 func (a *AuthService) Register(c *gin.Context, input RegisterInput) (repository.GetUserByIDRow, error) {
 
+	if err := util.ValidatePassword(input.Password, input.Name, input.Email); err != nil {
+		return repository.GetUserByIDRow{}, err
+	}
+
 	exists, err := a.Queries.CheckEmailExists(c.Request.Context(), input.Email)
 
 	if err != nil {
@@ -102,25 +283,29 @@ func (a *AuthService) Register(c *gin.Context, input RegisterInput) (repository.
 		return repository.GetUserByIDRow{}, err
 	}
 
-	user, err := a.Queries.CreateUser(c.Request.Context(), repository.CreateUserParams{
-		Name:         input.Name,
-		Email:        input.Email,
-		PasswordHash: passwordHash,
+	// the user and their verification email are created together, so nobody ends up
+	// registered without a way to verify
+	var user repository.CreateUserRow
+	err = a.Queries.InTx(c.Request.Context(), func(q *repository.Queries) error {
+		user, err = q.CreateUser(c.Request.Context(), repository.CreateUserParams{
+			Name:         input.Name,
+			Email:        input.Email,
+			PasswordHash: passwordHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		if a.EmailService != nil {
+			return a.EmailService.WithQueries(q).SendVerificationEmail(c.Request.Context(), user.ID, user.Email, user.Name)
+		}
+		return nil
 	})
 
 	if err != nil {
 		return repository.GetUserByIDRow{}, err
 	}
 
-	// Send verification email (don't fail registration if email fails)
-	if a.EmailService != nil {
-		err = a.EmailService.SendVerificationEmail(c.Request.Context(), user.ID, user.Email, user.Name)
-		if err != nil {
-			// Log error but don't fail registration
-			// User can request resend later
-		}
-	}
-
 	return repository.GetUserByIDRow{
 		ID:              user.ID,
 		Name:            user.Name,
@@ -132,9 +317,146 @@ func (a *AuthService) Register(c *gin.Context, input RegisterInput) (repository.
 
 }
 
-// Token management stubs
-//func (a *AuthService) GenerateTokens(...) { ... } // TODO
-//func (a *AuthService) ValidateAccessToken(...) { ... } // TODO
-//func (a *AuthService) ValidateRefreshToken(...) { ... } // TODO
-//func (a *AuthService) RefreshTokens(...) { ... } // TODO
-//func (a *AuthService) RevokeRefreshToken(...) { ... } // TODO
+// TokenPair is a short-lived access token and the refresh token used to renew it
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// GenerateTokens creates a session for a new login and issues its first access and refresh tokens.
+// The session ID doubles as the refresh token family.
+func (a *AuthService) GenerateTokens(ctx context.Context, userID uuid.UUID, meta SessionMeta) (TokenPair, error) {
+	if err := a.SuspensionService.CheckNotSuspended(ctx, userID); err != nil {
+		return TokenPair{}, err
+	}
+
+	session, err := a.SessionService.CreateSession(ctx, userID, meta)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, _, err := a.issueTokens(ctx, userID, session.ID)
+	return pair, err
+}
+
+// issueTokens signs an access token and stores a new refresh token in the given family
+func (a *AuthService) issueTokens(ctx context.Context, userID, familyID uuid.UUID) (TokenPair, repository.RefreshToken, error) {
+	now := time.Now()
+
+	accessToken, err := a.TokenService.GenerateToken(AuthTokenData{ID: userID, SessionID: familyID})
+	if err != nil {
+		return TokenPair{}, repository.RefreshToken{}, err
+	}
+
+	refreshToken, refreshHash, err := GenerateOpaqueToken()
+	if err != nil {
+		return TokenPair{}, repository.RefreshToken{}, err
+	}
+
+	stored, err := a.Queries.CreateRefreshToken(ctx, repository.CreateRefreshTokenParams{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: now.Add(a.Config.RefreshTokenTTL()),
+	})
+	if err != nil {
+		return TokenPair{}, repository.RefreshToken{}, err
+	}
+
+	return TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(a.Config.AccessTokenTTL()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, stored, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair in the same family.
+// A refresh token can be used once; presenting an already rotated token means it was
+// stolen (or replayed), so the whole family is revoked and the user has to log in again.
+func (a *AuthService) RefreshTokens(ctx context.Context, refreshToken string, ipAddress string) (TokenPair, error) {
+	current, err := a.Queries.GetRefreshTokenByHash(ctx, HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TokenPair{}, util.ErrInvalidRefresh
+		}
+		return TokenPair{}, err
+	}
+
+	if current.RevokedAt.Valid || time.Now().After(current.ExpiresAt) {
+		return TokenPair{}, util.ErrInvalidRefresh
+	}
+
+	if current.RotatedAt.Valid {
+		return TokenPair{}, a.handleRefreshReuse(ctx, current)
+	}
+
+	// the session may have been revoked from another device
+	if err := a.SessionService.ValidateSession(ctx, current.FamilyID, current.UserID, ipAddress); err != nil {
+		if errors.Is(err, util.ErrSessionRevoked) {
+			return TokenPair{}, util.ErrInvalidRefresh
+		}
+		return TokenPair{}, err
+	}
+
+	if err := a.SuspensionService.CheckNotSuspended(ctx, current.UserID); err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, next, err := a.issueTokens(ctx, current.UserID, current.FamilyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// only one caller can rotate a token; losing the race is treated as reuse
+	rotated, err := a.Queries.RotateRefreshToken(ctx, repository.RotateRefreshTokenParams{
+		ID:         current.ID,
+		ReplacedBy: pgtype.UUID{Bytes: next.ID, Valid: true},
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if rotated == 0 {
+		return TokenPair{}, a.handleRefreshReuse(ctx, current)
+	}
+
+	return pair, nil
+}
+
+// handleRefreshReuse revokes the session of a reused refresh token and records it
+func (a *AuthService) handleRefreshReuse(ctx context.Context, token repository.RefreshToken) error {
+	if err := a.SessionService.RevokeSession(ctx, token.UserID, token.FamilyID); err != nil && !errors.Is(err, util.ErrSessionNotFound) {
+		return err
+	}
+
+	util.Logger(ctx).Warn("refresh token reuse detected, token family revoked",
+		"user_id", token.UserID.String(),
+		"family_id", token.FamilyID.String(),
+	)
+
+	if err := a.AuditService.LogEntryWithMeta(ctx, token.UserID, AuditActionAuthRefreshReuse, "user", token.UserID, map[string]interface{}{
+		"family_id": token.FamilyID.String(),
+	}); err != nil {
+		util.Logger(ctx).Error("failed to write audit log", "error", err)
+	}
+
+	return util.ErrRefreshTokenReused
+}
+
+// RevokeRefreshToken ends the session of the given refresh token (logout)
+func (a *AuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	token, err := a.Queries.GetRefreshTokenByHash(ctx, HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrInvalidRefresh
+		}
+		return err
+	}
+
+	if err := a.SessionService.RevokeSession(ctx, token.UserID, token.FamilyID); err != nil && !errors.Is(err, util.ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
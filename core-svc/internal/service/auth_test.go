@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// A stored hash that can't be decoded is a server error; it must not count as a wrong
+// password, or the account would eventually be locked
+func TestLoginWithCorruptPasswordHashIsNotAFailedLogin(t *testing.T) {
+	db := newFakeDB()
+	db.returns("CountRecentFailedLoginAttemptsByIP", int64(0))
+	db.returns("GetPasswordHashByEmail", repository.GetPasswordHashByEmailRow{
+		ID:           uuid.New(),
+		PasswordHash: "$argon2id$v=19$m=oops",
+	})
+	db.returns("GetAccountLockout", nil)
+
+	config := newTestConfig(t)
+	s := NewAuthService(config, db.queries(), NewTokenService(config), nil)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+
+	_, err := s.Login(c, LoginInput{Email: "user@pollex.test", Password: "password"})
+	if err == nil || errors.Is(err, util.ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want the hash error", err)
+	}
+	if recorded := db.called("CreateLoginAttempt"); len(recorded) != 0 {
+		t.Errorf("login failure recorded: %v", recorded)
+	}
+	if recorded := db.called("RecordFailedLogin"); len(recorded) != 0 {
+		t.Errorf("failure counted toward a lockout: %v", recorded)
+	}
+}
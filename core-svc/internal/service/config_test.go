@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// newTestConfig returns a development config and loads a throwaway signing key for it
+func newTestConfig(t *testing.T) *util.Config {
+	t.Helper()
+	config := &util.Config{
+		Environment:                "development",
+		APIBaseURL:                 "http://api.pollex.test",
+		AppBaseURL:                 "http://pollex.test",
+		AccessTokenLifespanMinutes: 15,
+		RefreshTokenLifespanHours:  24,
+		Email: util.EmailConfig{
+			From:        "Pollex <noreply@pollex.test>",
+			ProductName: "Pollex",
+		},
+	}
+	if err := util.SetupSigningKeys(config); err != nil {
+		t.Fatalf("SetupSigningKeys: %v", err)
+	}
+	return config
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// EmailChangeService lets users move their account to a new email address. The new
+// address must be confirmed before it replaces the current one.
+type EmailChangeService struct {
+	Queries        *repository.Queries
+	EmailService   *EmailService
+	SessionService *SessionService
+	AuditService   *AuditService
+}
+
+func NewEmailChangeService(queries *repository.Queries, emailService *EmailService) *EmailChangeService {
+	return &EmailChangeService{
+		Queries:        queries,
+		EmailService:   emailService,
+		SessionService: NewSessionService(queries),
+		AuditService:   NewAuditService(queries),
+	}
+}
+
+// EmailChangeRequestInput asks to move the account to a new address.
+// The current password is required so a hijacked session can't take over the account.
+type EmailChangeRequestInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestChange sends a confirmation link to the new address and a notice to the current one
+func (s *EmailChangeService) RequestChange(ctx context.Context, userID uuid.UUID, input EmailChangeRequestInput) error {
+	user, err := s.Queries.GetUserWithPasswordByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrUserNotFound
+		}
+		return err
+	}
+
+	same, err := util.VerifyPassword(input.Password, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return util.ErrInvalidCredentials
+	}
+
+	newEmail := strings.TrimSpace(input.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return util.ErrEmailUnchanged
+	}
+
+	exists, err := s.Queries.CheckEmailExists(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return util.ErrEmailExists
+	}
+
+	// the notice is queued with the confirmation, so a change never goes unannounced to the
+	// current address
+	return s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		emailService := s.EmailService.WithQueries(q)
+		if err := emailService.SendEmailChangeConfirmation(ctx, userID, newEmail, user.Name); err != nil {
+			return err
+		}
+		return emailService.SendEmailChangeNotice(ctx, userID, user.Email, user.Name, newEmail)
+	})
+}
+
+// EmailChangeConfirmInput carries the token from the confirmation link
+type EmailChangeConfirmInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ConfirmChange swaps in the new address and signs the user out of every session except
+// currentSessionID. The link only works for the user who requested it.
+func (s *EmailChangeService) ConfirmChange(ctx context.Context, userID, currentSessionID uuid.UUID, input EmailChangeConfirmInput) (repository.UpdateUserEmailRow, error) {
+	dbToken, err := s.Queries.GetEmailChangeTokenByHash(ctx, HashOpaqueToken(input.Token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.UpdateUserEmailRow{}, util.ErrInvalidEmailChange
+		}
+		return repository.UpdateUserEmailRow{}, err
+	}
+
+	if dbToken.UserID != userID || dbToken.UsedAt.Valid || time.Now().After(dbToken.ExpiresAt) {
+		return repository.UpdateUserEmailRow{}, util.ErrInvalidEmailChange
+	}
+
+	// the address may have been registered since the change was requested
+	exists, err := s.Queries.CheckEmailExists(ctx, dbToken.NewEmail)
+	if err != nil {
+		return repository.UpdateUserEmailRow{}, err
+	}
+	if exists {
+		return repository.UpdateUserEmailRow{}, util.ErrEmailExists
+	}
+
+	previous, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return repository.UpdateUserEmailRow{}, err
+	}
+
+	// the link is only spent if the address is swapped and the other sessions are revoked
+	var user repository.UpdateUserEmailRow
+	var revoked int64
+	err = s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		// conditional update so two concurrent requests can't both use the link
+		consumed, err := q.MarkEmailChangeTokenUsed(ctx, dbToken.ID)
+		if err != nil {
+			return err
+		}
+		if consumed == 0 {
+			return util.ErrInvalidEmailChange
+		}
+
+		user, err = q.UpdateUserEmail(ctx, repository.UpdateUserEmailParams{
+			ID:    userID,
+			Email: dbToken.NewEmail,
+		})
+		if err != nil {
+			// registered between the check above and the update
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return util.ErrEmailExists
+			}
+			return err
+		}
+
+		revoked, err = NewSessionService(q).RevokeOtherSessions(ctx, userID, currentSessionID)
+		return err
+	})
+	if err != nil {
+		return repository.UpdateUserEmailRow{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, userID, AuditActionUserEmailChange, "user", userID, map[string]interface{}{
+		"old_email":        previous.Email,
+		"new_email":        user.Email,
+		"sessions_revoked": revoked,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log email change", "error", err)
+	}
+
+	return user, nil
+}
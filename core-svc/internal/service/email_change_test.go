@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// newEmailChangeFixture answers the lookups ConfirmChange makes before its transaction
+func newEmailChangeFixture(t *testing.T, userID uuid.UUID, token string) *fakeDB {
+	t.Helper()
+	db := newFakeDB()
+	db.returns("GetEmailChangeTokenByHash", repository.EmailChangeToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		NewEmail:  "new@pollex.test",
+		TokenHash: HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	})
+	db.returns("CheckEmailExists", false)
+	db.returns("GetUserByID", repository.GetUserByIDRow{ID: userID, Email: "old@pollex.test"})
+	db.returns("MarkEmailChangeTokenUsed", int64(1))
+	return db
+}
+
+func TestConfirmChangeRevokesSessionsInTheSameTransaction(t *testing.T) {
+	userID, sessionID := uuid.New(), uuid.New()
+	db := newEmailChangeFixture(t, userID, "token")
+	db.returns("UpdateUserEmail", repository.UpdateUserEmailRow{ID: userID, Email: "new@pollex.test"})
+	db.returns("RevokeOtherUserSessions", int64(2))
+	db.returns("RevokeOtherRefreshTokensByUserID", nil)
+	db.returns("CreateAuditLog", repository.AuditLog{})
+	s := NewEmailChangeService(db.queries(), nil)
+
+	user, err := s.ConfirmChange(t.Context(), userID, sessionID, EmailChangeConfirmInput{Token: "token"})
+	if err != nil {
+		t.Fatalf("ConfirmChange: %v", err)
+	}
+	if user.Email != "new@pollex.test" {
+		t.Errorf("email = %q, want the new address", user.Email)
+	}
+	if db.begins != 1 || db.commits != 1 || db.rollbacks != 0 {
+		t.Errorf("begins/commits/rollbacks = %d/%d/%d, want 1/1/0", db.begins, db.commits, db.rollbacks)
+	}
+	if revoked := db.called("RevokeOtherUserSessions"); len(revoked) != 1 || revoked[0].Args[1] != sessionID {
+		t.Errorf("RevokeOtherUserSessions calls = %v, want one keeping the current session", revoked)
+	}
+}
+
+func TestConfirmChangeRollsBackWhenTheAddressWasTaken(t *testing.T) {
+	userID := uuid.New()
+	db := newEmailChangeFixture(t, userID, "token")
+	db.on("UpdateUserEmail", func([]any) (any, error) {
+		return nil, &pgconn.PgError{Code: "23505", ConstraintName: "app_user_email_key"}
+	})
+	s := NewEmailChangeService(db.queries(), nil)
+
+	_, err := s.ConfirmChange(t.Context(), userID, uuid.New(), EmailChangeConfirmInput{Token: "token"})
+	if !errors.Is(err, util.ErrEmailExists) {
+		t.Fatalf("err = %v, want ErrEmailExists", err)
+	}
+	// the token was marked used inside the transaction, so the rollback leaves it usable
+	if db.begins != 1 || db.commits != 0 || db.rollbacks != 1 {
+		t.Errorf("begins/commits/rollbacks = %d/%d/%d, want 1/0/1", db.begins, db.commits, db.rollbacks)
+	}
+	if revoked := db.called("RevokeOtherUserSessions"); len(revoked) != 0 {
+		t.Errorf("sessions revoked for a failed change: %v", revoked)
+	}
+}
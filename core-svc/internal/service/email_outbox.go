@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+	"github.com/yatochka-dev/pollex/core-svc/internal/telemetry"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Delivery status of an outbox email
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
+
+const (
+	// an email is marked failed after this many attempts; retries back off exponentially
+	// from emailRetryBaseDelay, so the last attempt is about an hour after the first
+	emailMaxAttempts    = 8
+	emailRetryBaseDelay = 30 * time.Second
+	emailRetryMaxDelay  = 30 * time.Minute
+
+	// emails claimed per worker run
+	emailBatchSize = 20
+	// how long a claimed email is hidden from other workers; longer than any send can take
+	emailSendLease = 5 * time.Minute
+	// upper bound for one delivery attempt
+	emailSendTimeout = time.Minute
+
+	// sent emails are kept this long so their delivery status can be looked up
+	sentEmailRetention = 30 * 24 * time.Hour
+)
+
+// EmailOutboxService delivers queued emails and lets admins inspect and retry them
+type EmailOutboxService struct {
+	Queries      *repository.Queries
+	AuditService *AuditService
+	Mailer       mailer.Mailer
+}
+
+func NewEmailOutboxService(queries *repository.Queries, mail mailer.Mailer) *EmailOutboxService {
+	return &EmailOutboxService{
+		Queries:      queries,
+		AuditService: NewAuditService(queries),
+		Mailer:       mail,
+	}
+}
+
+// emailRetryDelay is the wait before the next attempt after the given number of failures
+func emailRetryDelay(failures int32) time.Duration {
+	delay := emailRetryBaseDelay
+	for i := int32(1); i < failures && delay < emailRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, emailRetryMaxDelay)
+}
+
+// DeliverDue sends one batch of due emails and returns how many were claimed
+func (s *EmailOutboxService) DeliverDue(ctx context.Context) (int, error) {
+	emails, err := s.Queries.ClaimDueEmails(ctx, repository.ClaimDueEmailsParams{
+		LeaseUntil: time.Now().Add(emailSendLease),
+		BatchSize:  emailBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		if err := s.deliver(ctx, email); err != nil {
+			util.Logger(ctx).Error("failed to record email delivery", "outbox_id", email.ID.String(), "error", err)
+		}
+	}
+
+	return len(emails), nil
+}
+
+// deliver makes one delivery attempt and records its outcome. The returned error is about
+// recording the outcome; a failed send is stored on the email.
+func (s *EmailOutboxService) deliver(ctx context.Context, email repository.EmailOutbox) error {
+	messageID, sendErr := s.send(ctx, email)
+	if sendErr == nil {
+		util.Logger(ctx).Info("email sent",
+			"outbox_id", email.ID.String(),
+			"template", email.Template,
+			"message_id", messageID,
+		)
+		return s.Queries.MarkEmailSent(ctx, repository.MarkEmailSentParams{
+			ID:                email.ID,
+			ProviderMessageID: pgtype.Text{String: messageID, Valid: messageID != ""},
+		})
+	}
+
+	failures := email.Attempts + 1
+	status := EmailStatusPending
+	if failures >= emailMaxAttempts {
+		status = EmailStatusFailed
+	}
+
+	util.Logger(ctx).Warn("email delivery failed",
+		"outbox_id", email.ID.String(),
+		"template", email.Template,
+		"attempts", failures,
+		"status", status,
+		"error", sendErr,
+	)
+
+	return s.Queries.RecordEmailFailure(ctx, repository.RecordEmailFailureParams{
+		ID:            email.ID,
+		Status:        status,
+		LastError:     pgtype.Text{String: sendErr.Error(), Valid: true},
+		NextAttemptAt: time.Now().Add(emailRetryDelay(failures)),
+	})
+}
+
+// send hands the email to the mailer inside its own span
+func (s *EmailOutboxService) send(ctx context.Context, email repository.EmailOutbox) (_ string, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "Mailer.Send",
+		attribute.String("email.outbox_id", email.ID.String()),
+		attribute.String("email.template", email.Template),
+	)
+	defer telemetry.EndSpan(span, &err)
+
+	ctx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	defer cancel()
+
+	msg := mailer.Message{
+		From:    email.Sender,
+		To:      []string{email.Recipient},
+		ReplyTo: email.ReplyTo,
+		Subject: email.Subject,
+		HTML:    email.HtmlBody,
+		Text:    email.TextBody,
+	}
+	if email.UnsubscribeUrl != "" {
+		// RFC 8058 one-click unsubscribe: mail clients POST to the URL
+		msg.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + email.UnsubscribeUrl + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+
+	messageID, err := s.Mailer.Send(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+
+	span.SetAttributes(attribute.String("email.message_id", messageID))
+	return messageID, nil
+}
+
+// PurgeSent deletes sent emails older than the retention period
+func (s *EmailOutboxService) PurgeSent(ctx context.Context) (int64, error) {
+	return s.Queries.DeleteSentEmailsBefore(ctx, pgtype.Timestamptz{Time: time.Now().Add(-sentEmailRetention), Valid: true})
+}
+
+// RunWorker delivers due emails every interval until ctx is cancelled. A full batch is
+// followed by the next one right away, so a backlog drains without waiting for the ticker.
+func (s *EmailOutboxService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		claimed, err := s.DeliverDue(ctx)
+		if err != nil {
+			util.Logger(ctx).Error("email outbox run failed", "error", err)
+		}
+
+		if time.Since(lastPurge) > time.Hour {
+			if purged, err := s.PurgeSent(ctx); err != nil {
+				util.Logger(ctx).Error("failed to purge sent emails", "error", err)
+			} else if purged > 0 {
+				util.Logger(ctx).Info("purged sent emails", "count", purged)
+			}
+			lastPurge = time.Now()
+		}
+
+		if claimed == emailBatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListEmailsInput contains the status filter and pagination parameters
+type ListEmailsInput struct {
+	Status string
+	Limit  int32
+	Offset int32
+}
+
+// ListEmailsResponse contains paginated outbox emails
+type ListEmailsResponse struct {
+	Emails []OutboxEmail `json:"emails"`
+	Total  int64         `json:"total"`
+	Limit  int32         `json:"limit"`
+	Offset int32         `json:"offset"`
+}
+
+// OutboxEmail is an outbox entry without its body, which may contain one-time links
+type OutboxEmail struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            *uuid.UUID `json:"user_id"`
+	Template          string     `json:"template"`
+	Recipient         string     `json:"recipient"`
+	Subject           string     `json:"subject"`
+	Status            string     `json:"status"`
+	Attempts          int32      `json:"attempts"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at"`
+	LastAttemptAt     *time.Time `json:"last_attempt_at"`
+	LastError         *string    `json:"last_error"`
+	ProviderMessageID *string    `json:"provider_message_id"`
+	SentAt            *time.Time `json:"sent_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func newOutboxEmail(email repository.EmailOutbox) OutboxEmail {
+	out := OutboxEmail{
+		ID:        email.ID,
+		Template:  email.Template,
+		Recipient: email.Recipient,
+		Subject:   email.Subject,
+		Status:    email.Status,
+		Attempts:  email.Attempts,
+		CreatedAt: email.CreatedAt,
+	}
+	if email.UserID.Valid {
+		userID := uuid.UUID(email.UserID.Bytes)
+		out.UserID = &userID
+	}
+	if email.Status == EmailStatusPending {
+		out.NextAttemptAt = &email.NextAttemptAt
+	}
+	if email.LastAttemptAt.Valid {
+		out.LastAttemptAt = &email.LastAttemptAt.Time
+	}
+	if email.LastError.Valid {
+		out.LastError = &email.LastError.String
+	}
+	if email.ProviderMessageID.Valid {
+		out.ProviderMessageID = &email.ProviderMessageID.String
+	}
+	if email.SentAt.Valid {
+		out.SentAt = &email.SentAt.Time
+	}
+	return out
+}
+
+// ListEmails lists outbox emails with the given status, newest first
+func (s *EmailOutboxService) ListEmails(ctx context.Context, input ListEmailsInput) (*ListEmailsResponse, error) {
+	switch input.Status {
+	case EmailStatusPending, EmailStatusSent, EmailStatusFailed:
+	default:
+		return nil, util.ErrInvalidEmailStatus
+	}
+
+	emails, err := s.Queries.ListEmailOutboxByStatus(ctx, repository.ListEmailOutboxByStatusParams{
+		Status: input.Status,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.Queries.CountEmailOutboxByStatus(ctx, input.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]OutboxEmail, 0, len(emails))
+	for _, email := range emails {
+		out = append(out, newOutboxEmail(email))
+	}
+
+	return &ListEmailsResponse{
+		Emails: out,
+		Total:  total,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}, nil
+}
+
+// GetEmail returns one outbox email
+func (s *EmailOutboxService) GetEmail(ctx context.Context, id uuid.UUID) (OutboxEmail, error) {
+	email, err := s.Queries.GetEmailOutbox(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OutboxEmail{}, util.ErrEmailNotFound
+		}
+		return OutboxEmail{}, err
+	}
+	return newOutboxEmail(email), nil
+}
+
+// RetryEmail puts a failed email back in the queue
+func (s *EmailOutboxService) RetryEmail(ctx context.Context, actorUserID, id uuid.UUID) (OutboxEmail, error) {
+	email, err := s.GetEmail(ctx, id)
+	if err != nil {
+		return OutboxEmail{}, err
+	}
+
+	retried, err := s.Queries.RetryEmail(ctx, id)
+	if err != nil {
+		return OutboxEmail{}, err
+	}
+	if retried == 0 {
+		return OutboxEmail{}, util.ErrEmailNotFailed
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, actorUserID, AuditActionEmailRetry, "email", id, map[string]interface{}{
+		"template":   email.Template,
+		"recipient":  email.Recipient,
+		"attempts":   email.Attempts,
+		"last_error": email.LastError,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log email retry", "error", err)
+	}
+
+	email, err = s.GetEmail(ctx, id)
+	if err != nil {
+		return OutboxEmail{}, fmt.Errorf("failed to reload email: %w", err)
+	}
+	return email, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+)
+
+// newOutboxFixture is a fake database that accepts enqueued emails, and an outbox delivering
+// through a memory mailer
+func newOutboxFixture(t *testing.T) (*fakeDB, *EmailService, *EmailOutboxService, *mailer.MemoryMailer) {
+	t.Helper()
+	db := newFakeDB()
+	db.returns("GetUserLocale", mailer.DefaultLocale)
+	db.on("EnqueueEmail", func([]any) (any, error) { return uuid.New(), nil })
+	db.returns("MarkEmailSent", nil)
+	db.returns("RecordEmailFailure", nil)
+
+	mail := mailer.NewMemoryMailer()
+	return db, NewEmailService(db.queries(), newTestConfig(t)), NewEmailOutboxService(db.queries(), mail), mail
+}
+
+// enqueuedEmails turns the EnqueueEmail calls into the rows ClaimDueEmails hands the worker
+func enqueuedEmails(db *fakeDB) []repository.EmailOutbox {
+	var emails []repository.EmailOutbox
+	for _, call := range db.called("EnqueueEmail") {
+		emails = append(emails, repository.EmailOutbox{
+			ID:             uuid.New(),
+			UserID:         call.Args[0].(pgtype.UUID),
+			Template:       call.Args[1].(string),
+			Sender:         call.Args[2].(string),
+			ReplyTo:        call.Args[3].(string),
+			Recipient:      call.Args[4].(string),
+			Subject:        call.Args[5].(string),
+			HtmlBody:       call.Args[6].(string),
+			TextBody:       call.Args[7].(string),
+			UnsubscribeUrl: call.Args[8].(string),
+			Status:         EmailStatusPending,
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+		})
+	}
+	return emails
+}
+
+func TestNotificationIsEnqueuedAndDeliveredWithListUnsubscribe(t *testing.T) {
+	db, emails, outbox, mail := newOutboxFixture(t)
+	db.returns("GetNotificationPreference", nil) // never set, so enabled
+
+	userID := uuid.New()
+	err := emails.SendPollReminderEmail(t.Context(), userID, "voter@pollex.test", "Voter", uuid.New(), "Lunch?", time.Now().Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("SendPollReminderEmail: %v", err)
+	}
+
+	queued := enqueuedEmails(db)
+	if len(queued) != 1 {
+		t.Fatalf("queued %d emails, want 1", len(queued))
+	}
+	email := queued[0]
+	if email.Template != mailer.TemplatePollReminder || email.Recipient != "voter@pollex.test" || email.Sender != "Pollex <noreply@pollex.test>" {
+		t.Errorf("queued %s to %s from %s", email.Template, email.Recipient, email.Sender)
+	}
+	if !strings.HasPrefix(email.UnsubscribeUrl, "http://api.pollex.test/notifications/unsubscribe?token=") {
+		t.Errorf("unsubscribe URL = %q, want the API's one-click endpoint", email.UnsubscribeUrl)
+	}
+	if !strings.Contains(email.TextBody, "http://pollex.test/unsubscribe?token=") {
+		t.Error("body has no link to the unsubscribe page")
+	}
+
+	db.returns("ClaimDueEmails", queued)
+	if claimed, err := outbox.DeliverDue(t.Context()); err != nil || claimed != 1 {
+		t.Fatalf("DeliverDue = %d, %v; want 1 claimed", claimed, err)
+	}
+
+	sent, ok := mail.Last()
+	if !ok {
+		t.Fatal("nothing was sent")
+	}
+	if sent.Subject != email.Subject || len(sent.To) != 1 || sent.To[0] != "voter@pollex.test" {
+		t.Errorf("sent %q to %v", sent.Subject, sent.To)
+	}
+	if got, want := sent.Headers["List-Unsubscribe"], "<"+email.UnsubscribeUrl+">"; got != want {
+		t.Errorf("List-Unsubscribe = %q, want %q", got, want)
+	}
+	if got := sent.Headers["List-Unsubscribe-Post"]; got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+
+	marked := db.called("MarkEmailSent")
+	if len(marked) != 1 || marked[0].Args[0] != email.ID {
+		t.Fatalf("MarkEmailSent calls = %v, want one for the queued email", marked)
+	}
+	if messageID := marked[0].Args[1].(pgtype.Text); !messageID.Valid || messageID.String == "" {
+		t.Error("provider message ID was not stored")
+	}
+}
+
+func TestNotificationIsSkippedWhenTurnedOff(t *testing.T) {
+	db, emails, _, _ := newOutboxFixture(t)
+	db.returns("GetNotificationPreference", false)
+
+	err := emails.SendPollResultsEmail(t.Context(), uuid.New(), "voter@pollex.test", "Voter", PollResults{PollID: uuid.New(), Question: "Lunch?"}, false)
+	if err != nil {
+		t.Fatalf("SendPollResultsEmail: %v", err)
+	}
+	if queued := db.called("EnqueueEmail"); len(queued) != 0 {
+		t.Errorf("queued %d emails for a category the user turned off", len(queued))
+	}
+	if checked := db.called("GetNotificationPreference"); len(checked) != 1 || checked[0].Args[1] != NotificationPollResults {
+		t.Errorf("GetNotificationPreference calls = %v, want one for poll results", checked)
+	}
+}
+
+func TestTransactionalEmailHasNoListUnsubscribe(t *testing.T) {
+	db, emails, outbox, mail := newOutboxFixture(t)
+
+	if err := emails.SendEmailChangeNotice(t.Context(), uuid.New(), "old@pollex.test", "User", "new@pollex.test"); err != nil {
+		t.Fatalf("SendEmailChangeNotice: %v", err)
+	}
+	db.returns("ClaimDueEmails", enqueuedEmails(db))
+	if _, err := outbox.DeliverDue(t.Context()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	sent, ok := mail.Last()
+	if !ok {
+		t.Fatal("nothing was sent")
+	}
+	if len(sent.Headers) != 0 {
+		t.Errorf("headers = %v, want none on a transactional email", sent.Headers)
+	}
+}
+
+func TestFailedSendIsRetriedWithBackoff(t *testing.T) {
+	db, _, outbox, mail := newOutboxFixture(t)
+	mail.FailWith(errors.New("connection refused"))
+
+	retried := repository.EmailOutbox{ID: uuid.New(), Template: mailer.TemplateVerifyEmail, Recipient: "user@pollex.test", Attempts: 2}
+	exhausted := repository.EmailOutbox{ID: uuid.New(), Template: mailer.TemplateVerifyEmail, Recipient: "user@pollex.test", Attempts: emailMaxAttempts - 1}
+	db.returns("ClaimDueEmails", []repository.EmailOutbox{retried, exhausted})
+
+	before := time.Now()
+	if claimed, err := outbox.DeliverDue(t.Context()); err != nil || claimed != 2 {
+		t.Fatalf("DeliverDue = %d, %v; want 2 claimed", claimed, err)
+	}
+	if sent := mail.Messages(); len(sent) != 0 {
+		t.Fatalf("%d messages delivered by a failing mailer", len(sent))
+	}
+	if marked := db.called("MarkEmailSent"); len(marked) != 0 {
+		t.Errorf("failed emails marked sent: %v", marked)
+	}
+
+	failures := db.called("RecordEmailFailure")
+	if len(failures) != 2 {
+		t.Fatalf("RecordEmailFailure calls = %v, want one per email", failures)
+	}
+
+	// third failure: still pending, next attempt after emailRetryDelay(3)
+	args := failures[0].Args
+	if args[0] != retried.ID || args[1] != EmailStatusPending {
+		t.Errorf("first failure recorded as %v %v, want the retried email pending", args[0], args[1])
+	}
+	if lastError := args[2].(pgtype.Text); lastError.String != "connection refused" {
+		t.Errorf("last_error = %q", lastError.String)
+	}
+	if wait := args[3].(time.Time).Sub(before); wait < emailRetryDelay(3) || wait > emailRetryDelay(3)+time.Minute {
+		t.Errorf("next attempt in %v, want about %v", wait, emailRetryDelay(3))
+	}
+
+	// last allowed attempt: the email gives up
+	if args := failures[1].Args; args[0] != exhausted.ID || args[1] != EmailStatusFailed {
+		t.Errorf("second failure recorded as %v %v, want the exhausted email failed", args[0], args[1])
+	}
+
+	// the provider is back: the retried email goes out on its next claim
+	mail.FailWith(nil)
+	retried.Attempts = 3
+	db.returns("ClaimDueEmails", []repository.EmailOutbox{retried})
+	if _, err := outbox.DeliverDue(t.Context()); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if sent := mail.Messages(); len(sent) != 1 {
+		t.Fatalf("%d messages delivered after recovery, want 1", len(sent))
+	}
+	if marked := db.called("MarkEmailSent"); len(marked) != 1 || marked[0].Args[0] != retried.ID {
+		t.Errorf("MarkEmailSent calls = %v, want one for the retried email", marked)
+	}
+}
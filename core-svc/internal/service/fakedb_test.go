@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+)
+
+// sqlc prefixes every query with "-- name: GetUserByID :one"
+var fakeQueryNameRe = regexp.MustCompile(`^--\s*name:\s*(\w+)`)
+
+// fakeResult answers a query by its sqlc name. What it returns depends on the query kind:
+//   - :one   a row struct (fields in scan order) or a scalar for single column queries;
+//     nil means no rows
+//   - :many  a slice of those
+//   - :exec/:execrows the number of affected rows as an int64 (nil is 0)
+type fakeResult func(args []any) (any, error)
+
+type fakeCall struct {
+	Name string
+	Args []any
+}
+
+// fakeDB is an in-memory repository.DBTX for service tests. Queries without a result fail
+// the call, so a test states every query the code under test runs.
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	calls   []fakeCall
+
+	begins    int
+	commits   int
+	rollbacks int
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{results: make(map[string]fakeResult)}
+}
+
+func (db *fakeDB) queries() *repository.Queries {
+	return repository.New(db)
+}
+
+// on sets the result of a query
+func (db *fakeDB) on(name string, result fakeResult) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.results[name] = result
+}
+
+// returns makes a query always return value
+func (db *fakeDB) returns(name string, value any) {
+	db.on(name, func([]any) (any, error) { return value, nil })
+}
+
+// called returns the calls of a query, in order
+func (db *fakeDB) called(name string) []fakeCall {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var calls []fakeCall
+	for _, call := range db.calls {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (db *fakeDB) run(sql string, args []any) (any, error) {
+	name := sql
+	if m := fakeQueryNameRe.FindStringSubmatch(sql); m != nil {
+		name = m[1]
+	}
+
+	db.mu.Lock()
+	db.calls = append(db.calls, fakeCall{Name: name, Args: args})
+	result, ok := db.results[name]
+	db.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("fakeDB: unexpected query %s", name)
+	}
+	return result(args)
+}
+
+func (db *fakeDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	value, err := db.run(sql, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	affected, _ := value.(int64)
+	return pgconn.NewCommandTag(fmt.Sprintf("UPDATE %d", affected)), nil
+}
+
+func (db *fakeDB) Query(_ context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	value, err := db.run(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	rows := &fakeRows{index: -1}
+	if value != nil {
+		slice := reflect.ValueOf(value)
+		for i := 0; i < slice.Len(); i++ {
+			rows.values = append(rows.values, slice.Index(i).Interface())
+		}
+	}
+	return rows, nil
+}
+
+func (db *fakeDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	value, err := db.run(sql, args)
+	if err == nil && value == nil {
+		err = pgx.ErrNoRows
+	}
+	return fakeRow{value: value, err: err}
+}
+
+func (db *fakeDB) CopyFrom(_ context.Context, table pgx.Identifier, _ []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	var rows []any
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, values)
+	}
+	if _, err := db.run("COPY "+table.Sanitize(), rows); err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+// Begin lets repository.Queries.InTx run against the fake
+func (db *fakeDB) Begin(context.Context) (pgx.Tx, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.begins++
+	return &fakeTx{db: db}, nil
+}
+
+// fakeTx counts commits and rollbacks; statements run against the same fake
+type fakeTx struct {
+	pgx.Tx
+	db   *fakeDB
+	done bool
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return tx.db.Exec(ctx, sql, args...)
+}
+
+func (tx *fakeTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return tx.db.Query(ctx, sql, args...)
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return tx.db.QueryRow(ctx, sql, args...)
+}
+
+func (tx *fakeTx) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return tx.db.CopyFrom(ctx, table, columns, rowSrc)
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return tx.db.Begin(ctx)
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	if !tx.done {
+		tx.done = true
+		tx.db.commits++
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	if !tx.done {
+		tx.done = true
+		tx.db.rollbacks++
+	}
+	return nil
+}
+
+type fakeRow struct {
+	value any
+	err   error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return scanFake(r.value, dest)
+}
+
+// scanFake copies a scalar into a single destination, or the fields of a struct into
+// the destinations in order
+func scanFake(value any, dest []any) error {
+	if len(dest) == 1 {
+		return assignFake(value, dest[0])
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Struct || v.NumField() != len(dest) {
+		return fmt.Errorf("fakeDB: cannot scan %T into %d columns", value, len(dest))
+	}
+	for i := range dest {
+		if err := assignFake(v.Field(i).Interface(), dest[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignFake(value any, dest any) error {
+	target := reflect.ValueOf(dest).Elem()
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("fakeDB: cannot scan %T into %s", value, target.Type())
+	}
+	target.Set(v)
+	return nil
+}
+
+type fakeRows struct {
+	pgx.Rows
+	values []any
+	index  int
+}
+
+func (r *fakeRows) Next() bool {
+	r.index++
+	return r.index < len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	if r.index < 0 || r.index >= len(r.values) {
+		return errors.New("fakeDB: Scan called without a row")
+	}
+	return scanFake(r.values[r.index], dest)
+}
+
+func (r *fakeRows) Close() {}
+
+func (r *fakeRows) Err() error { return nil }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// how long an impersonation session lasts unless a shorter duration is requested
+const impersonationDefaultDuration = 15 * time.Minute
+
+// upper bound for an impersonation session; it can't be refreshed, so this is a hard limit
+const impersonationMaxDuration = time.Hour
+
+const impersonationMaxReasonLength = 500
+
+// ImpersonationService lets an admin act as another user for a limited time, e.g. to
+// reproduce a bug report. The admin gets an access token for the user whose "act" claim
+// names the admin; every request made with it is written to the audit log.
+type ImpersonationService struct {
+	Queries        *repository.Queries
+	AuditService   *AuditService
+	SessionService *SessionService
+	RoleService    *RoleService
+	TokenService   *TokenService
+}
+
+func NewImpersonationService(queries *repository.Queries, config *util.Config) *ImpersonationService {
+	return &ImpersonationService{
+		Queries:        queries,
+		AuditService:   NewAuditService(queries),
+		SessionService: NewSessionService(queries),
+		RoleService:    NewRoleService(queries),
+		TokenService:   NewTokenService(config),
+	}
+}
+
+// StartImpersonationInput contains impersonation parameters
+type StartImpersonationInput struct {
+	ActorUserID  uuid.UUID
+	TargetUserID uuid.UUID
+	Reason       string
+	// Duration is impersonationDefaultDuration when zero
+	Duration time.Duration
+	Meta     SessionMeta
+}
+
+// Impersonation is the session handed to the admin
+type Impersonation struct {
+	AccessToken string    `json:"access_token"`
+	SessionID   uuid.UUID `json:"session_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// StartImpersonation opens a time-boxed session as the target user. Users whose role may
+// impersonate others (admins) can't be impersonated themselves.
+func (s *ImpersonationService) StartImpersonation(ctx context.Context, input StartImpersonationInput) (Impersonation, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > impersonationMaxReasonLength {
+		return Impersonation{}, util.ErrInvalidInput
+	}
+
+	duration := input.Duration
+	if duration == 0 {
+		duration = impersonationDefaultDuration
+	}
+	if duration < 0 || duration > impersonationMaxDuration {
+		return Impersonation{}, util.ErrInvalidInput
+	}
+
+	if input.ActorUserID == input.TargetUserID {
+		return Impersonation{}, util.ErrCannotImpersonate
+	}
+
+	target, err := s.Queries.GetUserByID(ctx, input.TargetUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Impersonation{}, util.ErrUserNotFound
+		}
+		return Impersonation{}, err
+	}
+
+	permissions, err := s.RoleService.RolePermissions(ctx, target.Role)
+	if err != nil {
+		return Impersonation{}, err
+	}
+	if slices.Contains(permissions, PermissionUserImpersonate) {
+		return Impersonation{}, util.ErrCannotImpersonate
+	}
+
+	expiresAt := time.Now().Add(duration)
+
+	session, err := s.Queries.CreateImpersonationSession(ctx, repository.CreateImpersonationSessionParams{
+		UserID:         target.ID,
+		UserAgent:      input.Meta.UserAgent,
+		IpAddress:      input.Meta.IPAddress,
+		ImpersonatorID: pgtype.UUID{Bytes: input.ActorUserID, Valid: true},
+		ExpiresAt:      pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return Impersonation{}, err
+	}
+
+	token, err := s.TokenService.GenerateImpersonationToken(AuthTokenData{
+		ID:        target.ID,
+		SessionID: session.ID,
+	}, input.ActorUserID, expiresAt)
+	if err != nil {
+		return Impersonation{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, input.ActorUserID, AuditActionImpersonationStart, "user", target.ID, map[string]interface{}{
+		"reason":               reason,
+		"session_id":           session.ID.String(),
+		"expires_at":           expiresAt,
+		"impersonator_id":      input.ActorUserID.String(),
+		"impersonated_user_id": target.ID.String(),
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log impersonation start", "error", err)
+	}
+
+	return Impersonation{
+		AccessToken: token,
+		SessionID:   session.ID,
+		UserID:      target.ID,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// EndImpersonation revokes the impersonation session before it expires
+func (s *ImpersonationService) EndImpersonation(ctx context.Context, impersonatorID, userID, sessionID uuid.UUID) error {
+	if err := s.SessionService.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, util.ErrSessionNotFound) {
+			return util.ErrNotImpersonating
+		}
+		return err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, impersonatorID, AuditActionImpersonationEnd, "user", userID, map[string]interface{}{
+		"session_id": sessionID.String(),
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log impersonation end", "error", err)
+	}
+
+	return nil
+}
+
+// LogRequest records a request made while impersonating. The admin is the actor and the
+// impersonated user the subject.
+func (s *ImpersonationService) LogRequest(ctx context.Context, impersonatorID, userID uuid.UUID, method, path string, status int) error {
+	return s.AuditService.LogEntryWithMeta(ctx, impersonatorID, AuditActionImpersonationRequest, "user", userID, map[string]interface{}{
+		"method": method,
+		"path":   path,
+		"status": status,
+	})
+}
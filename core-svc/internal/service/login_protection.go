@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const (
+	// failed logins from one IP (any account) within loginIPWindow before the IP is throttled
+	maxFailedLoginsPerIP = 30
+	loginIPWindow        = 15 * time.Minute
+
+	// consecutive failures allowed before each further attempt has to wait
+	loginBackoffThreshold = 3
+	loginBackoffMax       = time.Minute
+
+	// consecutive failures that lock the account; the lock doubles with every lockout
+	loginLockoutThreshold = 10
+	loginLockoutBase      = 15 * time.Minute
+	loginLockoutMax       = 24 * time.Hour
+
+	// wrong 2FA codes accepted per MFA challenge token before it is refused
+	maxMFAChallengeFailures = 5
+)
+
+// LoginBlockedError is returned when a login is refused before the password is checked.
+// It wraps util.ErrAccountLocked or util.ErrTooManyLoginAttempts.
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string { return e.Err.Error() }
+
+func (e *LoginBlockedError) Unwrap() error { return e.Err }
+
+// LoginProtectionService throttles password logins per IP and per account and locks
+// accounts after repeated failures
+type LoginProtectionService struct {
+	Queries      *repository.Queries
+	AuditService *AuditService
+	EmailService *EmailService
+}
+
+func NewLoginProtectionService(queries *repository.Queries, emailService *EmailService) *LoginProtectionService {
+	return &LoginProtectionService{
+		Queries:      queries,
+		AuditService: NewAuditService(queries),
+		EmailService: emailService,
+	}
+}
+
+// CheckIP refuses logins from an IP with too many recent failures
+func (s *LoginProtectionService) CheckIP(ctx context.Context, ipAddress string) error {
+	failures, err := s.Queries.CountRecentFailedLoginAttemptsByIP(ctx, repository.CountRecentFailedLoginAttemptsByIPParams{
+		IpAddress: ipAddress,
+		CreatedAt: time.Now().Add(-loginIPWindow),
+	})
+	if err != nil {
+		return err
+	}
+	if failures >= maxFailedLoginsPerIP {
+		return &LoginBlockedError{Err: util.ErrTooManyLoginAttempts, RetryAfter: loginIPWindow}
+	}
+	return nil
+}
+
+// CheckAccount refuses logins to a locked account, or one still inside its backoff delay
+func (s *LoginProtectionService) CheckAccount(ctx context.Context, userID uuid.UUID) error {
+	state, err := s.Queries.GetAccountLockout(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	if state.LockedUntil.Valid && now.Before(state.LockedUntil.Time) {
+		return &LoginBlockedError{Err: util.ErrAccountLocked, RetryAfter: state.LockedUntil.Time.Sub(now)}
+	}
+
+	if state.LastFailedAt.Valid {
+		retryAt := state.LastFailedAt.Time.Add(loginBackoff(state.FailedAttempts))
+		if now.Before(retryAt) {
+			return &LoginBlockedError{Err: util.ErrTooManyLoginAttempts, RetryAfter: retryAt.Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+// loginBackoff is the wait after the given number of consecutive failures: nothing for the
+// first few, then 1s, 2s, 4s... up to loginBackoffMax
+func loginBackoff(failedAttempts int32) time.Duration {
+	if failedAttempts < loginBackoffThreshold {
+		return 0
+	}
+	delay := time.Second << (failedAttempts - loginBackoffThreshold)
+	if delay > loginBackoffMax || delay <= 0 {
+		return loginBackoffMax
+	}
+	return delay
+}
+
+// lockoutDuration doubles the lock for every earlier lockout since the last successful login
+func lockoutDuration(previousLockouts int32) time.Duration {
+	duration := loginLockoutBase << previousLockouts
+	if duration > loginLockoutMax || duration <= 0 {
+		return loginLockoutMax
+	}
+	return duration
+}
+
+// RecordFailure stores a failed login. userID is uuid.Nil when the email is unknown.
+func (s *LoginProtectionService) RecordFailure(ctx context.Context, email string, userID uuid.UUID, ipAddress string) error {
+	if err := s.Queries.CreateLoginAttempt(ctx, repository.CreateLoginAttemptParams{
+		Email:     email,
+		UserID:    pgtype.UUID{Bytes: userID, Valid: userID != uuid.Nil},
+		IpAddress: ipAddress,
+		Succeeded: false,
+	}); err != nil {
+		return err
+	}
+
+	if userID == uuid.Nil {
+		return nil
+	}
+
+	state, err := s.Queries.RecordFailedLogin(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if state.FailedAttempts >= loginLockoutThreshold {
+		return s.lock(ctx, state, ipAddress)
+	}
+	return nil
+}
+
+// CheckMFAChallenge refuses a 2FA challenge token that already had too many wrong codes
+func (s *LoginProtectionService) CheckMFAChallenge(ctx context.Context, challenge MFAChallenge) error {
+	failures, err := s.Queries.GetMFAChallengeFailedAttempts(ctx, challenge.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if failures >= maxMFAChallengeFailures {
+		return util.ErrTooManyTwoFactorCodes
+	}
+	return nil
+}
+
+// RecordMFAFailure counts a wrong 2FA code against the challenge token and, like a wrong
+// password, against the account, so a known password doesn't allow unlimited code guesses
+func (s *LoginProtectionService) RecordMFAFailure(ctx context.Context, challenge MFAChallenge, email string, ipAddress string) error {
+	if _, err := s.Queries.RecordMFAChallengeFailure(ctx, repository.RecordMFAChallengeFailureParams{
+		ID:        challenge.ID,
+		UserID:    challenge.UserID,
+		ExpiresAt: challenge.ExpiresAt,
+	}); err != nil {
+		return err
+	}
+
+	return s.RecordFailure(ctx, email, challenge.UserID, ipAddress)
+}
+
+// lock locks the account and emails the owner a link to unlock it
+func (s *LoginProtectionService) lock(ctx context.Context, state repository.AccountLockout, ipAddress string) error {
+	unlockToken, unlockTokenHash, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	duration := lockoutDuration(state.LockoutCount)
+	lockedUntil := time.Now().Add(duration)
+
+	user, err := s.Queries.GetUserByID(ctx, state.UserID)
+	if err != nil {
+		return err
+	}
+
+	// the unlock link is queued with the lock, so a locked account always gets its email
+	err = s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		if _, err := q.LockAccount(ctx, repository.LockAccountParams{
+			UserID:          state.UserID,
+			LockedUntil:     pgtype.Timestamptz{Time: lockedUntil, Valid: true},
+			UnlockTokenHash: pgtype.Text{String: unlockTokenHash, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		if s.EmailService != nil {
+			return s.EmailService.WithQueries(q).SendAccountLockedEmail(ctx, user.ID, user.Email, user.Name, unlockToken, duration)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	util.Logger(ctx).Warn("account locked after failed logins",
+		"user_id", state.UserID.String(),
+		"ip_address", ipAddress,
+		"locked_until", lockedUntil,
+	)
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, state.UserID, AuditActionAuthAccountLocked, "user", state.UserID, map[string]interface{}{
+		"failed_attempts": state.FailedAttempts,
+		"lockout_count":   state.LockoutCount + 1,
+		"locked_until":    lockedUntil,
+		"ip_address":      ipAddress,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log account lockout", "error", err)
+	}
+
+	return nil
+}
+
+// RecordSuccess stores a successful login and resets the account's failure state
+func (s *LoginProtectionService) RecordSuccess(ctx context.Context, email string, userID uuid.UUID, ipAddress string) error {
+	if err := s.Queries.CreateLoginAttempt(ctx, repository.CreateLoginAttemptParams{
+		Email:     email,
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		IpAddress: ipAddress,
+		Succeeded: true,
+	}); err != nil {
+		return err
+	}
+
+	_, err := s.Queries.ClearAccountLockout(ctx, userID)
+	return err
+}
+
+// Unlock clears a lockout with the token from the lockout email. The token only works until
+// the lock ends; a lapsed token is dropped by the next failed login, a successful one
+// removes the whole lockout row.
+func (s *LoginProtectionService) Unlock(ctx context.Context, token string) error {
+	state, err := s.Queries.GetAccountLockoutByUnlockTokenHash(ctx, pgtype.Text{String: HashOpaqueToken(token), Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrInvalidUnlockToken
+		}
+		return err
+	}
+
+	if _, err := s.Queries.ClearAccountLockout(ctx, state.UserID); err != nil {
+		return err
+	}
+
+	if err := s.AuditService.LogEntry(ctx, state.UserID, AuditActionAuthAccountUnlock, "user", state.UserID); err != nil {
+		util.Logger(ctx).Error("failed to log account unlock", "error", err)
+	}
+
+	util.Logger(ctx).Info("account unlocked by email", "user_id", state.UserID.String())
+	return nil
+}
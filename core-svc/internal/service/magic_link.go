@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// MagicLinkRequestInput asks for a sign-in link by email
+type MagicLinkRequestInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// RequestMagicLink emails a sign-in link if the account exists and returns the browser
+// binding token to store in a cookie. The response is the same whether or not the account
+// exists or is over its hourly link limit, so it doesn't reveal registered emails. An
+// existing browser token is reused so links requested earlier from the same browser stay valid.
+func (a *AuthService) RequestMagicLink(ctx context.Context, input MagicLinkRequestInput, browserToken string) (string, error) {
+	if browserToken == "" {
+		token, _, err := GenerateOpaqueToken()
+		if err != nil {
+			return "", err
+		}
+		browserToken = token
+	}
+
+	user, err := a.Queries.GetUserByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := a.EmailService.SimulateMagicLinkEmail(ctx, input.Email); err != nil {
+				util.Logger(ctx).Error("failed to simulate magic link email", "error", err)
+			}
+			return browserToken, nil
+		}
+		return "", err
+	}
+
+	if err := a.EmailService.SendMagicLinkEmail(ctx, user.ID, user.Email, user.Name, HashOpaqueToken(browserToken)); err != nil {
+		return "", err
+	}
+
+	return browserToken, nil
+}
+
+// MagicLinkLoginInput completes a magic link sign-in
+type MagicLinkLoginInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CompleteMagicLinkLogin consumes the link and logs the user in. The link must be used from the
+// browser holding browserToken; using it also proves ownership of the email address.
+func (a *AuthService) CompleteMagicLinkLogin(c *gin.Context, input MagicLinkLoginInput, browserToken string) (LoginResult, error) {
+	ctx := c.Request.Context()
+
+	dbToken, err := a.Queries.GetMagicLinkTokenByHash(ctx, HashOpaqueToken(input.Token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return LoginResult{}, util.ErrInvalidMagicLink
+		}
+		return LoginResult{}, err
+	}
+
+	if dbToken.UsedAt.Valid || time.Now().After(dbToken.ExpiresAt) {
+		return LoginResult{}, util.ErrInvalidMagicLink
+	}
+
+	// checked before consuming so opening the link in the wrong browser doesn't burn it
+	if browserToken == "" || subtle.ConstantTimeCompare([]byte(HashOpaqueToken(browserToken)), []byte(dbToken.BrowserHash)) != 1 {
+		return LoginResult{}, util.ErrMagicLinkBrowserMismatch
+	}
+
+	// conditional update so two concurrent requests can't both use the link
+	consumed, err := a.Queries.MarkMagicLinkTokenUsed(ctx, dbToken.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if consumed == 0 {
+		return LoginResult{}, util.ErrInvalidMagicLink
+	}
+
+	user, err := a.Queries.GetUserByID(ctx, dbToken.UserID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !user.EmailVerifiedAt.Valid {
+		if _, err := a.Queries.SetUserEmailVerified(ctx, dbToken.UserID); err != nil {
+			return LoginResult{}, err
+		}
+	}
+
+	if err := a.AuditService.LogEntry(ctx, dbToken.UserID, AuditActionAuthMagicLinkLogin, "user", dbToken.UserID); err != nil {
+		util.Logger(ctx).Error("failed to log magic link login", "error", err)
+	}
+
+	return a.completeLogin(c, dbToken.UserID)
+}
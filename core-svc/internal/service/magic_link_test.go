@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+)
+
+// newMagicLinkFixture answers the queries of a magic link request that ends up not sending
+func newMagicLinkFixture(t *testing.T) (*fakeDB, *AuthService) {
+	t.Helper()
+	db := newFakeDB()
+	db.returns("GetUserLocale", "en")
+	db.on("EnqueueEmail", func([]any) (any, error) { return uuid.New(), nil })
+	db.on("CreateMagicLinkToken", func([]any) (any, error) {
+		return nil, &pgconn.PgError{Code: "23503", ConstraintName: "magic_link_token_user_id_fkey"}
+	})
+
+	config := newTestConfig(t)
+	queries := db.queries()
+	return db, NewAuthService(config, queries, NewTokenService(config), NewEmailService(queries, config))
+}
+
+// A capped account and an unknown email get the same answer and make the same writes, all
+// rolled back, so the response doesn't reveal which emails are registered
+func TestRequestMagicLinkDoesNotRevealAccounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(db *fakeDB)
+	}{
+		{"unknown email", func(db *fakeDB) {
+			db.returns("GetUserByEmail", nil)
+			db.returns("CountRecentMagicLinkTokens", int64(0))
+		}},
+		{"account over its hourly limit", func(db *fakeDB) {
+			db.returns("GetUserByEmail", repository.GetUserByEmailRow{ID: uuid.New(), Email: "user@pollex.test"})
+			db.returns("CountRecentMagicLinkTokens", int64(maxMagicLinksPerHour))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, s := newMagicLinkFixture(t)
+			tt.setup(db)
+
+			browserToken, err := s.RequestMagicLink(t.Context(), MagicLinkRequestInput{Email: "user@pollex.test"}, "")
+			if err != nil {
+				t.Fatalf("RequestMagicLink: %v", err)
+			}
+			if browserToken == "" {
+				t.Error("no browser token returned")
+			}
+			if queued := db.called("EnqueueEmail"); len(queued) != 1 {
+				t.Errorf("EnqueueEmail calls = %d, want 1", len(queued))
+			}
+			if created := db.called("CreateMagicLinkToken"); len(created) != 1 {
+				t.Errorf("CreateMagicLinkToken calls = %d, want 1", len(created))
+			}
+			if db.begins != 1 || db.commits != 0 || db.rollbacks != 1 {
+				t.Errorf("begins/commits/rollbacks = %d/%d/%d, want 1/0/1", db.begins, db.commits, db.rollbacks)
+			}
+		})
+	}
+}
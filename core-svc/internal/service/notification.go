@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// Notification email categories users can turn off. Transactional emails (verification,
+// password reset, security notices) have no category and are always sent.
+const (
+	// results of polls the user owns or asked to be told about
+	NotificationPollResults = "poll_results"
+	// reminders before a poll the user was invited to closes
+	NotificationPollReminders = "poll_reminders"
+)
+
+// NotificationCategories lists every notification category
+var NotificationCategories = []string{
+	NotificationPollResults,
+	NotificationPollReminders,
+}
+
+// NotificationPreferences maps each category to whether its emails are sent
+type NotificationPreferences map[string]bool
+
+// NotificationService manages which notification emails a user receives
+type NotificationService struct {
+	Queries      *repository.Queries
+	TokenService *TokenService
+}
+
+func NewNotificationService(queries *repository.Queries, config *util.Config) *NotificationService {
+	return &NotificationService{
+		Queries:      queries,
+		TokenService: NewTokenService(config),
+	}
+}
+
+// notificationEnabled reports whether the user receives emails of the category; categories
+// the user never changed are on
+func notificationEnabled(ctx context.Context, q *repository.Queries, userID uuid.UUID, category string) (bool, error) {
+	enabled, err := q.GetNotificationPreference(ctx, repository.GetNotificationPreferenceParams{
+		UserID:   userID,
+		Category: category,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+	return enabled, nil
+}
+
+// GetPreferences returns the user's setting for every category
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uuid.UUID) (NotificationPreferences, error) {
+	stored, err := s.Queries.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(NotificationPreferences, len(NotificationCategories))
+	for _, category := range NotificationCategories {
+		preferences[category] = true
+	}
+	for _, preference := range stored {
+		// rows of categories that were since removed are ignored
+		if _, ok := preferences[preference.Category]; ok {
+			preferences[preference.Category] = preference.Enabled
+		}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences changes the given categories and leaves the others as they are
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, changes NotificationPreferences) (NotificationPreferences, error) {
+	for category := range changes {
+		if !slices.Contains(NotificationCategories, category) {
+			return nil, util.ErrUnknownNotificationCategory
+		}
+	}
+
+	err := s.Queries.InTx(ctx, func(q *repository.Queries) error {
+		for category, enabled := range changes {
+			if err := q.UpsertNotificationPreference(ctx, repository.UpsertNotificationPreferenceParams{
+				UserID:   userID,
+				Category: category,
+				Enabled:  enabled,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
+
+// Unsubscribe turns off the category named in an unsubscribe link and returns it
+func (s *NotificationService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	userID, category, err := s.TokenService.ParseUnsubscribeToken(token)
+	if err != nil || !slices.Contains(NotificationCategories, category) {
+		return "", util.ErrInvalidUnsubscribeToken
+	}
+
+	if err := s.Queries.UpsertNotificationPreference(ctx, repository.UpsertNotificationPreferenceParams{
+		UserID:   userID,
+		Category: category,
+		Enabled:  false,
+	}); err != nil {
+		// the user was deleted after the email was sent
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return "", util.ErrInvalidUnsubscribeToken
+		}
+		return "", err
+	}
+
+	util.Logger(ctx).Info("unsubscribed from notifications", "user_id", userID.String(), "category", category)
+	return category, nil
+}
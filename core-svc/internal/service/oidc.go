@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// audience of the signed cookie that carries state, nonce and PKCE verifier through the redirect
+	oidcStateAudience = "pollex:oidc"
+	// how long a user has to finish logging in at the provider
+	OIDCStateLifespan = 10 * time.Minute
+	// timeout for discovery and token exchange requests to the provider
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+// OIDCService implements OpenID Connect login (authorization code flow with PKCE)
+// for the providers in Config.OIDCProviders
+type OIDCService struct {
+	Config      *util.Config
+	Queries     *repository.Queries
+	AuthService *AuthService
+
+	httpClient *http.Client
+	mu         sync.Mutex
+	providers  map[string]*oidcProvider
+}
+
+// oidcProvider is a configured provider after discovery
+type oidcProvider struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// oidcStateClaims is the content of the state cookie
+type oidcStateClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// oidcIDClaims are the ID token claims Pollex uses
+type oidcIDClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+func NewOIDCService(config *util.Config, queries *repository.Queries, authService *AuthService) *OIDCService {
+	return &OIDCService{
+		Config:      config,
+		Queries:     queries,
+		AuthService: authService,
+		httpClient:  &http.Client{Timeout: oidcHTTPTimeout},
+		providers:   make(map[string]*oidcProvider),
+	}
+}
+
+// Providers lists the configured providers
+func (s *OIDCService) Providers() []util.OIDCProviderConfig {
+	return s.Config.OIDCProviders
+}
+
+// provider returns the named provider, running discovery on first use
+// so an unreachable provider doesn't prevent startup
+func (s *OIDCService) provider(name string) (*oidcProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.providers[name]; ok {
+		return p, nil
+	}
+
+	var cfg *util.OIDCProviderConfig
+	for i := range s.Config.OIDCProviders {
+		if s.Config.OIDCProviders[i].Name == name {
+			cfg = &s.Config.OIDCProviders[i]
+			break
+		}
+	}
+	if cfg == nil {
+		return nil, util.ErrUnknownProvider
+	}
+
+	// the provider keeps this context for fetching signing keys later, so it must not be request scoped
+	discoveryCtx := oidc.ClientContext(context.Background(), s.httpClient)
+	discovered, err := oidc.NewProvider(discoveryCtx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s failed: %w", name, err)
+	}
+
+	p := &oidcProvider{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  s.CallbackURL(name),
+			Scopes:       cfg.Scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+	s.providers[name] = p
+	return p, nil
+}
+
+// CallbackURL is the redirect URI to register with the provider
+func (s *OIDCService) CallbackURL(name string) string {
+	return s.Config.APIBaseURL + "/auth/oidc/" + name + "/callback"
+}
+
+// BeginLogin returns the provider authorization URL and the signed state cookie value
+func (s *OIDCService) BeginLogin(ctx context.Context, name string) (string, string, error) {
+	p, err := s.provider(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	cookie, err := s.AuthService.TokenService.Keys.Sign(oidcStateClaims{
+		Provider: name,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{oidcStateAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(OIDCStateLifespan)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL := p.oauth2.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	)
+	return authURL, cookie, nil
+}
+
+// parseStateCookie validates the signed state cookie
+func (s *OIDCService) parseStateCookie(cookie string) (*oidcStateClaims, error) {
+	claims := &oidcStateClaims{}
+	keys := s.AuthService.TokenService.Keys
+	token, err := jwt.ParseWithClaims(cookie, claims, keys.Keyfunc,
+		jwt.WithValidMethods(keys.Algorithms()),
+		jwt.WithAudience(oidcStateAudience),
+	)
+	if err != nil || !token.Valid {
+		return nil, util.ErrOIDCStateMismatch
+	}
+	return claims, nil
+}
+
+// OIDCCallbackInput is what the provider redirects back with, plus the state cookie
+type OIDCCallbackInput struct {
+	Provider    string
+	Code        string
+	State       string
+	StateCookie string
+}
+
+// CompleteLogin exchanges the authorization code, validates the ID token and logs the
+// linked user in. Unknown identities are linked to the account with the same email only
+// when the provider has verified that email; otherwise a new account is created.
+func (s *OIDCService) CompleteLogin(c *gin.Context, input OIDCCallbackInput) (LoginResult, error) {
+	ctx := c.Request.Context()
+
+	state, err := s.parseStateCookie(input.StateCookie)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if state.Provider != input.Provider || state.State == "" || state.State != input.State {
+		return LoginResult{}, util.ErrOIDCStateMismatch
+	}
+
+	p, err := s.provider(input.Provider)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	exchangeCtx := oidc.ClientContext(ctx, s.httpClient)
+	token, err := p.oauth2.Exchange(exchangeCtx, input.Code, oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("oidc code exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return LoginResult{}, util.ErrOIDCInvalidToken
+	}
+
+	idToken, err := p.verifier.Verify(exchangeCtx, rawIDToken)
+	if err != nil {
+		util.Logger(ctx).Warn("oidc id token rejected", "provider", input.Provider, "error", err)
+		return LoginResult{}, util.ErrOIDCInvalidToken
+	}
+	if idToken.Nonce != state.Nonce {
+		return LoginResult{}, util.ErrOIDCInvalidToken
+	}
+
+	var claims oidcIDClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return LoginResult{}, util.ErrOIDCInvalidToken
+	}
+
+	userID, err := s.resolveUser(ctx, input.Provider, idToken.Subject, claims)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	return s.AuthService.completeLogin(c, userID)
+}
+
+// resolveUser finds or creates the user for an external identity
+func (s *OIDCService) resolveUser(ctx context.Context, provider, subject string, claims oidcIDClaims) (uuid.UUID, error) {
+	identity, err := s.Queries.GetUserIdentity(ctx, repository.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if err == nil {
+		if err := s.Queries.TouchUserIdentity(ctx, identity.ID); err != nil {
+			util.Logger(ctx).Warn("failed to update identity login time", "error", err)
+		}
+		return identity.UserID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return uuid.Nil, util.ErrOIDCEmailMissing
+	}
+
+	var userID uuid.UUID
+	existing, err := s.Queries.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// never take over an existing account on an unverified claim
+		if !claims.EmailVerified {
+			return uuid.Nil, util.ErrOIDCEmailNotVerified
+		}
+		userID = existing.ID
+		if !existing.EmailVerifiedAt.Valid {
+			if _, err := s.Queries.SetUserEmailVerified(ctx, userID); err != nil {
+				return uuid.Nil, err
+			}
+		}
+
+	case errors.Is(err, pgx.ErrNoRows):
+		userID, err = s.createUser(ctx, email, claims)
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+	default:
+		return uuid.Nil, err
+	}
+
+	if _, err := s.Queries.CreateUserIdentity(ctx, repository.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	}); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.AuthService.AuditService.LogEntryWithMeta(ctx, userID, AuditActionUserIdentityLink, "user", userID, map[string]interface{}{
+		"provider": provider,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log identity link", "error", err)
+	}
+
+	return userID, nil
+}
+
+// createUser registers a user from OIDC claims. The account gets a random password;
+// the user can set one later through password reset.
+func (s *OIDCService) createUser(ctx context.Context, email string, claims oidcIDClaims) (uuid.UUID, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+
+	randomPassword, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	passwordHash, err := util.HashPassword(randomPassword)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	user, err := s.Queries.CreateUser(ctx, repository.CreateUserParams{
+		Name:         name,
+		Email:        email,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claims.EmailVerified {
+		if _, err := s.Queries.SetUserEmailVerified(ctx, user.ID); err != nil {
+			return uuid.Nil, err
+		}
+	} else if s.AuthService.EmailService != nil {
+		if err := s.AuthService.EmailService.SendVerificationEmail(ctx, user.ID, user.Email, user.Name); err != nil {
+			util.Logger(ctx).Warn("failed to send verification email", "error", err)
+		}
+	}
+
+	return user.ID, nil
+}
+
+// ListIdentities returns the external identities linked to the user
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]repository.UserIdentity, error) {
+	identities, err := s.Queries.ListUserIdentitiesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if identities == nil {
+		identities = []repository.UserIdentity{}
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes one of the user's external identities
+func (s *OIDCService) UnlinkIdentity(ctx context.Context, userID, identityID uuid.UUID) error {
+	deleted, err := s.Queries.DeleteUserIdentity(ctx, repository.DeleteUserIdentityParams{
+		ID:     identityID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return util.ErrIdentityNotFound
+	}
+	return nil
+}
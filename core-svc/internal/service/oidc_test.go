@@ -0,0 +1,380 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const (
+	mockClientID     = "pollex"
+	mockClientSecret = "pollex-secret"
+)
+
+// mockOIDCProvider is an OpenID provider with discovery, JWKS and token endpoints. Tests
+// "authorize" an authorization URL directly and get back the code the provider would have
+// redirected with.
+type mockOIDCProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// mockAuthorization is what a code was issued for
+type mockAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	p := &mockOIDCProvider{t: t, key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *mockOIDCProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *mockOIDCProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code, checking the client credentials and the PKCE verifier
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != mockClientID || clientSecret != mockClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.claims)
+	idToken.Header["kid"] = "mock"
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		p.t.Errorf("sign id token: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+// authorize plays the user signing in at the provider: it issues a code for the
+// authorization URL and returns the state and code the provider redirects back with.
+// The ID token carries the nonce from the URL unless claims override it.
+func (p *mockOIDCProvider) authorize(authURL string, claims jwt.MapClaims) (string, string) {
+	p.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatalf("parse authorization url: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		p.t.Fatalf("authorization url has no S256 code challenge: %s", authURL)
+	}
+
+	idClaims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   mockClientID,
+		"sub":   "subject-1",
+		"nonce": query.Get("nonce"),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		idClaims[k] = v
+	}
+
+	code := uuid.NewString()
+	p.mu.Lock()
+	p.codes[code] = mockAuthorization{challenge: query.Get("code_challenge"), claims: idClaims}
+	p.mu.Unlock()
+
+	return query.Get("state"), code
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newTestOIDCService wires an OIDCService to the mock provider and a fake database
+func newTestOIDCService(t *testing.T, provider *mockOIDCProvider, db *fakeDB) *OIDCService {
+	t.Helper()
+	config := newTestConfig(t)
+	config.OIDCProviders = []util.OIDCProviderConfig{{
+		Name:         "mock",
+		Issuer:       provider.server.URL,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		Scopes:       []string{"openid", "email", "profile"},
+	}}
+
+	queries := db.queries()
+	authService := NewAuthService(config, queries, NewTokenService(config), nil)
+	return NewOIDCService(config, queries, authService)
+}
+
+func newCallbackContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback", nil)
+	return c
+}
+
+func beginLogin(t *testing.T, s *OIDCService) (string, string) {
+	t.Helper()
+	authURL, cookie, err := s.BeginLogin(t.Context(), "mock")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	return authURL, cookie
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	db := newFakeDB()
+	s := newTestOIDCService(t, provider, db)
+
+	authURL, cookie := beginLogin(t, s)
+	state, code := provider.authorize(authURL, nil)
+
+	tests := []struct {
+		name  string
+		input OIDCCallbackInput
+	}{
+		{"different state", OIDCCallbackInput{Provider: "mock", Code: code, State: "forged", StateCookie: cookie}},
+		{"missing cookie", OIDCCallbackInput{Provider: "mock", Code: code, State: state}},
+		{"tampered cookie", OIDCCallbackInput{Provider: "mock", Code: code, State: state, StateCookie: cookie + "x"}},
+		{"other provider", OIDCCallbackInput{Provider: "other", Code: code, State: state, StateCookie: cookie}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CompleteLogin(newCallbackContext(), tt.input)
+			if !errors.Is(err, util.ErrOIDCStateMismatch) {
+				t.Fatalf("err = %v, want ErrOIDCStateMismatch", err)
+			}
+		})
+	}
+
+	if len(db.calls) != 0 {
+		t.Errorf("rejected callbacks queried the database: %v", db.calls)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	db := newFakeDB()
+	s := newTestOIDCService(t, provider, db)
+
+	authURL, cookie := beginLogin(t, s)
+	state, code := provider.authorize(authURL, jwt.MapClaims{"nonce": "replayed-nonce"})
+
+	_, err := s.CompleteLogin(newCallbackContext(), OIDCCallbackInput{
+		Provider: "mock", Code: code, State: state, StateCookie: cookie,
+	})
+	if !errors.Is(err, util.ErrOIDCInvalidToken) {
+		t.Fatalf("err = %v, want ErrOIDCInvalidToken", err)
+	}
+	if len(db.calls) != 0 {
+		t.Errorf("rejected callback queried the database: %v", db.calls)
+	}
+}
+
+func TestOIDCCallbackSendsPKCEVerifierOfItsOwnLogin(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	db := newFakeDB()
+	s := newTestOIDCService(t, provider, db)
+
+	// a code obtained for another login (e.g. injected by an attacker) can't be redeemed with
+	// this browser's state cookie, because the provider checks the PKCE verifier
+	otherURL, _ := beginLogin(t, s)
+	_, injectedCode := provider.authorize(otherURL, jwt.MapClaims{"email": "attacker@example.com", "email_verified": true})
+
+	authURL, cookie := beginLogin(t, s)
+	state, _ := provider.authorize(authURL, nil)
+
+	_, err := s.CompleteLogin(newCallbackContext(), OIDCCallbackInput{
+		Provider: "mock", Code: injectedCode, State: state, StateCookie: cookie,
+	})
+	if err == nil {
+		t.Fatal("code issued for another PKCE challenge was accepted")
+	}
+	if len(db.calls) != 0 {
+		t.Errorf("rejected callback queried the database: %v", db.calls)
+	}
+}
+
+func TestOIDCCallbackRefusesToLinkUnverifiedEmail(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	db := newFakeDB()
+	s := newTestOIDCService(t, provider, db)
+
+	db.returns("GetUserIdentity", nil)
+	db.returns("GetUserByEmail", repository.GetUserByEmailRow{
+		ID:    uuid.New(),
+		Name:  "Existing",
+		Email: "existing@example.com",
+		Role:  "user",
+	})
+
+	authURL, cookie := beginLogin(t, s)
+	state, code := provider.authorize(authURL, jwt.MapClaims{
+		"email":          "Existing@Example.com",
+		"email_verified": false,
+	})
+
+	_, err := s.CompleteLogin(newCallbackContext(), OIDCCallbackInput{
+		Provider: "mock", Code: code, State: state, StateCookie: cookie,
+	})
+	if !errors.Is(err, util.ErrOIDCEmailNotVerified) {
+		t.Fatalf("err = %v, want ErrOIDCEmailNotVerified", err)
+	}
+
+	if lookups := db.called("GetUserByEmail"); len(lookups) != 1 || lookups[0].Args[0] != "existing@example.com" {
+		t.Errorf("GetUserByEmail calls = %v, want one with the normalized email", lookups)
+	}
+	if links := db.called("CreateUserIdentity"); len(links) != 0 {
+		t.Errorf("identity was linked to the existing account: %v", links)
+	}
+}
+
+func TestOIDCFirstLoginCreatesAccount(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	db := newFakeDB()
+	s := newTestOIDCService(t, provider, db)
+
+	userID := uuid.New()
+	now := time.Now()
+	verifiedAt := pgtype.Timestamptz{Time: now, Valid: true}
+
+	db.returns("GetUserIdentity", nil)
+	db.returns("GetUserByEmail", nil)
+	db.returns("CreateUser", repository.CreateUserRow{ID: userID, Name: "Ada Lovelace", Email: "ada@example.com", Role: "user"})
+	db.returns("SetUserEmailVerified", repository.SetUserEmailVerifiedRow{ID: userID, Name: "Ada Lovelace", Email: "ada@example.com", Role: "user", EmailVerifiedAt: verifiedAt})
+	db.on("CreateUserIdentity", func(args []any) (any, error) {
+		return repository.UserIdentity{ID: uuid.New(), UserID: args[0].(uuid.UUID), Provider: args[1].(string), Subject: args[2].(string), Email: args[3].(string), CreatedAt: now}, nil
+	})
+	db.returns("CreateAuditLog", repository.AuditLog{ID: uuid.New()})
+	db.returns("GetActiveUserSuspension", nil)
+	db.returns("GetUserTOTP", nil)
+	db.returns("CreateUserSession", repository.UserSession{ID: uuid.New(), UserID: userID, CreatedAt: now, LastSeenAt: now})
+	db.on("CreateRefreshToken", func(args []any) (any, error) {
+		return repository.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: args[1].(uuid.UUID), TokenHash: args[2].(string), ExpiresAt: args[3].(time.Time), CreatedAt: now}, nil
+	})
+	db.returns("GetUserByID", repository.GetUserByIDRow{ID: userID, Name: "Ada Lovelace", Email: "ada@example.com", Role: "user", EmailVerifiedAt: verifiedAt})
+
+	authURL, cookie := beginLogin(t, s)
+	state, code := provider.authorize(authURL, jwt.MapClaims{
+		"sub":            "ada-subject",
+		"email":          " Ada@Example.com ",
+		"email_verified": true,
+		"name":           "Ada Lovelace",
+	})
+
+	result, err := s.CompleteLogin(newCallbackContext(), OIDCCallbackInput{
+		Provider: "mock", Code: code, State: state, StateCookie: cookie,
+	})
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if result.Tokens.AccessToken == "" || result.Tokens.RefreshToken == "" || result.MFAToken != "" {
+		t.Errorf("result = %+v, want a token pair", result)
+	}
+
+	created := db.called("CreateUser")
+	if len(created) != 1 || created[0].Args[0] != "Ada Lovelace" || created[0].Args[1] != "ada@example.com" {
+		t.Fatalf("CreateUser calls = %v, want one for Ada with the normalized email", created)
+	}
+	if verified := db.called("SetUserEmailVerified"); len(verified) != 1 || verified[0].Args[0] != userID {
+		t.Errorf("SetUserEmailVerified calls = %v, want the new user verified", verified)
+	}
+
+	links := db.called("CreateUserIdentity")
+	if len(links) != 1 {
+		t.Fatalf("CreateUserIdentity calls = %v, want one", links)
+	}
+	if got := links[0].Args; got[0] != userID || got[1] != "mock" || got[2] != "ada-subject" || got[3] != "ada@example.com" {
+		t.Errorf("identity = %v, want mock/ada-subject linked to the new user", got)
+	}
+}
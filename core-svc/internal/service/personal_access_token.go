@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// PersonalAccessTokenPrefix marks a bearer token as a personal access token rather than a JWT
+const PersonalAccessTokenPrefix = "pollex_pat_"
+
+const (
+	// characters of the token kept in clear for display
+	patDisplayPrefixLength = len(PersonalAccessTokenPrefix) + 8
+	patMaxNameLength       = 100
+	patDefaultLifetimeDays = 30
+	patMaxLifetimeDays     = 365
+	// how often last_used_at is written for an active token
+	patTouchInterval = time.Minute
+)
+
+// Scopes that can be granted to a personal access token
+const (
+	ScopePollsRead   = "polls:read"
+	ScopePollsWrite  = "polls:write"
+	ScopeVotesRead   = "votes:read"
+	ScopeVotesWrite  = "votes:write"
+	ScopeProfileRead = "profile:read"
+	ScopeAdmin       = "admin"
+)
+
+// PersonalAccessTokenScopes lists every valid scope
+var PersonalAccessTokenScopes = []string{
+	ScopePollsRead,
+	ScopePollsWrite,
+	ScopeVotesRead,
+	ScopeVotesWrite,
+	ScopeProfileRead,
+	ScopeAdmin,
+}
+
+// PersonalAccessTokenService manages personal access tokens for API clients
+type PersonalAccessTokenService struct {
+	Queries *repository.Queries
+}
+
+func NewPersonalAccessTokenService(queries *repository.Queries) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		Queries: queries,
+	}
+}
+
+// IsPersonalAccessToken reports whether a bearer token looks like a personal access token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// CreatePersonalAccessTokenInput contains parameters for creating a token
+type CreatePersonalAccessTokenInput struct {
+	UserID        uuid.UUID
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreateToken stores a new token and returns it together with the plaintext value,
+// which is never stored and can't be shown again
+func (s *PersonalAccessTokenService) CreateToken(ctx context.Context, input CreatePersonalAccessTokenInput) (repository.PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > patMaxNameLength {
+		return repository.PersonalAccessToken{}, "", util.ErrInvalidInput
+	}
+
+	if input.ExpiresInDays == 0 {
+		input.ExpiresInDays = patDefaultLifetimeDays
+	}
+	if input.ExpiresInDays < 0 || input.ExpiresInDays > patMaxLifetimeDays {
+		return repository.PersonalAccessToken{}, "", util.ErrInvalidInput
+	}
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return repository.PersonalAccessToken{}, "", err
+	}
+
+	// only users whose role grants permissions can hand out admin access
+	if slices.Contains(scopes, ScopeAdmin) {
+		permissions, err := NewRoleService(s.Queries).UserPermissions(ctx, input.UserID)
+		if err != nil {
+			return repository.PersonalAccessToken{}, "", err
+		}
+		if len(permissions) == 0 {
+			return repository.PersonalAccessToken{}, "", util.ErrInsufficientPerms
+		}
+	}
+
+	secret, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return repository.PersonalAccessToken{}, "", err
+	}
+	token := PersonalAccessTokenPrefix + secret
+
+	stored, err := s.Queries.CreatePersonalAccessToken(ctx, repository.CreatePersonalAccessTokenParams{
+		UserID:      input.UserID,
+		Name:        name,
+		TokenPrefix: token[:patDisplayPrefixLength],
+		TokenHash:   HashOpaqueToken(token),
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, input.ExpiresInDays),
+	})
+	if err != nil {
+		return repository.PersonalAccessToken{}, "", err
+	}
+
+	return stored, token, nil
+}
+
+// normalizeScopes validates and de-duplicates the requested scopes
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, util.ErrInvalidScope
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(PersonalAccessTokenScopes, scope) {
+			return nil, util.ErrInvalidScope
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Authenticate resolves a personal access token, rejecting unknown, revoked and expired tokens
+func (s *PersonalAccessTokenService) Authenticate(ctx context.Context, token string) (repository.PersonalAccessToken, error) {
+	pat, err := s.Queries.GetPersonalAccessTokenByHash(ctx, HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.PersonalAccessToken{}, util.ErrInvalidAccessToken
+		}
+		return repository.PersonalAccessToken{}, err
+	}
+
+	if pat.RevokedAt.Valid || time.Now().After(pat.ExpiresAt) {
+		return repository.PersonalAccessToken{}, util.ErrInvalidAccessToken
+	}
+
+	if !pat.LastUsedAt.Valid || time.Since(pat.LastUsedAt.Time) > patTouchInterval {
+		if err := s.Queries.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+			// not worth failing the request over
+			util.Logger(ctx).Warn("failed to update token usage", "token_id", pat.ID.String(), "error", err)
+		}
+	}
+
+	return pat, nil
+}
+
+// ListTokens returns the user's active tokens, newest first
+func (s *PersonalAccessTokenService) ListTokens(ctx context.Context, userID uuid.UUID) ([]repository.PersonalAccessToken, error) {
+	tokens, err := s.Queries.ListPersonalAccessTokensByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []repository.PersonalAccessToken{}
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes one of the user's tokens
+func (s *PersonalAccessTokenService) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	revoked, err := s.Queries.RevokePersonalAccessToken(ctx, repository.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return util.ErrTokenNotFound
+	}
+	return nil
+}
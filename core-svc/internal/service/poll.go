@@ -3,13 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/telemetry"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PollService struct {
@@ -23,7 +24,10 @@ func NewPollService(repo *repository.Queries) *PollService {
 }
 
 // CreatePoll creates a new poll with options and optional expiration
-func (s *PollService) CreatePoll(ctx context.Context, userID uuid.UUID, question string, options []string, expiresAt *time.Time) (*repository.Poll, error) {
+func (s *PollService) CreatePoll(ctx context.Context, userID uuid.UUID, question string, options []string, expiresAt *time.Time) (_ *repository.Poll, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.CreatePoll", attribute.String("user.id", userID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Validate inputs
 	if question == "" {
 		return nil, fmt.Errorf("poll question cannot be empty")
@@ -63,8 +67,7 @@ func (s *PollService) CreatePoll(ctx context.Context, userID uuid.UUID, question
 		return nil, fmt.Errorf("failed to create poll: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Created poll %s by user %s",
-		util.ColorGreen, util.ColorReset, poll.ID, userID)
+	util.Logger(ctx).Info("created poll", "poll_id", poll.ID.String(), "user_id", userID.String())
 
 	return &repository.Poll{
 		ID:        poll.ID,
@@ -77,7 +80,10 @@ func (s *PollService) CreatePoll(ctx context.Context, userID uuid.UUID, question
 }
 
 // GetPoll retrieves a poll by ID and auto-closes if expired
-func (s *PollService) GetPoll(ctx context.Context, pollID uuid.UUID) (*repository.Poll, error) {
+func (s *PollService) GetPoll(ctx context.Context, pollID uuid.UUID) (_ *repository.Poll, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.GetPoll", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	poll, err := s.repo.GetPollByID(ctx, pollID)
 	if err != nil {
 		return nil, fmt.Errorf("poll not found")
@@ -88,8 +94,7 @@ func (s *PollService) GetPoll(ctx context.Context, pollID uuid.UUID) (*repositor
 		poll.Closed = true
 		_, err := s.repo.ClosePoll(ctx, pollID)
 		if err != nil {
-			log.Printf("%s[POLL WARNING]%s Failed to auto-close expired poll %s: %v",
-				util.ColorYellow, util.ColorReset, pollID, err)
+			util.Logger(ctx).Warn("failed to auto-close expired poll", "poll_id", pollID.String(), "error", err)
 		}
 	}
 
@@ -97,7 +102,10 @@ func (s *PollService) GetPoll(ctx context.Context, pollID uuid.UUID) (*repositor
 }
 
 // UpdatePoll updates a poll's question and/or options (only if no votes exist)
-func (s *PollService) UpdatePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID, question string, options []string) error {
+func (s *PollService) UpdatePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID, question string, options []string) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.UpdatePoll", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check ownership
 	isOwner, err := s.repo.IsPollOwner(ctx, repository.IsPollOwnerParams{
 		ID:     pollID,
@@ -168,14 +176,16 @@ func (s *PollService) UpdatePoll(ctx context.Context, pollID uuid.UUID, userID u
 		return fmt.Errorf("failed to insert options: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Updated poll %s by user %s",
-		util.ColorGreen, util.ColorReset, pollID, userID)
+	util.Logger(ctx).Info("updated poll", "poll_id", pollID.String(), "user_id", userID.String())
 
 	return nil
 }
 
 // DeletePoll deletes a poll (owner only)
-func (s *PollService) DeletePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) error {
+func (s *PollService) DeletePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.DeletePoll", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check ownership
 	isOwner, err := s.repo.IsPollOwner(ctx, repository.IsPollOwnerParams{
 		ID:     pollID,
@@ -194,14 +204,16 @@ func (s *PollService) DeletePoll(ctx context.Context, pollID uuid.UUID, userID u
 		return fmt.Errorf("failed to delete poll: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Deleted poll %s by user %s",
-		util.ColorGreen, util.ColorReset, pollID, userID)
+	util.Logger(ctx).Info("deleted poll", "poll_id", pollID.String(), "user_id", userID.String())
 
 	return nil
 }
 
 // ClosePoll closes a poll (owner only)
-func (s *PollService) ClosePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) error {
+func (s *PollService) ClosePoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.ClosePoll", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check ownership
 	isOwner, err := s.repo.IsPollOwner(ctx, repository.IsPollOwnerParams{
 		ID:     pollID,
@@ -220,14 +232,16 @@ func (s *PollService) ClosePoll(ctx context.Context, pollID uuid.UUID, userID uu
 		return fmt.Errorf("failed to close poll: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Closed poll %s by user %s",
-		util.ColorGreen, util.ColorReset, pollID, userID)
+	util.Logger(ctx).Info("closed poll", "poll_id", pollID.String(), "user_id", userID.String())
 
 	return nil
 }
 
 // ReopenPoll reopens a poll (owner only)
-func (s *PollService) ReopenPoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) error {
+func (s *PollService) ReopenPoll(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.ReopenPoll", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check ownership
 	isOwner, err := s.repo.IsPollOwner(ctx, repository.IsPollOwnerParams{
 		ID:     pollID,
@@ -255,14 +269,16 @@ func (s *PollService) ReopenPoll(ctx context.Context, pollID uuid.UUID, userID u
 		return fmt.Errorf("failed to reopen poll: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Reopened poll %s by user %s",
-		util.ColorGreen, util.ColorReset, pollID, userID)
+	util.Logger(ctx).Info("reopened poll", "poll_id", pollID.String(), "user_id", userID.String())
 
 	return nil
 }
 
 // UpdatePollExpiration updates a poll's expiration time (owner only)
-func (s *PollService) UpdatePollExpiration(ctx context.Context, pollID uuid.UUID, userID uuid.UUID, expiresAt *time.Time) error {
+func (s *PollService) UpdatePollExpiration(ctx context.Context, pollID uuid.UUID, userID uuid.UUID, expiresAt *time.Time) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.UpdatePollExpiration", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check ownership
 	isOwner, err := s.repo.IsPollOwner(ctx, repository.IsPollOwnerParams{
 		ID:     pollID,
@@ -291,15 +307,17 @@ func (s *PollService) UpdatePollExpiration(ctx context.Context, pollID uuid.UUID
 		return fmt.Errorf("failed to update poll expiration: %w", err)
 	}
 
-	log.Printf("%s[POLL]%s Updated expiration for poll %s by user %s",
-		util.ColorGreen, util.ColorReset, pollID, userID)
+	util.Logger(ctx).Info("updated expiration for poll", "poll_id", pollID.String(), "user_id", userID.String())
 
 	return nil
 }
 
 // AutoCloseExpiredPolls closes all expired polls (called periodically)
-func (s *PollService) AutoCloseExpiredPolls(ctx context.Context) error {
-	err := s.repo.AutoCloseExpiredPolls(ctx)
+func (s *PollService) AutoCloseExpiredPolls(ctx context.Context) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.AutoCloseExpiredPolls")
+	defer telemetry.EndSpan(span, &err)
+
+	err = s.repo.AutoCloseExpiredPolls(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to auto-close expired polls: %w", err)
 	}
@@ -307,7 +325,10 @@ func (s *PollService) AutoCloseExpiredPolls(ctx context.Context) error {
 }
 
 // IsPollClosedOrExpired checks if a poll is closed or expired
-func (s *PollService) IsPollClosedOrExpired(ctx context.Context, pollID uuid.UUID) (bool, error) {
+func (s *PollService) IsPollClosedOrExpired(ctx context.Context, pollID uuid.UUID) (_ bool, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.IsPollClosedOrExpired", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	result, err := s.repo.IsPollClosedOrExpired(ctx, pollID)
 	if err != nil {
 		return false, fmt.Errorf("failed to check poll status: %w", err)
@@ -319,7 +340,10 @@ func (s *PollService) IsPollClosedOrExpired(ctx context.Context, pollID uuid.UUI
 }
 
 // GetPollWithVoteCounts retrieves a poll with vote counts for each option
-func (s *PollService) GetPollWithVoteCounts(ctx context.Context, pollID uuid.UUID) (*repository.GetPollWithVoteCountsRow, error) {
+func (s *PollService) GetPollWithVoteCounts(ctx context.Context, pollID uuid.UUID) (_ *repository.GetPollWithVoteCountsRow, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.GetPollWithVoteCounts", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	poll, err := s.repo.GetPollWithVoteCounts(ctx, pollID)
 	if err != nil {
 		return nil, fmt.Errorf("poll not found")
@@ -329,8 +353,7 @@ func (s *PollService) GetPollWithVoteCounts(ctx context.Context, pollID uuid.UUI
 	if poll.ExpiresAt.Valid && time.Now().After(poll.ExpiresAt.Time) && !poll.Closed {
 		_, err := s.repo.ClosePoll(ctx, pollID)
 		if err != nil {
-			log.Printf("%s[POLL WARNING]%s Failed to auto-close expired poll %s: %v",
-				util.ColorYellow, util.ColorReset, pollID, err)
+			util.Logger(ctx).Warn("failed to auto-close expired poll", "poll_id", pollID.String(), "error", err)
 		}
 		poll.Closed = true
 	}
@@ -339,7 +362,10 @@ func (s *PollService) GetPollWithVoteCounts(ctx context.Context, pollID uuid.UUI
 }
 
 // CanUserVote checks if a user can vote on a poll (not closed, not expired, email verified)
-func (s *PollService) CanUserVote(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) error {
+func (s *PollService) CanUserVote(ctx context.Context, pollID uuid.UUID, userID uuid.UUID) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "PollService.CanUserVote", attribute.String("poll.id", pollID.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check if user's email is verified
 	verified, err := s.repo.IsEmailVerified(ctx, userID)
 	if err != nil {
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const (
+	// polls announced (or reminded about) per worker run
+	pollNotificationBatchSize = 20
+
+	// a poll whose emails keep failing is given up on after this many attempts; retries back
+	// off exponentially from pollNotificationRetryBaseDelay
+	pollNotificationMaxAttempts    = 5
+	pollNotificationRetryBaseDelay = time.Minute
+	pollNotificationRetryMaxDelay  = time.Hour
+
+	// reminders can be sent up to a week before a poll expires
+	maxPollReminderHours = 7 * 24
+
+	// emails accepted by one invite request
+	maxInviteesPerRequest = 100
+)
+
+// PollResults is the final tally of a closed poll
+type PollResults struct {
+	PollID     uuid.UUID
+	Question   string
+	Options    []PollResultOption
+	TotalVotes int64
+}
+
+// PollResultOption is one option of PollResults
+type PollResultOption struct {
+	Label   string
+	Votes   int64
+	Percent int
+}
+
+// PollNotificationService emails poll results when polls close and reminds invitees before
+// they do, and lets owners manage reminders and invitees
+type PollNotificationService struct {
+	Queries      *repository.Queries
+	PollService  *PollService
+	EmailService *EmailService
+}
+
+func NewPollNotificationService(queries *repository.Queries, emailService *EmailService) *PollNotificationService {
+	return &PollNotificationService{
+		Queries:      queries,
+		PollService:  NewPollService(queries),
+		EmailService: emailService,
+	}
+}
+
+// checkOwner returns ErrPollNotFound or ErrNotPollOwner unless the user owns the poll
+func (s *PollNotificationService) checkOwner(ctx context.Context, pollID, userID uuid.UUID) error {
+	isOwner, err := s.Queries.IsPollOwner(ctx, repository.IsPollOwnerParams{
+		ID:     pollID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrPollNotFound
+		}
+		return err
+	}
+	if !isOwner {
+		return util.ErrNotPollOwner
+	}
+	return nil
+}
+
+// pollResults tallies the votes of a poll, most voted option first
+func pollResults(ctx context.Context, q *repository.Queries, poll repository.Poll) (PollResults, error) {
+	options, err := q.ListOptionsByPollID(ctx, poll.ID)
+	if err != nil {
+		return PollResults{}, err
+	}
+
+	votes, err := q.ListVotesByPollId(ctx, poll.ID)
+	if err != nil {
+		return PollResults{}, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(votes))
+	var total int64
+	for _, vote := range votes {
+		counts[vote.OptionID] = vote.VoteCount
+		total += vote.VoteCount
+	}
+
+	results := PollResults{
+		PollID:     poll.ID,
+		Question:   poll.Question,
+		Options:    make([]PollResultOption, 0, len(options)),
+		TotalVotes: total,
+	}
+	for _, option := range options {
+		result := PollResultOption{Label: option.Label, Votes: counts[option.ID]}
+		if total > 0 {
+			result.Percent = int((result.Votes*100 + total/2) / total)
+		}
+		results.Options = append(results.Options, result)
+	}
+	slices.SortStableFunc(results.Options, func(a, b PollResultOption) int {
+		return cmp.Compare(b.Votes, a.Votes)
+	})
+
+	return results, nil
+}
+
+// AnnounceClosedPolls emails the results of polls that closed since the last run to their
+// owners and to voters who asked for them, and returns how many polls were announced
+func (s *PollNotificationService) AnnounceClosedPolls(ctx context.Context) (int, error) {
+	return s.processPolls(ctx, "results",
+		func(q *repository.Queries) (claimedPoll, error) {
+			claimed, err := q.ClaimPollResultsNotice(ctx, pollNotificationMaxAttempts)
+			return claimedPoll{ID: claimed.PollID, Attempts: claimed.Attempts}, err
+		},
+		s.announceResults,
+		func(q *repository.Queries, pollID uuid.UUID, lastError pgtype.Text, nextAttemptAt pgtype.Timestamptz) error {
+			return q.RecordPollResultsNoticeFailure(ctx, repository.RecordPollResultsNoticeFailureParams{
+				PollID:        pollID,
+				LastError:     lastError,
+				NextAttemptAt: nextAttemptAt,
+			})
+		},
+	)
+}
+
+// claimedPoll is a poll a worker claimed to email about, with its earlier failed attempts
+type claimedPoll struct {
+	ID       uuid.UUID
+	Attempts int32
+}
+
+// pollNotificationRetryDelay is the wait before the next attempt after the given number of failures
+func pollNotificationRetryDelay(failures int32) time.Duration {
+	delay := pollNotificationRetryBaseDelay
+	for i := int32(1); i < failures && delay < pollNotificationRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, pollNotificationRetryMaxDelay)
+}
+
+// processPolls claims up to a batch of polls one at a time and emails about each, returning
+// how many succeeded. A poll's emails are queued in a savepoint under its claim: when they
+// fail only they are rolled back, the failure is recorded on the claim so the poll is retried
+// later, and the batch moves on. Only errors claiming or recording stop the batch.
+func (s *PollNotificationService) processPolls(
+	ctx context.Context,
+	kind string,
+	claim func(q *repository.Queries) (claimedPoll, error),
+	notify func(ctx context.Context, q *repository.Queries, pollID uuid.UUID) error,
+	recordFailure func(q *repository.Queries, pollID uuid.UUID, lastError pgtype.Text, nextAttemptAt pgtype.Timestamptz) error,
+) (int, error) {
+	succeeded := 0
+	for range pollNotificationBatchSize {
+		err := s.Queries.InTx(ctx, func(q *repository.Queries) error {
+			poll, err := claim(q)
+			if err != nil {
+				return err
+			}
+
+			notifyErr := q.InTx(ctx, func(q *repository.Queries) error {
+				return notify(ctx, q, poll.ID)
+			})
+			if notifyErr == nil {
+				succeeded++
+				return nil
+			}
+
+			failures := poll.Attempts + 1
+			nextAttemptAt := time.Now().Add(pollNotificationRetryDelay(failures))
+			if failures >= pollNotificationMaxAttempts {
+				util.Logger(ctx).Error("giving up on poll notification",
+					"kind", kind,
+					"poll_id", poll.ID.String(),
+					"attempts", failures,
+					"error", notifyErr,
+				)
+			} else {
+				util.Logger(ctx).Warn("poll notification failed",
+					"kind", kind,
+					"poll_id", poll.ID.String(),
+					"attempts", failures,
+					"next_attempt_at", nextAttemptAt,
+					"error", notifyErr,
+				)
+			}
+
+			return recordFailure(q, poll.ID,
+				pgtype.Text{String: notifyErr.Error(), Valid: true},
+				pgtype.Timestamptz{Time: nextAttemptAt, Valid: true},
+			)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return succeeded, nil
+		}
+		if err != nil {
+			return succeeded, err
+		}
+	}
+	return succeeded, nil
+}
+
+func (s *PollNotificationService) announceResults(ctx context.Context, q *repository.Queries, pollID uuid.UUID) error {
+	poll, err := q.GetPollByID(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("failed to load poll: %w", err)
+	}
+
+	results, err := pollResults(ctx, q, poll)
+	if err != nil {
+		return fmt.Errorf("failed to count votes: %w", err)
+	}
+
+	emails := s.EmailService.WithQueries(q)
+
+	owner, err := q.GetUserByID(ctx, poll.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load poll owner: %w", err)
+	}
+	if err := emails.SendPollResultsEmail(ctx, owner.ID, owner.Email, owner.Name, results, true); err != nil {
+		return err
+	}
+
+	subscribers, err := q.ListPollResultSubscribers(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("failed to list results subscribers: %w", err)
+	}
+	for _, subscriber := range subscribers {
+		// the owner already got the results
+		if subscriber.UserID == poll.UserID {
+			continue
+		}
+		if err := emails.SendPollResultsEmail(ctx, subscriber.UserID, subscriber.Email, subscriber.Name, results, false); err != nil {
+			return err
+		}
+	}
+
+	util.Logger(ctx).Info("poll results announced",
+		"poll_id", pollID.String(),
+		"total_votes", results.TotalVotes,
+		"subscribers", len(subscribers),
+	)
+	return nil
+}
+
+// SendDueReminders reminds the invitees of polls that close soon and who haven't voted, and
+// returns how many polls reminders were sent for
+func (s *PollNotificationService) SendDueReminders(ctx context.Context) (int, error) {
+	return s.processPolls(ctx, "reminder",
+		func(q *repository.Queries) (claimedPoll, error) {
+			claimed, err := q.ClaimDuePollReminder(ctx, pollNotificationMaxAttempts)
+			return claimedPoll{ID: claimed.PollID, Attempts: claimed.Attempts}, err
+		},
+		s.remindInvitees,
+		func(q *repository.Queries, pollID uuid.UUID, lastError pgtype.Text, nextAttemptAt pgtype.Timestamptz) error {
+			return q.RecordPollReminderFailure(ctx, repository.RecordPollReminderFailureParams{
+				PollID:        pollID,
+				LastError:     lastError,
+				NextAttemptAt: nextAttemptAt,
+			})
+		},
+	)
+}
+
+func (s *PollNotificationService) remindInvitees(ctx context.Context, q *repository.Queries, pollID uuid.UUID) error {
+	poll, err := q.GetPollByID(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("failed to load poll: %w", err)
+	}
+
+	invitees, err := q.ListPollInviteesToRemind(ctx, pollID)
+	if err != nil {
+		return fmt.Errorf("failed to list invitees: %w", err)
+	}
+
+	emails := s.EmailService.WithQueries(q)
+	for _, invitee := range invitees {
+		if err := emails.SendPollReminderEmail(ctx, invitee.UserID, invitee.Email, invitee.Name, poll.ID, poll.Question, poll.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	util.Logger(ctx).Info("poll reminders sent", "poll_id", pollID.String(), "invitees", len(invitees))
+	return nil
+}
+
+// RunWorker closes expired polls, announces the results of closed ones and sends due
+// reminders every interval until ctx is cancelled
+func (s *PollNotificationService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// polls otherwise only close on expiry when someone looks at them
+		if err := s.PollService.AutoCloseExpiredPolls(ctx); err != nil {
+			util.Logger(ctx).Error("failed to close expired polls", "error", err)
+		}
+
+		if announced, err := s.AnnounceClosedPolls(ctx); err != nil {
+			util.Logger(ctx).Error("failed to announce poll results", "error", err)
+		} else if announced > 0 {
+			util.Logger(ctx).Info("announced poll results", "polls", announced)
+		}
+
+		if reminded, err := s.SendDueReminders(ctx); err != nil {
+			util.Logger(ctx).Error("failed to send poll reminders", "error", err)
+		} else if reminded > 0 {
+			util.Logger(ctx).Info("sent poll reminders", "polls", reminded)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollReminder is the reminder setting of a poll
+type PollReminder struct {
+	HoursBefore int32      `json:"hours_before"`
+	SentAt      *time.Time `json:"sent_at"`
+}
+
+func newPollReminder(reminder repository.PollReminder) PollReminder {
+	out := PollReminder{HoursBefore: reminder.HoursBefore}
+	if reminder.SentAt.Valid {
+		out.SentAt = &reminder.SentAt.Time
+	}
+	return out
+}
+
+// GetReminder returns the reminder setting of the user's poll
+func (s *PollNotificationService) GetReminder(ctx context.Context, pollID, userID uuid.UUID) (PollReminder, error) {
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return PollReminder{}, err
+	}
+
+	reminder, err := s.Queries.GetPollReminder(ctx, pollID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PollReminder{}, util.ErrPollReminderNotFound
+		}
+		return PollReminder{}, err
+	}
+	return newPollReminder(reminder), nil
+}
+
+// SetReminder reminds invitees who haven't voted the given number of hours before the poll
+// expires; polls without an expiration never send it
+func (s *PollNotificationService) SetReminder(ctx context.Context, pollID, userID uuid.UUID, hoursBefore int) (PollReminder, error) {
+	if hoursBefore < 1 || hoursBefore > maxPollReminderHours {
+		return PollReminder{}, util.ErrInvalidReminderHours
+	}
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return PollReminder{}, err
+	}
+
+	reminder, err := s.Queries.UpsertPollReminder(ctx, repository.UpsertPollReminderParams{
+		PollID:      pollID,
+		HoursBefore: int32(hoursBefore),
+	})
+	if err != nil {
+		return PollReminder{}, err
+	}
+
+	util.Logger(ctx).Info("poll reminder set", "poll_id", pollID.String(), "hours_before", hoursBefore)
+	return newPollReminder(reminder), nil
+}
+
+// DeleteReminder turns reminders off for the user's poll
+func (s *PollNotificationService) DeleteReminder(ctx context.Context, pollID, userID uuid.UUID) error {
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.Queries.DeletePollReminder(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return util.ErrPollReminderNotFound
+	}
+	return nil
+}
+
+// PollInvitee is a user invited to vote on a poll
+type PollInvitee struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Voted     bool      `json:"voted"`
+	InvitedAt time.Time `json:"invited_at"`
+}
+
+// ListInvitees lists the invitees of the user's poll
+func (s *PollNotificationService) ListInvitees(ctx context.Context, pollID, userID uuid.UUID) ([]PollInvitee, error) {
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.Queries.ListPollInvitees(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitees := make([]PollInvitee, 0, len(rows))
+	for _, row := range rows {
+		invitees = append(invitees, PollInvitee{
+			UserID:    row.UserID,
+			Name:      row.Name,
+			Email:     row.Email,
+			Voted:     row.Voted,
+			InvitedAt: row.CreatedAt,
+		})
+	}
+	return invitees, nil
+}
+
+// AddInvitees invites the users with the given emails to the user's poll and returns the
+// invitees. Emails without an account are skipped.
+func (s *PollNotificationService) AddInvitees(ctx context.Context, pollID, userID uuid.UUID, emails []string) ([]PollInvitee, error) {
+	if len(emails) > maxInviteesPerRequest {
+		return nil, util.ErrTooManyInvitees
+	}
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		if email = strings.TrimSpace(email); email != "" {
+			normalized = append(normalized, email)
+		}
+	}
+
+	added, err := s.Queries.AddPollInvitees(ctx, repository.AddPollInviteesParams{
+		PollID: pollID,
+		Emails: normalized,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	util.Logger(ctx).Info("poll invitees added", "poll_id", pollID.String(), "requested", len(normalized), "added", added)
+	return s.ListInvitees(ctx, pollID, userID)
+}
+
+// RemoveInvitee withdraws an invitation to the user's poll
+func (s *PollNotificationService) RemoveInvitee(ctx context.Context, pollID, userID, inviteeID uuid.UUID) error {
+	if err := s.checkOwner(ctx, pollID, userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.Queries.DeletePollInvitee(ctx, repository.DeletePollInviteeParams{
+		PollID: pollID,
+		UserID: inviteeID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return util.ErrPollInviteeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+)
+
+// claimsInOrder answers a claim query with the given rows, then with no rows
+func claimsInOrder[T any](rows ...T) fakeResult {
+	return func([]any) (any, error) {
+		if len(rows) == 0 {
+			return nil, nil
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+}
+
+func TestSendDueRemindersSkipsFailingPoll(t *testing.T) {
+	db := newFakeDB()
+	s := NewPollNotificationService(db.queries(), NewEmailService(db.queries(), newTestConfig(t)))
+
+	failing, healthy := uuid.New(), uuid.New()
+	db.on("ClaimDuePollReminder", claimsInOrder(
+		repository.ClaimDuePollReminderRow{PollID: failing, Attempts: 1},
+		repository.ClaimDuePollReminderRow{PollID: healthy},
+	))
+	db.on("GetPollByID", func(args []any) (any, error) {
+		if args[0] == failing {
+			return nil, errors.New("poll row is corrupt")
+		}
+		return repository.Poll{
+			ID:        healthy,
+			Question:  "Lunch?",
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		}, nil
+	})
+	db.returns("ListPollInviteesToRemind", []repository.ListPollInviteesToRemindRow{})
+	db.on("RecordPollReminderFailure", func([]any) (any, error) { return nil, nil })
+
+	before := time.Now()
+	reminded, err := s.SendDueReminders(t.Context())
+	if err != nil {
+		t.Fatalf("SendDueReminders: %v", err)
+	}
+	if reminded != 1 {
+		t.Errorf("reminded = %d, want 1", reminded)
+	}
+
+	// the healthy poll after the failing one was still processed
+	if listed := db.called("ListPollInviteesToRemind"); len(listed) != 1 || listed[0].Args[0] != healthy {
+		t.Errorf("ListPollInviteesToRemind calls = %v, want one for the healthy poll", listed)
+	}
+
+	failures := db.called("RecordPollReminderFailure")
+	if len(failures) != 1 {
+		t.Fatalf("RecordPollReminderFailure calls = %v, want one", failures)
+	}
+	args := failures[0].Args
+	if args[0] != failing {
+		t.Errorf("failure recorded for %v, want the failing poll", args[0])
+	}
+	if lastError := args[1].(pgtype.Text); lastError.String != "failed to load poll: poll row is corrupt" {
+		t.Errorf("last_error = %q", lastError.String)
+	}
+	// second failure waits twice the base delay
+	nextAttemptAt := args[2].(pgtype.Timestamptz).Time
+	if wait := nextAttemptAt.Sub(before); wait < 2*pollNotificationRetryBaseDelay || wait > 2*pollNotificationRetryBaseDelay+time.Minute {
+		t.Errorf("next attempt in %v, want about %v", wait, 2*pollNotificationRetryBaseDelay)
+	}
+
+	// each claim runs in its own transaction with the emails in a savepoint; the failing
+	// poll's savepoint and the final empty claim are rolled back
+	if db.begins != 5 || db.commits != 3 || db.rollbacks != 2 {
+		t.Errorf("begins/commits/rollbacks = %d/%d/%d, want 5/3/2", db.begins, db.commits, db.rollbacks)
+	}
+}
+
+func TestAnnounceClosedPollsRecordsFailureAndContinues(t *testing.T) {
+	db := newFakeDB()
+	s := NewPollNotificationService(db.queries(), NewEmailService(db.queries(), newTestConfig(t)))
+
+	first, second := uuid.New(), uuid.New()
+	db.on("ClaimPollResultsNotice", claimsInOrder(
+		repository.ClaimPollResultsNoticeRow{PollID: first, Attempts: pollNotificationMaxAttempts - 1},
+		repository.ClaimPollResultsNoticeRow{PollID: second},
+	))
+	db.on("GetPollByID", func([]any) (any, error) { return nil, errors.New("connection reset") })
+	db.on("RecordPollResultsNoticeFailure", func([]any) (any, error) { return nil, nil })
+
+	announced, err := s.AnnounceClosedPolls(t.Context())
+	if err != nil {
+		t.Fatalf("AnnounceClosedPolls: %v", err)
+	}
+	if announced != 0 {
+		t.Errorf("announced = %d, want 0", announced)
+	}
+
+	failures := db.called("RecordPollResultsNoticeFailure")
+	if len(failures) != 2 || failures[0].Args[0] != first || failures[1].Args[0] != second {
+		t.Fatalf("RecordPollResultsNoticeFailure calls = %v, want one per poll in claim order", failures)
+	}
+}
+
+func TestPollNotificationRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{40, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := pollNotificationRetryDelay(tt.failures); got != tt.want {
+			t.Errorf("pollNotificationRetryDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// Built-in roles
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permissions that can be granted to a role
+const (
+	PermissionPollReadAny       = "poll.read.any"
+	PermissionPollCloseAny      = "poll.close.any"
+	PermissionPollDeleteAny     = "poll.delete.any"
+	PermissionUserRead          = "user.read"
+	PermissionUserUpdate        = "user.update"
+	PermissionUserRoleAssign    = "user.role.assign"
+	PermissionUserPasswordReset = "user.password_reset"
+	PermissionUserLogout        = "user.logout"
+	PermissionUserUnlock        = "user.unlock"
+	PermissionUserSuspend       = "user.suspend"
+	PermissionUserImpersonate   = "user.impersonate"
+	PermissionUserDelete        = "user.delete"
+	PermissionAuditRead         = "audit.read"
+	PermissionAuditWrite        = "audit.write"
+	PermissionRoleManage        = "role.manage"
+	PermissionEmailPreview      = "email.preview"
+	PermissionEmailManage       = "email.manage"
+)
+
+// Permissions lists every valid permission
+var Permissions = []string{
+	PermissionPollReadAny,
+	PermissionPollCloseAny,
+	PermissionPollDeleteAny,
+	PermissionUserRead,
+	PermissionUserUpdate,
+	PermissionUserRoleAssign,
+	PermissionUserPasswordReset,
+	PermissionUserLogout,
+	PermissionUserUnlock,
+	PermissionUserSuspend,
+	PermissionUserImpersonate,
+	PermissionUserDelete,
+	PermissionAuditRead,
+	PermissionAuditWrite,
+	PermissionRoleManage,
+	PermissionEmailPreview,
+	PermissionEmailManage,
+}
+
+// permissions of the built-in roles; custom roles keep theirs in the database
+var builtInRolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleModerator: {
+		PermissionPollReadAny,
+		PermissionPollCloseAny,
+		PermissionPollDeleteAny,
+		PermissionUserRead,
+		PermissionUserSuspend,
+		PermissionAuditRead,
+	},
+	RoleAdmin: Permissions,
+}
+
+// lowercase, starts with a letter, up to 50 characters
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// RoleService resolves role permissions and manages custom roles
+type RoleService struct {
+	Queries      *repository.Queries
+	AuditService *AuditService
+}
+
+func NewRoleService(queries *repository.Queries) *RoleService {
+	return &RoleService{
+		Queries:      queries,
+		AuditService: NewAuditService(queries),
+	}
+}
+
+// IsBuiltInRole reports whether the role is defined in code
+func IsBuiltInRole(name string) bool {
+	_, ok := builtInRolePermissions[name]
+	return ok
+}
+
+// RolePermissions returns the effective permissions of a role
+func (s *RoleService) RolePermissions(ctx context.Context, name string) ([]string, error) {
+	if permissions, ok := builtInRolePermissions[name]; ok {
+		return slices.Clone(permissions), nil
+	}
+
+	role, err := s.Queries.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, util.ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	// skip permissions that have since been removed from the code
+	permissions := []string{}
+	for _, permission := range role.Permissions {
+		if slices.Contains(Permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// UserPermissions returns the effective permissions of the user's role
+func (s *RoleService) UserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	user, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, util.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return s.RolePermissions(ctx, user.Role)
+}
+
+// EnsureGrantable fails with ErrInsufficientPerms unless the actor holds every permission,
+// so nobody can hand out more access than they have themselves
+func (s *RoleService) EnsureGrantable(ctx context.Context, actorUserID uuid.UUID, permissions []string) error {
+	granted, err := s.UserPermissions(ctx, actorUserID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !slices.Contains(granted, permission) {
+			return util.ErrInsufficientPerms
+		}
+	}
+	return nil
+}
+
+// ListRoles returns every role with its effective permissions
+func (s *RoleService) ListRoles(ctx context.Context) ([]repository.AppRole, error) {
+	roles, err := s.Queries.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range roles {
+		if permissions, ok := builtInRolePermissions[roles[i].Name]; ok {
+			roles[i].Permissions = slices.Clone(permissions)
+		}
+	}
+	return roles, nil
+}
+
+// RoleInput describes a custom role
+type RoleInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// CreateRole adds a custom role
+func (s *RoleService) CreateRole(ctx context.Context, actorUserID uuid.UUID, input RoleInput) (repository.AppRole, error) {
+	name := strings.TrimSpace(input.Name)
+	if !roleNamePattern.MatchString(name) {
+		return repository.AppRole{}, util.ErrInvalidInput
+	}
+	if IsBuiltInRole(name) {
+		return repository.AppRole{}, util.ErrRoleExists
+	}
+
+	permissions, err := normalizePermissions(input.Permissions)
+	if err != nil {
+		return repository.AppRole{}, err
+	}
+	if err := s.EnsureGrantable(ctx, actorUserID, permissions); err != nil {
+		return repository.AppRole{}, err
+	}
+
+	if _, err := s.Queries.GetRoleByName(ctx, name); err == nil {
+		return repository.AppRole{}, util.ErrRoleExists
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return repository.AppRole{}, err
+	}
+
+	role, err := s.Queries.CreateRole(ctx, repository.CreateRoleParams{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	})
+	if err != nil {
+		return repository.AppRole{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, actorUserID, AuditActionRoleCreate, "role", role.ID, map[string]interface{}{
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log role creation", "error", err)
+	}
+
+	return role, nil
+}
+
+// UpdateRole replaces the description and permissions of a custom role
+func (s *RoleService) UpdateRole(ctx context.Context, actorUserID uuid.UUID, name string, input RoleInput) (repository.AppRole, error) {
+	if IsBuiltInRole(name) {
+		return repository.AppRole{}, util.ErrBuiltInRole
+	}
+
+	permissions, err := normalizePermissions(input.Permissions)
+	if err != nil {
+		return repository.AppRole{}, err
+	}
+	if err := s.EnsureGrantable(ctx, actorUserID, permissions); err != nil {
+		return repository.AppRole{}, err
+	}
+
+	role, err := s.Queries.UpdateRole(ctx, repository.UpdateRoleParams{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.AppRole{}, util.ErrRoleNotFound
+		}
+		return repository.AppRole{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, actorUserID, AuditActionRoleUpdate, "role", role.ID, map[string]interface{}{
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log role update", "error", err)
+	}
+
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no user has
+func (s *RoleService) DeleteRole(ctx context.Context, actorUserID uuid.UUID, name string) error {
+	if IsBuiltInRole(name) {
+		return util.ErrBuiltInRole
+	}
+
+	role, err := s.Queries.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrRoleNotFound
+		}
+		return err
+	}
+
+	members, err := s.Queries.CountUsersByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return util.ErrRoleInUse
+	}
+
+	deleted, err := s.Queries.DeleteRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return util.ErrRoleNotFound
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, actorUserID, AuditActionRoleDelete, "role", role.ID, map[string]interface{}{
+		"name": role.Name,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log role deletion", "error", err)
+	}
+
+	return nil
+}
+
+func normalizePermissions(requested []string) ([]string, error) {
+	permissions := make([]string, 0, len(requested))
+	for _, permission := range requested {
+		permission = strings.TrimSpace(permission)
+		if !slices.Contains(Permissions, permission) {
+			return nil, util.ErrInvalidPermission
+		}
+		if !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+// how often last_seen_at is written for an active session
+const sessionTouchInterval = time.Minute
+
+// SessionService manages server-side session records (one per login / device)
+type SessionService struct {
+	Queries *repository.Queries
+}
+
+func NewSessionService(queries *repository.Queries) *SessionService {
+	return &SessionService{
+		Queries: queries,
+	}
+}
+
+// SessionMeta describes the client a session was created from
+type SessionMeta struct {
+	UserAgent string
+	IPAddress string
+}
+
+// CreateSession records a new session for the user
+func (s *SessionService) CreateSession(ctx context.Context, userID uuid.UUID, meta SessionMeta) (repository.UserSession, error) {
+	return s.Queries.CreateUserSession(ctx, repository.CreateUserSessionParams{
+		UserID:    userID,
+		UserAgent: meta.UserAgent,
+		IpAddress: meta.IPAddress,
+	})
+}
+
+// ValidateSession checks that the session exists, belongs to the user and is neither revoked
+// nor expired. Activity is recorded at most once per sessionTouchInterval.
+func (s *SessionService) ValidateSession(ctx context.Context, sessionID, userID uuid.UUID, ipAddress string) error {
+	session, err := s.Queries.GetUserSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrSessionRevoked
+		}
+		return err
+	}
+
+	if session.UserID != userID || session.RevokedAt.Valid {
+		return util.ErrSessionRevoked
+	}
+	if session.ExpiresAt.Valid && !session.ExpiresAt.Time.After(time.Now()) {
+		return util.ErrSessionRevoked
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval || session.IpAddress != ipAddress {
+		if err := s.Queries.TouchUserSession(ctx, repository.TouchUserSessionParams{
+			ID:        sessionID,
+			IpAddress: ipAddress,
+		}); err != nil {
+			// not worth failing the request over
+			util.Logger(ctx).Warn("failed to update session activity", "session_id", sessionID.String(), "error", err)
+		}
+	}
+
+	return nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *SessionService) ListSessions(ctx context.Context, userID uuid.UUID) ([]repository.UserSession, error) {
+	sessions, err := s.Queries.ListActiveUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []repository.UserSession{}
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions together with its refresh tokens.
+// Returns ErrSessionNotFound if the user has no such active session.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	revoked, err := s.Queries.RevokeUserSession(ctx, repository.RevokeUserSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return util.ErrSessionNotFound
+	}
+
+	return s.Queries.RevokeRefreshTokenFamily(ctx, sessionID)
+}
+
+// RevokeAllSessions logs the user out everywhere and returns the number of sessions revoked
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	revoked, err := s.Queries.RevokeAllUserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.Queries.RevokeRefreshTokensByUserID(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+// RevokeOtherSessions signs the user out of every session except keepSessionID and returns
+// the number of sessions revoked
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) (int64, error) {
+	revoked, err := s.Queries.RevokeOtherUserSessions(ctx, repository.RevokeOtherUserSessionsParams{
+		UserID: userID,
+		ID:     keepSessionID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.Queries.RevokeOtherRefreshTokensByUserID(ctx, repository.RevokeOtherRefreshTokensByUserIDParams{
+		UserID:   userID,
+		FamilyID: keepSessionID,
+	}); err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
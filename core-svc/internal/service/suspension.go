@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const suspensionMaxReasonLength = 500
+
+// AccountSuspendedError is returned when a suspended user signs in or makes a request.
+// It wraps util.ErrAccountSuspended.
+type AccountSuspendedError struct {
+	Reason string
+	// Until is nil for a permanent ban
+	Until *time.Time
+}
+
+func (e *AccountSuspendedError) Error() string { return util.ErrAccountSuspended.Error() }
+
+func (e *AccountSuspendedError) Unwrap() error { return util.ErrAccountSuspended }
+
+// SuspensionService suspends accounts without deleting them. A suspended user can't sign
+// in or use the API, but their polls and votes are kept.
+type SuspensionService struct {
+	Queries        *repository.Queries
+	AuditService   *AuditService
+	SessionService *SessionService
+	RoleService    *RoleService
+}
+
+func NewSuspensionService(queries *repository.Queries) *SuspensionService {
+	return &SuspensionService{
+		Queries:        queries,
+		AuditService:   NewAuditService(queries),
+		SessionService: NewSessionService(queries),
+		RoleService:    NewRoleService(queries),
+	}
+}
+
+// CheckNotSuspended returns an *AccountSuspendedError while the user is suspended
+func (s *SuspensionService) CheckNotSuspended(ctx context.Context, userID uuid.UUID) error {
+	suspension, err := s.GetSuspension(ctx, userID)
+	if err != nil {
+		if errors.Is(err, util.ErrNotSuspended) {
+			return nil
+		}
+		return err
+	}
+
+	suspended := &AccountSuspendedError{Reason: suspension.Reason}
+	if suspension.SuspendedUntil.Valid {
+		suspended.Until = &suspension.SuspendedUntil.Time
+	}
+	return suspended
+}
+
+// GetSuspension returns the user's current suspension
+func (s *SuspensionService) GetSuspension(ctx context.Context, userID uuid.UUID) (repository.UserSuspension, error) {
+	suspension, err := s.Queries.GetActiveUserSuspension(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.UserSuspension{}, util.ErrNotSuspended
+		}
+		return repository.UserSuspension{}, err
+	}
+	return suspension, nil
+}
+
+// SuspendUserInput contains suspension parameters
+type SuspendUserInput struct {
+	ActorUserID  uuid.UUID
+	TargetUserID uuid.UUID
+	Reason       string
+	// Until is nil for a permanent ban
+	Until *time.Time
+}
+
+// SuspendUser suspends (or re-suspends) a user and signs them out everywhere
+func (s *SuspensionService) SuspendUser(ctx context.Context, input SuspendUserInput) (repository.UserSuspension, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > suspensionMaxReasonLength {
+		return repository.UserSuspension{}, util.ErrInvalidInput
+	}
+	if input.Until != nil && !input.Until.After(time.Now()) {
+		return repository.UserSuspension{}, util.ErrInvalidInput
+	}
+	if input.ActorUserID == input.TargetUserID {
+		return repository.UserSuspension{}, util.ErrCannotSuspendSelf
+	}
+
+	if err := s.ensureCanModerate(ctx, input.ActorUserID, input.TargetUserID); err != nil {
+		return repository.UserSuspension{}, err
+	}
+
+	until := pgtype.Timestamptz{}
+	if input.Until != nil {
+		until = pgtype.Timestamptz{Time: *input.Until, Valid: true}
+	}
+
+	suspension, err := s.Queries.UpsertUserSuspension(ctx, repository.UpsertUserSuspensionParams{
+		UserID:         input.TargetUserID,
+		Reason:         reason,
+		SuspendedBy:    pgtype.UUID{Bytes: input.ActorUserID, Valid: true},
+		SuspendedUntil: until,
+	})
+	if err != nil {
+		return repository.UserSuspension{}, err
+	}
+
+	revoked, err := s.SessionService.RevokeAllSessions(ctx, input.TargetUserID)
+	if err != nil {
+		return repository.UserSuspension{}, err
+	}
+
+	if err := s.AuditService.LogEntryWithMeta(ctx, input.ActorUserID, AuditActionUserSuspend, "user", input.TargetUserID, map[string]interface{}{
+		"reason":           reason,
+		"until":            input.Until,
+		"sessions_revoked": revoked,
+	}); err != nil {
+		util.Logger(ctx).Error("failed to log user suspension", "error", err)
+	}
+
+	return suspension, nil
+}
+
+// UnsuspendUserInput contains parameters for lifting a suspension
+type UnsuspendUserInput struct {
+	ActorUserID  uuid.UUID
+	TargetUserID uuid.UUID
+}
+
+// UnsuspendUser lifts a user's current suspension
+func (s *SuspensionService) UnsuspendUser(ctx context.Context, input UnsuspendUserInput) error {
+	if err := s.ensureCanModerate(ctx, input.ActorUserID, input.TargetUserID); err != nil {
+		return err
+	}
+
+	lifted, err := s.Queries.DeleteUserSuspension(ctx, input.TargetUserID)
+	if err != nil {
+		return err
+	}
+	if lifted == 0 {
+		return util.ErrNotSuspended
+	}
+
+	if err := s.AuditService.LogUserAction(ctx, input.ActorUserID, AuditActionUserUnsuspend, input.TargetUserID); err != nil {
+		util.Logger(ctx).Error("failed to log user unsuspension", "error", err)
+	}
+
+	return nil
+}
+
+// ensureCanModerate checks the target exists and that the actor holds every permission of
+// the target's role, so a moderator can't suspend an admin
+func (s *SuspensionService) ensureCanModerate(ctx context.Context, actorUserID, targetUserID uuid.UUID) error {
+	target, err := s.Queries.GetUserByID(ctx, targetUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrUserNotFound
+		}
+		return err
+	}
+
+	permissions, err := s.RoleService.RolePermissions(ctx, target.Role)
+	if err != nil {
+		return err
+	}
+	return s.RoleService.EnsureGrantable(ctx, actorUserID, permissions)
+}
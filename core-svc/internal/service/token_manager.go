@@ -1,6 +1,9 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
@@ -15,15 +18,26 @@ import (
 
 type AuthTokenData struct {
 	ID uuid.UUID
+	// SessionID is the server-side session (and refresh token family) the token belongs to
+	SessionID uuid.UUID `json:"sid"`
+	// Actor is set when an admin is impersonating the user
+	Actor *TokenActor `json:"act,omitempty"`
+}
+
+// TokenActor is the RFC 8693 "act" claim: the party actually making the requests
+type TokenActor struct {
+	ID uuid.UUID `json:"sub"`
 }
 
 type TokenService struct {
 	Config *util.Config
+	Keys   *util.KeySet
 }
 
 func NewTokenService(config *util.Config) *TokenService {
 	return &TokenService{
 		Config: config,
+		Keys:   util.SigningKeys(),
 	}
 }
 
@@ -33,22 +47,30 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a JWT token signed with HS256 and includes user ID and expiry
+// GenerateToken creates a JWT signed with the active signing key and includes user ID and expiry
 func (t *TokenService) GenerateToken(data AuthTokenData) (string, error) {
+	return t.generateAccessToken(data, time.Now().Add(t.Config.AccessTokenTTL()))
+}
+
+// GenerateImpersonationToken creates an access token for the user that names the admin in the
+// "act" claim and expires with the impersonation session
+func (t *TokenService) GenerateImpersonationToken(data AuthTokenData, impersonatorID uuid.UUID, expiresAt time.Time) (string, error) {
+	data.Actor = &TokenActor{ID: impersonatorID}
+	return t.generateAccessToken(data, expiresAt)
+}
+
+func (t *TokenService) generateAccessToken(data AuthTokenData, expiresAt time.Time) (string, error) {
 	claims := Claims{
 		AuthTokenData: data,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(t.Config.AuthTokenLifespanHours) * time.Hour)),
+			Issuer:    t.Config.APIBaseURL,
+			Subject:   data.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			// Optional: add Issuer, Subject, etc.
-
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := []byte(t.Config.AuthSecret)
-
-	signedToken, err := token.SignedString(secret)
+	signedToken, err := t.Keys.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -56,6 +78,116 @@ func (t *TokenService) GenerateToken(data AuthTokenData) (string, error) {
 	return signedToken, nil
 }
 
+// parse verifies the signature against the key set and parses the claims
+func (t *TokenService) parse(tokenString string, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods(t.Keys.Algorithms()))
+	return jwt.ParseWithClaims(tokenString, &Claims{}, t.Keys.Keyfunc, opts...)
+}
+
+// audience of the short-lived token that carries a login between the password and 2FA steps
+const mfaTokenAudience = "pollex:mfa"
+
+// lifetime of the 2FA login challenge
+const mfaTokenLifespan = 5 * time.Minute
+
+// GenerateMFAToken creates the challenge token returned by a password login that still needs a 2FA code.
+// Its jti identifies the challenge so wrong codes can be counted against it.
+func (t *TokenService) GenerateMFAToken(userID uuid.UUID) (string, error) {
+	claims := Claims{
+		AuthTokenData: AuthTokenData{ID: userID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Audience:  jwt.ClaimStrings{mfaTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenLifespan)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return t.Keys.Sign(claims)
+}
+
+// MFAChallenge is a validated 2FA challenge token
+type MFAChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+// ParseMFAToken validates a 2FA challenge token and returns the challenge and the user it was issued for
+func (t *TokenService) ParseMFAToken(tokenString string) (MFAChallenge, error) {
+	token, err := t.parse(tokenString, jwt.WithAudience(mfaTokenAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.AuthTokenData.ID == uuid.Nil {
+		return MFAChallenge{}, errors.New("invalid token claims")
+	}
+
+	challengeID, err := uuid.Parse(claims.RegisteredClaims.ID)
+	if err != nil {
+		return MFAChallenge{}, errors.New("invalid token claims")
+	}
+
+	return MFAChallenge{
+		ID:        challengeID,
+		UserID:    claims.AuthTokenData.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// audience of the token in one-click unsubscribe links
+const unsubscribeTokenAudience = "pollex:unsubscribe"
+
+// unsubscribe links keep working for a while after the email was sent, but not forever, so
+// retired signing keys can eventually be dropped
+const unsubscribeTokenLifespan = 90 * 24 * time.Hour
+
+// UnsubscribeClaims identify the user and the notification category to turn off
+type UnsubscribeClaims struct {
+	Category string `json:"cat"`
+	jwt.RegisteredClaims
+}
+
+// GenerateUnsubscribeToken creates the token of an unsubscribe link, which works without signing in
+func (t *TokenService) GenerateUnsubscribeToken(userID uuid.UUID, category string) (string, error) {
+	claims := UnsubscribeClaims{
+		Category: category,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{unsubscribeTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(unsubscribeTokenLifespan)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return t.Keys.Sign(claims)
+}
+
+// ParseUnsubscribeToken validates an unsubscribe token and returns the user and category
+func (t *TokenService) ParseUnsubscribeToken(tokenString string) (uuid.UUID, string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &UnsubscribeClaims{}, t.Keys.Keyfunc,
+		jwt.WithValidMethods(t.Keys.Algorithms()),
+		jwt.WithAudience(unsubscribeTokenAudience),
+	)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	claims, ok := token.Claims.(*UnsubscribeClaims)
+	if !ok || !token.Valid || claims.Category == "" {
+		return uuid.Nil, "", errors.New("invalid token claims")
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, "", errors.New("invalid token claims")
+	}
+
+	return userID, claims.Category, nil
+}
+
 // ExtractToken extracts the Bearer token string from the "Authorization" header
 func (t *TokenService) ExtractToken(c *gin.Context) string {
 	authHeader := c.GetHeader("Authorization")
@@ -73,34 +205,23 @@ func (t *TokenService) ExtractToken(c *gin.Context) string {
 
 // ValidateToken validates the JWT token string and returns error if invalid
 func (t *TokenService) ValidateToken(tokenString string) error {
-	secret := []byte(t.Config.AuthSecret)
-
 	// Parse token without claims extraction first, just for validation
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Ensure token method is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return secret, nil
-	})
+	token, err := t.parse(tokenString)
 
-	expirationTime, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return err
+	}
 
-	fmt.Println(expirationTime)
+	if _, err := token.Claims.GetExpirationTime(); err != nil {
+		return err
+	}
 
-	return err
+	return nil
 }
 
 // ExtractTokenData parses the token string and extracts AuthTokenData from claims
 func (t *TokenService) ExtractTokenData(tokenString string) (AuthTokenData, error) {
-	secret := []byte(t.Config.AuthSecret)
-
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return secret, nil
-	})
+	token, err := t.parse(tokenString)
 	if err != nil {
 		return AuthTokenData{}, err
 	}
@@ -110,6 +231,11 @@ func (t *TokenService) ExtractTokenData(tokenString string) (AuthTokenData, erro
 		return AuthTokenData{}, errors.New("invalid token claims")
 	}
 
+	// access tokens have no audience; anything else (e.g. a 2FA challenge) can't be used as one
+	if len(claims.Audience) > 0 {
+		return AuthTokenData{}, errors.New("not an access token")
+	}
+
 	// Ensure ID is valid UUID
 	if claims.AuthTokenData.ID == uuid.Nil {
 		return AuthTokenData{}, errors.New("token missing user ID")
@@ -131,3 +257,20 @@ func (t *TokenService) ParseToken(c *gin.Context) (AuthTokenData, error) {
 
 	return t.ExtractTokenData(tokenString)
 }
+
+// GenerateOpaqueToken creates a random URL-safe token and its SHA-256 hash for storage
+func GenerateOpaqueToken() (string, string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 hash under which an opaque token is stored
+func HashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("%x", hash)
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+)
+
+const (
+	// issuer shown in authenticator apps
+	totpIssuer        = "Pollex"
+	recoveryCodeCount = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService manages TOTP enrolment, verification and recovery codes
+type TwoFactorService struct {
+	Queries      *repository.Queries
+	AuditService *AuditService
+}
+
+func NewTwoFactorService(queries *repository.Queries) *TwoFactorService {
+	return &TwoFactorService{
+		Queries:      queries,
+		AuditService: NewAuditService(queries),
+	}
+}
+
+// TwoFactorStatus describes a user's 2FA state
+type TwoFactorStatus struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment is returned when enrolment starts
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// IsTwoFactorRequired reports whether the role must use 2FA. Every role above a regular
+// user (moderators, admins and custom roles) can act on other users' data.
+func IsTwoFactorRequired(role string) bool {
+	return role != RoleUser
+}
+
+// IsEnabled reports whether the user has confirmed a TOTP enrolment
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	totp, err := s.Queries.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return totp.EnabledAt.Valid, nil
+}
+
+// Status returns the user's 2FA state
+func (s *TwoFactorService) Status(ctx context.Context, userID uuid.UUID) (TwoFactorStatus, error) {
+	user, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return TwoFactorStatus{}, util.ErrUserNotFound
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+
+	status := TwoFactorStatus{
+		Enabled:  enabled,
+		Required: IsTwoFactorRequired(user.Role),
+	}
+	if enabled {
+		status.RecoveryCodesLeft, err = s.Queries.CountUnusedRecoveryCodes(ctx, userID)
+		if err != nil {
+			return TwoFactorStatus{}, err
+		}
+	}
+	return status, nil
+}
+
+// BeginEnrollment creates a new (pending) secret for the user.
+// Starting over replaces any earlier pending secret.
+func (s *TwoFactorService) BeginEnrollment(ctx context.Context, userID uuid.UUID) (TwoFactorEnrollment, error) {
+	user, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return TwoFactorEnrollment{}, util.ErrUserNotFound
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	if enabled {
+		return TwoFactorEnrollment{}, util.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	if _, err := s.Queries.UpsertUserTOTP(ctx, repository.UpsertUserTOTPParams{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	return TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(secret, totpIssuer, user.Email),
+	}, nil
+}
+
+// ConfirmEnrollment enables 2FA once the user proves their authenticator works
+// and returns the recovery codes, which are only shown this once
+func (s *TwoFactorService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	totp, err := s.Queries.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, util.ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if totp.EnabledAt.Valid {
+		return nil, util.ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, totp, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.Queries.EnableUserTOTP(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AuditService.LogUserAction(ctx, userID, AuditActionUserTwoFactorEnable, userID); err != nil {
+		util.Logger(ctx).Error("failed to log 2fa enable", "error", err)
+	}
+
+	return codes, nil
+}
+
+// Verify checks a TOTP code or, failing that, consumes a recovery code
+func (s *TwoFactorService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	totp, err := s.Queries.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return util.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !totp.EnabledAt.Valid {
+		return util.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, totp, code); err == nil || !errors.Is(err, util.ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	used, err := s.Queries.UseRecoveryCode(ctx, repository.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: HashOpaqueToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return util.ErrInvalidTwoFactorCode
+	}
+
+	util.Logger(ctx).Info("recovery code used", "user_id", userID.String())
+	return nil
+}
+
+// verifyTOTP validates a code and records its time step so it can't be replayed
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, totp repository.UserTotp, code string) error {
+	step, ok := util.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok || step <= totp.LastUsedStep {
+		return util.ErrInvalidTwoFactorCode
+	}
+
+	updated, err := s.Queries.UpdateUserTOTPLastUsedStep(ctx, repository.UpdateUserTOTPLastUsedStepParams{
+		UserID:       totp.UserID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		// a concurrent request used this (or a later) code first
+		return util.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Disable turns 2FA off after checking a code. Roles that require 2FA can't disable it.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.Queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return util.ErrUserNotFound
+	}
+	if IsTwoFactorRequired(user.Role) {
+		return util.ErrTwoFactorRequired
+	}
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.Queries.DeleteUserTOTP(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.Queries.DeleteRecoveryCodesByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.AuditService.LogUserAction(ctx, userID, AuditActionUserTwoFactorDisable, userID); err != nil {
+		util.Logger(ctx).Error("failed to log 2fa disable", "error", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the old recovery codes and returns a fresh set
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	params := make([]repository.InsertRecoveryCodesParams, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		params = append(params, repository.InsertRecoveryCodesParams{
+			UserID:   userID,
+			CodeHash: HashOpaqueToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.Queries.DeleteRecoveryCodesByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.Queries.InsertRecoveryCodes(ctx, params); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code like "k3jd8-2hf9a" (50 bits of entropy)
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and separator insensitive
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
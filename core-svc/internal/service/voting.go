@@ -1,15 +1,17 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
 
-	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
 	"github.com/yatochka-dev/pollex/core-svc/internal/pubsub"
+	"github.com/yatochka-dev/pollex/core-svc/internal/telemetry"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type VotingService struct {
@@ -24,7 +26,12 @@ func NewVotingService(queries *repository.Queries, broker *pubsub.Broker) *Votin
 	}
 }
 
-func (s *VotingService) Vote(c *gin.Context, optionId uuid.UUID, userId uuid.UUID) error {
+// Vote records the user's vote. notifyResults, when set, turns the results email for the
+// poll on or off; nil leaves it as it was.
+func (s *VotingService) Vote(c context.Context, optionId uuid.UUID, userId uuid.UUID, notifyResults *bool) (err error) {
+	c, span := telemetry.StartSpan(c, "VotingService.Vote", attribute.String("option.id", optionId.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	// Check if user's email is verified
 	user, err := s.Queries.GetUserByID(c, userId)
 	if err != nil {
@@ -35,10 +42,17 @@ func (s *VotingService) Vote(c *gin.Context, optionId uuid.UUID, userId uuid.UUI
 		return errors.New("email verification required. Please verify your email before voting")
 	}
 
-	vote, err := s.Queries.CreateVote(c, repository.CreateVoteParams{UserID: userId, OptionID: optionId})
+	var vote repository.CreateVoteRow
+	err = s.Queries.InTx(c, func(q *repository.Queries) error {
+		vote, err = q.CreateVote(c, repository.CreateVoteParams{UserID: pgtype.UUID{Bytes: userId, Valid: true}, OptionID: optionId})
+		if err != nil {
+			return err
+		}
+		return setResultsSubscription(c, q, vote.PollID, userId, notifyResults)
+	})
 
 	if err != nil {
-		println(err.Error())
+		util.Logger(c).Error("failed to create vote", "option_id", optionId.String(), "error", err)
 		return err
 	}
 
@@ -53,13 +67,34 @@ func (s *VotingService) Vote(c *gin.Context, optionId uuid.UUID, userId uuid.UUI
 	}
 
 	// publish to SSE subscribers
-	log.Printf("pub poll=%s active=%d", vote.PollID.String(), s.Broker.ActiveSubscribers(vote.PollID.String()))
+	span.SetAttributes(attribute.String("poll.id", vote.PollID.String()))
+	_, pubSpan := telemetry.StartSpan(c, "Broker.PublishVoteUpdate",
+		attribute.Int("broker.subscribers", s.Broker.ActiveSubscribers(vote.PollID.String())))
+	util.Logger(c).Debug("publishing vote update",
+		"poll_id", vote.PollID.String(),
+		"subscribers", s.Broker.ActiveSubscribers(vote.PollID.String()))
 	s.Broker.PublishVoteUpdate(p, opts, votesData, &optionId)
+	pubSpan.End()
 
 	return nil
 }
 
-func (s *VotingService) GetPollData(c *gin.Context, pollId uuid.UUID) (repository.Poll, []repository.PollOption, error) {
+// setResultsSubscription subscribes the voter to the poll's results email or unsubscribes them
+func setResultsSubscription(c context.Context, q *repository.Queries, pollId uuid.UUID, userId uuid.UUID, notifyResults *bool) error {
+	switch {
+	case notifyResults == nil:
+		return nil
+	case *notifyResults:
+		return q.SubscribeToPollResults(c, repository.SubscribeToPollResultsParams{PollID: pollId, UserID: userId})
+	default:
+		return q.DeletePollResultSubscription(c, repository.DeletePollResultSubscriptionParams{PollID: pollId, UserID: userId})
+	}
+}
+
+func (s *VotingService) GetPollData(c context.Context, pollId uuid.UUID) (_ repository.Poll, _ []repository.PollOption, err error) {
+	c, span := telemetry.StartSpan(c, "VotingService.GetPollData", attribute.String("poll.id", pollId.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	p, err := s.Queries.GetPollByID(c, pollId)
 	if err != nil {
 		return repository.Poll{}, nil, err
@@ -73,7 +108,10 @@ func (s *VotingService) GetPollData(c *gin.Context, pollId uuid.UUID) (repositor
 	return p, opts, nil
 }
 
-func (s *VotingService) GetVoteUpdate(c *gin.Context, pollId uuid.UUID) (pubsub.VoteUpdate, error) {
+func (s *VotingService) GetVoteUpdate(c context.Context, pollId uuid.UUID) (_ pubsub.VoteUpdate, err error) {
+	c, span := telemetry.StartSpan(c, "VotingService.GetVoteUpdate", attribute.String("poll.id", pollId.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	poll, options, err := s.GetPollData(c, pollId)
 	if err != nil {
 		return pubsub.VoteUpdate{}, err
@@ -92,10 +130,13 @@ func (s *VotingService) GetVoteUpdate(c *gin.Context, pollId uuid.UUID) (pubsub.
 	}, nil
 }
 
-func (s *VotingService) GetVotes(c *gin.Context, pollId uuid.UUID) (util.PollVotes, error) {
+func (s *VotingService) GetVotes(c context.Context, pollId uuid.UUID) (_ util.PollVotes, err error) {
+	c, span := telemetry.StartSpan(c, "VotingService.GetVotes", attribute.String("poll.id", pollId.String()))
+	defer telemetry.EndSpan(span, &err)
+
 	votes, err := s.Queries.ListVotesByPollId(c, pollId)
 	if err != nil {
-		println(err.Error())
+		util.Logger(c).Error("failed to list votes", "poll_id", pollId.String(), "error", err)
 		return util.PollVotes{}, err
 	}
 
@@ -110,15 +151,18 @@ func (s *VotingService) GetVotes(c *gin.Context, pollId uuid.UUID) (util.PollVot
 }
 
 // GetVoteForUser gets user's vote for a poll
-func (s *VotingService) GetVoteForUser(c *gin.Context, poll_id uuid.UUID, userId uuid.UUID) (uuid.UUID, error) {
-	optionId, err := s.Queries.GetUserOptionIdByPollId(c, repository.GetUserOptionIdByPollIdParams{PollID: poll_id, UserID: userId})
+func (s *VotingService) GetVoteForUser(c context.Context, poll_id uuid.UUID, userId uuid.UUID) (_ uuid.UUID, err error) {
+	c, span := telemetry.StartSpan(c, "VotingService.GetVoteForUser", attribute.String("poll.id", poll_id.String()))
+	defer telemetry.EndSpan(span, &err)
+
+	optionId, err := s.Queries.GetUserOptionIdByPollId(c, repository.GetUserOptionIdByPollIdParams{PollID: poll_id, UserID: pgtype.UUID{Bytes: userId, Valid: true}})
 	if err != nil {
 		// no rows = hasn't voted yet
 		if errors.Is(err, pgx.ErrNoRows) {
 			return uuid.Nil, nil
 		}
 		// actual error
-		log.Printf("Failed to fetch vote for user %s on poll %s: %v", userId, poll_id, err)
+		util.Logger(c).Error("failed to fetch vote for user", "poll_id", poll_id.String(), "error", err)
 		return uuid.Nil, err
 	}
 
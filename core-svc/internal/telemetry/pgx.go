@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// max length of the SQL text recorded on a span
+const maxQueryTextLength = 2048
+
+// sqlc prefixes every query with "-- name: GetUserByID :one"
+var sqlcNameRe = regexp.MustCompile(`^--\s*name:\s*(\w+)`)
+
+// QueryTracer is a pgx tracer that creates a span per query, named after the sqlc query
+// (e.g. "db GetUserByID") so slow requests can be attributed to a specific query.
+type QueryTracer struct{}
+
+// NewQueryTracer creates a pgx query tracer
+func NewQueryTracer() *QueryTracer {
+	return &QueryTracer{}
+}
+
+var (
+	_ pgx.QueryTracer    = (*QueryTracer)(nil)
+	_ pgx.CopyFromTracer = (*QueryTracer)(nil)
+)
+
+// queryName returns the sqlc query name, or the leading SQL keyword for ad-hoc statements
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if m := sqlcNameRe.FindStringSubmatch(sql); m != nil {
+		return m[1]
+	}
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "query"
+}
+
+func truncateQuery(sql string) string {
+	if len(sql) > maxQueryTextLength {
+		return sql[:maxQueryTextLength]
+	}
+	return sql
+}
+
+// TraceQueryStart starts a client span for the query
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, _ = Tracer().Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(truncateQuery(data.SQL)),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd ends the query span, recording errors and affected rows
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && data.Err != pgx.ErrNoRows {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	span.End()
+}
+
+// TraceCopyFromStart starts a span for a COPY FROM
+func (t *QueryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := data.TableName.Sanitize()
+	ctx, _ = Tracer().Start(ctx, "db COPY "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName("COPY"),
+			semconv.DBCollectionName(table),
+		),
+	)
+	return ctx
+}
+
+// TraceCopyFromEnd ends the COPY FROM span
+func (t *QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	span.End()
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation scope used for all Pollex spans
+const tracerName = "github.com/yatochka-dev/pollex/core-svc"
+
+// Tracer returns the Pollex tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartSpan starts a span named after the operation, e.g. "VotingService.Vote"
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records *errp on the span (if any) and ends it. Meant to be deferred with a named error result.
+func EndSpan(span trace.Span, errp *error) {
+	if errp != nil && *errp != nil {
+		span.RecordError(*errp)
+		span.SetStatus(codes.Error, (*errp).Error())
+	}
+	span.End()
+}
+
+// Setup installs the global tracer provider and propagator.
+// Spans are exported over OTLP/HTTP (configured through the standard OTEL_EXPORTER_OTLP_* variables)
+// when tracing is enabled; otherwise the provider samples nothing and exports nowhere.
+// The returned function flushes and shuts the provider down.
+func Setup(ctx context.Context, config *util.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.OtelServiceName),
+		semconv.DeploymentEnvironmentName(config.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.TracingEnabled {
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.NeverSample()),
+		)
+		otel.SetTracerProvider(tp)
+		return tp.Shutdown, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.OtelSampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	slog.Info("tracing enabled", "service", config.OtelServiceName, "sample_ratio", config.OtelSampleRatio)
+
+	return tp.Shutdown, nil
+}
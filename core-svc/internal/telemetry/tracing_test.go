@@ -0,0 +1,170 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newInMemoryProvider installs a synchronous tracer provider backed by an in-memory exporter,
+// so the spans a request produced can be asserted on
+func newInMemoryProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return exporter
+}
+
+// runQuery drives the tracer the way pgx does around a query
+func runQuery(ctx context.Context, tracer *QueryTracer, sql string, tag pgconn.CommandTag, err error) {
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: tag, Err: err})
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	t.Fatalf("no span named %q in %v", name, names)
+	return tracetest.SpanStub{}
+}
+
+func attrValue(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestRequestSpansNestServiceAndQuerySpans(t *testing.T) {
+	exporter := newInMemoryProvider(t)
+	tracer := NewQueryTracer()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(otelgin.Middleware("pollex-test"))
+	r.GET("/polls/:id", func(c *gin.Context) {
+		var err error
+		ctx, span := StartSpan(c.Request.Context(), "PollService.GetPoll", attribute.String("poll.id", c.Param("id")))
+		runQuery(ctx, tracer, "-- name: GetPollByID :one\nSELECT id FROM poll WHERE id = $1", pgconn.NewCommandTag("SELECT 1"), nil)
+		runQuery(ctx, tracer, "-- name: ListPollOptions :many\nSELECT id FROM poll_option WHERE poll_id = $1", pgconn.NewCommandTag("SELECT 0"), pgx.ErrNoRows)
+		EndSpan(span, &err)
+		c.Status(http.StatusOK)
+	})
+
+	// the caller's trace context is continued
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/polls/42", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 4", len(spans))
+	}
+
+	server := spanByName(t, spans, "GET /polls/:id")
+	service := spanByName(t, spans, "PollService.GetPoll")
+	getPoll := spanByName(t, spans, "db GetPollByID")
+	listOptions := spanByName(t, spans, "db ListPollOptions")
+
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v, want server", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the one from traceparent", got)
+	}
+	if got := server.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("server span parent = %s, want the remote span", got)
+	}
+	if v, ok := attrValue(server, "http.route"); !ok || v.AsString() != "/polls/:id" {
+		t.Errorf("http.route = %v, want /polls/:id", v.Emit())
+	}
+
+	if service.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("service span is not a child of the server span")
+	}
+	if v, _ := attrValue(service, "poll.id"); v.AsString() != "42" {
+		t.Errorf("poll.id = %q, want 42", v.AsString())
+	}
+
+	for _, query := range []tracetest.SpanStub{getPoll, listOptions} {
+		if query.Parent.SpanID() != service.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of the service span", query.Name)
+		}
+		if query.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("%s is in a different trace", query.Name)
+		}
+		if query.SpanKind != trace.SpanKindClient {
+			t.Errorf("%s kind = %v, want client", query.Name, query.SpanKind)
+		}
+		if v, _ := attrValue(query, "db.system.name"); v.AsString() != "postgresql" {
+			t.Errorf("%s db.system.name = %q, want postgresql", query.Name, v.AsString())
+		}
+		// a query that finds no rows is not an error
+		if query.Status.Code == codes.Error {
+			t.Errorf("%s status = error, want unset", query.Name)
+		}
+	}
+
+	if v, _ := attrValue(getPoll, "db.operation.name"); v.AsString() != "GetPollByID" {
+		t.Errorf("db.operation.name = %q, want GetPollByID", v.AsString())
+	}
+	if v, _ := attrValue(getPoll, "db.rows_affected"); v.AsInt64() != 1 {
+		t.Errorf("db.rows_affected = %d, want 1", v.AsInt64())
+	}
+}
+
+func TestQueryErrorsAreRecordedOnTheQuerySpan(t *testing.T) {
+	exporter := newInMemoryProvider(t)
+
+	ctx, parent := StartSpan(context.Background(), "VotingService.Vote")
+	runQuery(ctx, NewQueryTracer(), "INSERT INTO votes (poll_id) VALUES ($1)", pgconn.CommandTag{}, errors.New("duplicate key"))
+	parent.End()
+
+	query := spanByName(t, exporter.GetSpans(), "db INSERT")
+	if query.Status.Code != codes.Error || query.Status.Description != "duplicate key" {
+		t.Errorf("status = %+v, want error with the query error", query.Status)
+	}
+	if len(query.Events) != 1 || query.Events[0].Name != "exception" {
+		t.Errorf("events = %v, want the recorded error", query.Events)
+	}
+	if query.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("query span is not a child of the calling span")
+	}
+}
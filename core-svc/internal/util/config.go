@@ -1,34 +1,110 @@
 package util
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	env "github.com/joho/godotenv"
 )
 
 type Config struct {
-	DatabaseUrl            string
-	AuthTokenLifespanHours int64
-	AuthSecret             string
-	AllowedOrigins         []string
-	CookieDomain           string
-	CookieSecure           bool
-	ResendAPIKey           string
-	AppBaseURL             string
-	Port                   int16
+	Environment                string
+	LogLevel                   string
+	DatabaseUrl                string
+	AccessTokenLifespanMinutes int64
+	RefreshTokenLifespanHours  int64
+	AuthSecret                 string
+	AuthSigningKeyFiles        []string
+	AllowedOrigins             []string
+	TrustedProxies             []string
+	CookieDomain               string
+	CookieSecure               bool
+	CookieSameSite             http.SameSite
+	ResendAPIKey               string
+	MailDriver                 string
+	MailFileDir                string
+	SMTP                       SMTPConfig
+	Email                      EmailConfig
+	AppBaseURL                 string
+	APIBaseURL                 string
+	OIDCProviders              []OIDCProviderConfig
+	Port                       int16
+	RateLimitEnabled           bool
+	RateLimitStore             string
+	PasswordHashParams         Argon2Params
+	PasswordPolicy             PasswordPolicy
+	TracingEnabled             bool
+	OtelServiceName            string
+	OtelSampleRatio            float64
+}
+
+// OIDCProviderConfig configures one OpenID Connect login provider
+type OIDCProviderConfig struct {
+	// Name is used in URLs (/auth/oidc/:provider/login) and stored with linked identities
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// EmailConfig is the sender identity and branding of outgoing email
+type EmailConfig struct {
+	// From is the sender, an RFC 5322 address such as "Pollex <no-reply@example.com>"
+	From string
+	// ReplyTo is where replies go; without it emails ask not to be replied to
+	ReplyTo     string
+	ProductName string
+	// BrandColor is the button and link colour, #rgb or #rrggbb
+	BrandColor string
+	// LogoURL is an image shown above the content, omitted when empty
+	LogoURL string
+}
+
+// SMTP connection security
+const (
+	// SMTPTLSStartTLS upgrades a plain connection (usually port 587)
+	SMTPTLSStartTLS = "starttls"
+	// SMTPTLSImplicit connects over TLS from the start (usually port 465)
+	SMTPTLSImplicit = "tls"
+	// SMTPTLSNone sends in the clear, only for relays on a trusted network
+	SMTPTLSNone = "none"
+)
+
+// SMTPConfig configures the SMTP mail driver
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
 }
 
 func LoadEnvironment() {
 	err := env.Load(".env")
 	if err != nil {
-		log.Println("Unable to load the core environment file")
+		slog.Warn("unable to load the core environment file", "error", err)
 	}
 
 }
 
 func NewConfig() *Config {
+	// APP_ENV: "production" switches on JSON logging, anything else is treated as development
+	environment := os.Getenv("APP_ENV")
+	if environment == "" {
+		environment = "development"
+	}
+
 	// Cookie security: use "true" for production, "false" for local development
 	cookieSecure := os.Getenv("COOKIE_SECURE") == "true"
 
@@ -37,9 +113,24 @@ func NewConfig() *Config {
 		cookieDomain = "localhost" // fallback for dev
 	}
 
-	appBaseURL := os.Getenv("APP_BASE_URL")
+	// COOKIE_SAMESITE: "lax" (default), "strict" or "none"; browsers drop SameSite=None
+	// cookies that aren't Secure, so "none" needs COOKIE_SECURE=true
+	cookieSameSite := http.SameSiteLaxMode
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "strict":
+		cookieSameSite = http.SameSiteStrictMode
+	case "none":
+		if cookieSecure {
+			cookieSameSite = http.SameSiteNoneMode
+		} else {
+			slog.Warn("COOKIE_SAMESITE=none requires COOKIE_SECURE=true, using lax")
+		}
+	}
+
+	// public URL of the web app, used for links in emails and redirects after OAuth
+	appBaseURL := strings.TrimSuffix(os.Getenv("APP_BASE_URL"), "/")
 	if appBaseURL == "" {
-		appBaseURL = "http://localhost:8080" // fallback for dev
+		appBaseURL = "http://localhost:3000" // fallback for dev
 	}
 
 	// PORT - parse to int16, default 8080
@@ -50,16 +141,35 @@ func NewConfig() *Config {
 		}
 	}
 
-	// AUTH SECRET
-	authSecret := os.Getenv("AUTH_SECRET")
-	if authSecret == "" {
-		authSecret = "hello"
+	// public URL of this API, used for OAuth redirect URIs
+	apiBaseURL := strings.TrimSuffix(os.Getenv("API_BASE_URL"), "/")
+	if apiBaseURL == "" {
+		apiBaseURL = fmt.Sprintf("http://localhost:%d", port) // fallback for dev
 	}
 
-	// AUTH TOKEN LIFESPAN (hours) - parse to int64, default 24
-	authLifespan := int64(24)
-	if s := os.Getenv("AUTH_TOKEN_LIFESPAN_HOURS"); s != "" {
-		authLifespan, _ = strconv.ParseInt(s, 10, 64)
+	// TOKEN SIGNING - AUTH_SIGNING_KEYS is a comma separated list of PEM key files, the first
+	// one signs; AUTH_SECRET is the HS256 key (verification only in production). See SetupSigningKeys.
+	var authSigningKeyFiles []string
+	for _, path := range strings.Split(os.Getenv("AUTH_SIGNING_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			authSigningKeyFiles = append(authSigningKeyFiles, path)
+		}
+	}
+
+	// ACCESS TOKEN LIFESPAN (minutes) - short lived, renewed through the refresh token, default 15
+	accessLifespan := int64(15)
+	if s := os.Getenv("AUTH_ACCESS_TOKEN_LIFESPAN_MINUTES"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil && v > 0 {
+			accessLifespan = v
+		}
+	}
+
+	// REFRESH TOKEN LIFESPAN (hours) - default 30 days
+	refreshLifespan := int64(720)
+	if s := os.Getenv("AUTH_REFRESH_TOKEN_LIFESPAN_HOURS"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil && v > 0 {
+			refreshLifespan = v
+		}
 	}
 
 	// ALLOWED ORIGINS - comma separated env var
@@ -114,15 +224,269 @@ func NewConfig() *Config {
 		allowedOrigins = defaultOrigins
 	}
 
+	// TRUSTED PROXIES - comma separated IPs or CIDRs of the reverse proxies in front of the API.
+	// Only these may set the client IP through X-Forwarded-For / X-Real-IP; by default none are
+	// trusted and the client IP is the address of the connection
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	// RATE LIMITING - on unless RATE_LIMIT_ENABLED=false; RATE_LIMIT_STORE is "memory"
+	// (per instance, default) or "postgres" (shared between replicas)
+	rateLimitEnabled := os.Getenv("RATE_LIMIT_ENABLED") != "false"
+	rateLimitStore := os.Getenv("RATE_LIMIT_STORE")
+	if rateLimitStore != "postgres" {
+		rateLimitStore = "memory"
+	}
+
+	// PASSWORD HASHING - argon2id cost; raising it rehashes passwords as users log in
+	passwordHashParams := DefaultArgon2Params
+	if s := os.Getenv("PASSWORD_ARGON2_MEMORY_KIB"); s != "" {
+		if v, err := strconv.ParseUint(s, 10, 32); err == nil && v >= 8*1024 {
+			passwordHashParams.Memory = uint32(v)
+		}
+	}
+	if s := os.Getenv("PASSWORD_ARGON2_ITERATIONS"); s != "" {
+		if v, err := strconv.ParseUint(s, 10, 32); err == nil && v >= 1 {
+			passwordHashParams.Iterations = uint32(v)
+		}
+	}
+	if s := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); s != "" {
+		if v, err := strconv.ParseUint(s, 10, 8); err == nil && v >= 1 {
+			passwordHashParams.Parallelism = uint8(v)
+		}
+	}
+
+	// PASSWORD POLICY
+	passwordPolicy := DefaultPasswordPolicy
+	if s := os.Getenv("PASSWORD_MIN_LENGTH"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v >= 1 {
+			passwordPolicy.MinLength = v
+		}
+	}
+	if s := os.Getenv("PASSWORD_MAX_LENGTH"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v >= passwordPolicy.MinLength {
+			passwordPolicy.MaxLength = v
+		}
+	}
+	passwordPolicy.RejectPersonalInfo = os.Getenv("PASSWORD_REJECT_PERSONAL_INFO") != "false"
+	passwordPolicy.CheckBreached = os.Getenv("PASSWORD_BREACH_CHECK") != "false"
+	passwordPolicy.BreachedListPath = os.Getenv("PASSWORD_BREACHED_LIST_PATH")
+
+	// MAIL - MAIL_DRIVER is "resend", "smtp", "file" or "memory". Without it, Resend is used when
+	// an API key is set (and always in production), otherwise mail is written to files
+	resendAPIKey := os.Getenv("RESEND_API_KEY")
+	mailDriver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	if mailDriver == "" {
+		if resendAPIKey != "" || environment == "production" {
+			mailDriver = "resend"
+		} else {
+			mailDriver = "file"
+		}
+	}
+
+	mailFileDir := os.Getenv("MAIL_FILE_DIR")
+	if mailFileDir == "" {
+		mailFileDir = "tmp/mail"
+	}
+
+	smtpConfig := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     587,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		TLS:      SMTPTLSStartTLS,
+	}
+	switch tlsMode := strings.ToLower(os.Getenv("SMTP_TLS")); tlsMode {
+	case SMTPTLSImplicit, SMTPTLSNone:
+		smtpConfig.TLS = tlsMode
+		if tlsMode == SMTPTLSImplicit {
+			smtpConfig.Port = 465
+		}
+	}
+	if s := os.Getenv("SMTP_PORT"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 && v <= 65535 {
+			smtpConfig.Port = v
+		}
+	}
+
+	// EMAIL IDENTITY - sender, reply-to and branding of every email
+	emailConfig := EmailConfig{
+		From:        strings.TrimSpace(os.Getenv("MAIL_FROM")),
+		ReplyTo:     strings.TrimSpace(os.Getenv("MAIL_REPLY_TO")),
+		ProductName: strings.TrimSpace(os.Getenv("MAIL_PRODUCT_NAME")),
+		BrandColor:  strings.TrimSpace(os.Getenv("MAIL_BRAND_COLOR")),
+		LogoURL:     strings.TrimSpace(os.Getenv("MAIL_LOGO_URL")),
+	}
+	if emailConfig.ProductName == "" {
+		emailConfig.ProductName = "Pollex"
+	}
+	if emailConfig.From == "" {
+		emailConfig.From = emailConfig.ProductName + " <no-reply@yatochka.dev>"
+	}
+	if emailConfig.BrandColor == "" {
+		emailConfig.BrandColor = "#4F46E5"
+	}
+
+	// TRACING - enabled explicitly or by configuring an OTLP endpoint
+	tracingEnabled := os.Getenv("OTEL_TRACING_ENABLED") == "true" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+
+	otelServiceName := os.Getenv("OTEL_SERVICE_NAME")
+	if otelServiceName == "" {
+		otelServiceName = "pollex-core"
+	}
+
+	otelSampleRatio := 1.0
+	if s := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); s != "" {
+		if r, err := strconv.ParseFloat(s, 64); err == nil && r >= 0 && r <= 1 {
+			otelSampleRatio = r
+		}
+	}
+
 	return &Config{
-		DatabaseUrl:            os.Getenv("DATABASE_URL"),
-		AuthTokenLifespanHours: authLifespan,
-		AuthSecret:             authSecret,
-		AllowedOrigins:         allowedOrigins,
-		CookieDomain:           cookieDomain,
-		CookieSecure:           cookieSecure,
-		ResendAPIKey:           os.Getenv("RESEND_API_KEY"),
-		AppBaseURL:             appBaseURL,
-		Port:                   port,
+		Environment:                environment,
+		LogLevel:                   os.Getenv("LOG_LEVEL"),
+		DatabaseUrl:                os.Getenv("DATABASE_URL"),
+		AccessTokenLifespanMinutes: accessLifespan,
+		RefreshTokenLifespanHours:  refreshLifespan,
+		AuthSecret:                 os.Getenv("AUTH_SECRET"),
+		AuthSigningKeyFiles:        authSigningKeyFiles,
+		AllowedOrigins:             allowedOrigins,
+		TrustedProxies:             trustedProxies,
+		CookieDomain:               cookieDomain,
+		CookieSecure:               cookieSecure,
+		CookieSameSite:             cookieSameSite,
+		ResendAPIKey:               resendAPIKey,
+		MailDriver:                 mailDriver,
+		MailFileDir:                mailFileDir,
+		SMTP:                       smtpConfig,
+		Email:                      emailConfig,
+		AppBaseURL:                 appBaseURL,
+		APIBaseURL:                 apiBaseURL,
+		OIDCProviders:              loadOIDCProviders(),
+		Port:                       port,
+		RateLimitEnabled:           rateLimitEnabled,
+		RateLimitStore:             rateLimitStore,
+		PasswordHashParams:         passwordHashParams,
+		PasswordPolicy:             passwordPolicy,
+		TracingEnabled:             tracingEnabled,
+		OtelServiceName:            otelServiceName,
+		OtelSampleRatio:            otelSampleRatio,
+	}
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS (comma separated names) and, for each name,
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and optionally
+// OIDC_<NAME>_SCOPES and OIDC_<NAME>_DISPLAY_NAME. Incomplete providers are skipped.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  os.Getenv(prefix + "DISPLAY_NAME"),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " ")),
+		}
+
+		if provider.Issuer == "" || provider.ClientID == "" {
+			continue
+		}
+		if provider.DisplayName == "" {
+			provider.DisplayName = name
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// hex colour usable in email CSS
+var brandColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Validate checks the settings that are only used later (links and sender in emails), so a
+// misconfigured deployment fails at startup instead of sending broken mail
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validatePublicURL(c.AppBaseURL, c.IsProduction()); err != nil {
+		errs = append(errs, fmt.Errorf("APP_BASE_URL: %w", err))
+	}
+	// the API URL is the token issuer and is sent in OAuth redirect URIs and one-click
+	// List-Unsubscribe headers, which RFC 8058 requires to be https
+	if err := validatePublicURL(c.APIBaseURL, c.IsProduction()); err != nil {
+		errs = append(errs, fmt.Errorf("API_BASE_URL: %w", err))
+	}
+
+	if _, err := mail.ParseAddress(c.Email.From); err != nil {
+		errs = append(errs, fmt.Errorf("MAIL_FROM: %w", err))
+	}
+	if c.Email.ReplyTo != "" {
+		if _, err := mail.ParseAddress(c.Email.ReplyTo); err != nil {
+			errs = append(errs, fmt.Errorf("MAIL_REPLY_TO: %w", err))
+		}
 	}
+	if strings.ContainsAny(c.Email.ProductName, "\r\n") {
+		errs = append(errs, errors.New("MAIL_PRODUCT_NAME must be a single line"))
+	}
+	if !brandColorPattern.MatchString(c.Email.BrandColor) {
+		errs = append(errs, errors.New("MAIL_BRAND_COLOR must be a hex colour like #4F46E5"))
+	}
+	if c.Email.LogoURL != "" {
+		if err := validatePublicURL(c.Email.LogoURL, c.IsProduction()); err != nil {
+			errs = append(errs, fmt.Errorf("MAIL_LOGO_URL: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePublicURL requires an absolute http(s) URL, https when requireHTTPS is set
+func validatePublicURL(raw string, requireHTTPS bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q must not have a query or fragment", raw)
+	}
+	if requireHTTPS && u.Scheme != "https" {
+		return fmt.Errorf("%q must use https in production", raw)
+	}
+	return nil
+}
+
+// AccessTokenTTL is how long an access token (the session cookie) stays valid
+func (c *Config) AccessTokenTTL() time.Duration {
+	return time.Duration(c.AccessTokenLifespanMinutes) * time.Minute
+}
+
+// RefreshTokenTTL is how long a refresh token stays valid
+func (c *Config) RefreshTokenTTL() time.Duration {
+	return time.Duration(c.RefreshTokenLifespanHours) * time.Hour
+}
+
+// IsProduction reports whether the service runs in production mode
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
 }
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRequiresHTTPSAPIURLInProduction(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		apiBaseURL  string
+		wantErr     bool
+	}{
+		{"production http", "production", "http://api.pollex.test", true},
+		{"production https", "production", "https://api.pollex.test", false},
+		{"development http", "development", "http://localhost:8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Environment: tt.environment,
+				AppBaseURL:  "https://pollex.test",
+				APIBaseURL:  tt.apiBaseURL,
+				Email: EmailConfig{
+					From:       "Pollex <noreply@pollex.test>",
+					BrandColor: "#4F46E5",
+				},
+			}
+			err := config.Validate()
+			if tt.wantErr != (err != nil && strings.Contains(err.Error(), "API_BASE_URL")) {
+				t.Errorf("Validate() = %v, want API_BASE_URL error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -1,30 +1,149 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Password hashes are stored in PHC string format, which records the algorithm and
+// its parameters with every hash:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+//
+// New hashes use argon2id. Legacy bcrypt hashes ($2a$/$2b$/$2y$) still verify and are
+// replaced on the next successful login (see PasswordNeedsRehash).
+
+// Argon2Params are the argon2id cost parameters for new password hashes
+type Argon2Params struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var passwordHashParams = DefaultArgon2Params
+
+var errInvalidPasswordHash = errors.New("invalid password hash format")
+
+// SetupPasswordHashing sets the parameters used for new password hashes
+func SetupPasswordHashing(config *Config) {
+	passwordHashParams = config.PasswordHashParams
+}
+
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	params := passwordHashParams
+
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
 
-	return string(hashedPassword), nil
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
 func VerifyPassword(password string, hashedPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if isBcryptHash(hashedPassword) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
 
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return false, nil
-		}
 		return false, err
 	}
 
-	return true, nil
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+// PasswordNeedsRehash reports whether a stored hash uses a legacy algorithm or
+// different parameters than the current ones
+func PasswordNeedsRehash(hashedPassword string) bool {
+	if isBcryptHash(hashedPassword) {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	current := passwordHashParams
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		uint32(len(salt)) != current.SaltLength ||
+		uint32(len(key)) != current.KeyLength
+}
+
+func isBcryptHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+// decodeArgon2Hash parses a PHC argon2id string
+func decodeArgon2Hash(hashedPassword string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, errInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, errInvalidPasswordHash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, errInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, errInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, errInvalidPasswordHash
+	}
 
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
@@ -1,15 +1,21 @@
 package util
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// ANSI color codes for terminal output
+// ANSI color codes for terminal output (only used by the development handler)
 const (
 	ColorReset   = "\033[0m"
 	ColorRed     = "\033[31m"
@@ -23,10 +29,134 @@ const (
 	ColorBold    = "\033[1m"
 )
 
-// RequestLogger provides standardized debug logging for API endpoints
+// RequestIDHeader is the header used to propagate request IDs between services
+const RequestIDHeader = "X-Request-ID"
+
+type logContextKey string
+
+const (
+	requestIDKey logContextKey = "pollex.request_id"
+	routeKey     logContextKey = "pollex.route"
+	userIDKey    logContextKey = "pollex.user_id"
+	// set while an admin is impersonating the user
+	impersonatorIDKey logContextKey = "pollex.impersonator_id"
+)
+
+// NewLogger builds the application logger: JSON in production, colourised text in development
+func NewLogger(config *Config) *slog.Logger {
+	level := parseLogLevel(config.LogLevel)
+
+	if config.IsProduction() {
+		return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	}
+
+	return slog.New(newPrettyHandler(os.Stdout, level))
+}
+
+// SetupLogger installs the application logger as the slog default
+func SetupLogger(config *Config) *slog.Logger {
+	logger := NewLogger(config)
+	slog.SetDefault(logger)
+	return logger
+}
+
+func parseLogLevel(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID stores the request ID in the context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in the context, or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithRoute stores the matched route in the context
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// WithUserID stores the authenticated user ID in the context
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID stored in the context
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if ctx == nil {
+		return uuid.Nil, false
+	}
+	id, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// WithImpersonatorID stores the admin impersonating the authenticated user in the context
+func WithImpersonatorID(ctx context.Context, impersonatorID uuid.UUID) context.Context {
+	return context.WithValue(ctx, impersonatorIDKey, impersonatorID)
+}
+
+// ImpersonatorIDFromContext returns the admin impersonating the authenticated user, if any
+func ImpersonatorIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if ctx == nil {
+		return uuid.Nil, false
+	}
+	id, ok := ctx.Value(impersonatorIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// Logger returns the default logger enriched with request ID, route, user ID and impersonator
+// from the context
+func Logger(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if ctx == nil {
+		return logger
+	}
+
+	var attrs []any
+	if id, ok := ctx.Value(requestIDKey).(string); ok && id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if route, ok := ctx.Value(routeKey).(string); ok && route != "" {
+		attrs = append(attrs, slog.String("route", route))
+	}
+	if userID, ok := ctx.Value(userIDKey).(uuid.UUID); ok && userID != uuid.Nil {
+		attrs = append(attrs, slog.String("user_id", userID.String()))
+	}
+	if impersonatorID, ok := ctx.Value(impersonatorIDKey).(uuid.UUID); ok && impersonatorID != uuid.Nil {
+		attrs = append(attrs, slog.String("impersonator_id", impersonatorID.String()))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	if len(attrs) == 0 {
+		return logger
+	}
+	return logger.With(attrs...)
+}
+
+// RequestLogger provides standardized logging for API endpoints
 type RequestLogger struct {
 	Method     string
 	Path       string
+	RequestID  string
+	TraceID    string
 	UserID     *uuid.UUID
 	StartTime  time.Time
 	StatusCode int
@@ -34,11 +164,23 @@ type RequestLogger struct {
 
 // NewRequestLogger creates a new request logger
 func NewRequestLogger(c *gin.Context) *RequestLogger {
-	return &RequestLogger{
+	rl := &RequestLogger{
 		Method:    c.Request.Method,
 		Path:      c.FullPath(),
+		RequestID: RequestIDFromContext(c.Request.Context()),
 		StartTime: time.Now(),
 	}
+
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		rl.TraceID = sc.TraceID().String()
+	}
+
+	// pick up the user ID if the auth middleware already ran
+	if userID, ok := c.Request.Context().Value(userIDKey).(uuid.UUID); ok && userID != uuid.Nil {
+		rl.UserID = &userID
+	}
+
+	return rl
 }
 
 // SetUserID sets the authenticated user ID for the request
@@ -46,28 +188,35 @@ func (rl *RequestLogger) SetUserID(userID uuid.UUID) {
 	rl.UserID = &userID
 }
 
-// LogStart logs the beginning of a request with parameters
-func (rl *RequestLogger) LogStart(params ...interface{}) {
-	userInfo := ColorGray + "guest" + ColorReset
+func (rl *RequestLogger) logger() *slog.Logger {
+	attrs := []any{
+		slog.String("method", rl.Method),
+		slog.String("route", rl.Path),
+	}
+	if rl.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", rl.RequestID))
+	}
+	if rl.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", rl.TraceID))
+	}
 	if rl.UserID != nil {
-		userInfo = ColorCyan + fmt.Sprintf("user=%s", rl.UserID.String()[:8]) + ColorReset
+		attrs = append(attrs, slog.String("user_id", rl.UserID.String()))
 	}
+	return slog.Default().With(attrs...)
+}
+
+// Logger returns a logger carrying the request attributes, for handler-specific events
+func (rl *RequestLogger) Logger() *slog.Logger {
+	return rl.logger()
+}
 
-	paramStr := ""
+// LogStart logs the beginning of a request with parameters
+func (rl *RequestLogger) LogStart(params ...interface{}) {
+	var attrs []any
 	if len(params) > 0 {
-		paramStr = ColorGray + fmt.Sprintf(" | params=%v", params) + ColorReset
-	}
-
-	log.Printf("%s[START]%s %s%s %s%s | %s%s",
-		ColorBlue+ColorBold,
-		ColorReset,
-		ColorYellow,
-		rl.Method,
-		ColorReset+ColorGray,
-		rl.Path,
-		userInfo,
-		paramStr,
-	)
+		attrs = append(attrs, slog.Any("params", params))
+	}
+	rl.logger().Debug("request started", attrs...)
 }
 
 // LogEnd logs the completion of a request with status and duration
@@ -75,71 +224,142 @@ func (rl *RequestLogger) LogEnd(statusCode int, additionalInfo ...interface{}) {
 	rl.StatusCode = statusCode
 	duration := time.Since(rl.StartTime)
 
-	userInfo := ColorGray + "guest" + ColorReset
-	if rl.UserID != nil {
-		userInfo = ColorCyan + fmt.Sprintf("user=%s", rl.UserID.String()[:8]) + ColorReset
+	attrs := []any{
+		slog.Int("status", statusCode),
+		slog.Duration("duration", duration),
+	}
+	if len(additionalInfo) > 0 {
+		attrs = append(attrs, slog.Any("info", additionalInfo))
 	}
 
-	// Color status code based on HTTP status
-	statusColor := ColorGreen
+	level := slog.LevelInfo
 	if statusCode >= 400 && statusCode < 500 {
-		statusColor = ColorYellow
+		level = slog.LevelWarn
 	} else if statusCode >= 500 {
-		statusColor = ColorRed
-	}
-
-	// Color duration based on speed
-	durationColor := ColorGreen
-	if duration > 500*time.Millisecond {
-		durationColor = ColorYellow
-	}
-	if duration > 1*time.Second {
-		durationColor = ColorRed
+		level = slog.LevelError
 	}
 
-	infoStr := ""
-	if len(additionalInfo) > 0 {
-		infoStr = ColorGray + fmt.Sprintf(" | info=%v", additionalInfo) + ColorReset
-	}
-
-	log.Printf("%s[END]%s %s%s %s%s | %s | %sstatus=%d%s | %sduration=%v%s%s",
-		ColorGreen+ColorBold,
-		ColorReset,
-		ColorYellow,
-		rl.Method,
-		ColorReset+ColorGray,
-		rl.Path,
-		userInfo,
-		statusColor,
-		statusCode,
-		ColorReset,
-		durationColor,
-		duration,
-		ColorReset,
-		infoStr,
-	)
+	rl.logger().Log(context.Background(), level, "request completed", attrs...)
 }
 
 // LogError logs an error that occurred during request processing
 func (rl *RequestLogger) LogError(err error, context string) {
-	userInfo := ColorGray + "guest" + ColorReset
-	if rl.UserID != nil {
-		userInfo = ColorCyan + fmt.Sprintf("user=%s", rl.UserID.String()[:8]) + ColorReset
-	}
-
-	log.Printf("%s[ERROR]%s %s%s %s%s | %s | %scontext=%s%s | %serror=%v%s",
-		ColorRed+ColorBold,
-		ColorReset,
-		ColorYellow,
-		rl.Method,
-		ColorReset+ColorGray,
-		rl.Path,
-		userInfo,
-		ColorMagenta,
-		context,
-		ColorReset,
-		ColorRed,
-		err,
-		ColorReset,
+	rl.logger().Error("request error",
+		slog.String("context", context),
+		slog.Any("error", err),
 	)
 }
+
+// prettyHandler is a colourised, human readable slog handler for local development
+type prettyHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Leveler
+	attrs  []slog.Attr
+	groups []string
+}
+
+func newPrettyHandler(w io.Writer, level slog.Leveler) *prettyHandler {
+	return &prettyHandler{
+		w:     w,
+		mu:    &sync.Mutex{},
+		level: level,
+	}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	b.WriteString(ColorGray)
+	b.WriteString(r.Time.Format("15:04:05.000"))
+	b.WriteString(ColorReset)
+	b.WriteByte(' ')
+
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString(ColorRed + ColorBold + "ERROR" + ColorReset)
+	case r.Level >= slog.LevelWarn:
+		b.WriteString(ColorYellow + ColorBold + "WARN " + ColorReset)
+	case r.Level >= slog.LevelInfo:
+		b.WriteString(ColorGreen + ColorBold + "INFO " + ColorReset)
+	default:
+		b.WriteString(ColorBlue + ColorBold + "DEBUG" + ColorReset)
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+
+	for _, a := range h.attrs {
+		writePrettyAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writePrettyAttr(&b, prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func writePrettyAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writePrettyAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+
+	color := ColorCyan
+	switch a.Key {
+	case "error":
+		color = ColorRed
+	case "status":
+		color = ColorYellow
+	case "request_id":
+		color = ColorGray
+	}
+
+	fmt.Fprintf(b, " %s%s%s=%s%v%s", ColorGray, prefix+a.Key, ColorReset, color, a.Value, ColorReset)
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+
+	next := *h
+	next.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	next.attrs = append(next.attrs, h.attrs...)
+	for _, a := range attrs {
+		a.Key = prefix + a.Key
+		next.attrs = append(next.attrs, a)
+	}
+	return &next
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.groups = append(append([]string{}, h.groups...), name)
+	return &next
+}
@@ -0,0 +1,187 @@
+package util
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// PasswordPolicy is checked whenever a password is set (registration, reset, admin reset)
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// reject passwords containing the user's name or the local part of their email
+	RejectPersonalInfo bool
+	// reject passwords found in the breached password list
+	CheckBreached bool
+	// BreachedListPath replaces the bundled list; same format as breached_passwords.txt
+	BreachedListPath string
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:          8,
+	MaxLength:          128,
+	RejectPersonalInfo: true,
+	CheckBreached:      true,
+}
+
+var passwordPolicy = DefaultPasswordPolicy
+
+// SetupPasswordPolicy sets the policy used by ValidatePassword
+func SetupPasswordPolicy(config *Config) {
+	passwordPolicy = config.PasswordPolicy
+}
+
+// password policy rules, reported in PasswordPolicyError
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleContainsName = "contains_name"
+	PasswordRuleContainsMail = "contains_email"
+	PasswordRuleBreached     = "breached"
+)
+
+// PasswordViolation is one failed password rule
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password failed; errors.Is matches ErrPasswordPolicy
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+	}
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy.Error(), strings.Join(rules, ", "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error { return ErrPasswordPolicy }
+
+// ValidatePassword checks a new password for the user with the given name and email
+// against the configured policy. It returns a *PasswordPolicyError or nil.
+func ValidatePassword(password, name, email string) error {
+	policy := passwordPolicy
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters", policy.MinLength),
+		})
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d characters", policy.MaxLength),
+		})
+	}
+
+	if policy.RejectPersonalInfo {
+		lowered := strings.ToLower(password)
+
+		for _, part := range strings.Fields(strings.ToLower(name)) {
+			// short name parts ("Al", initials) would reject too many passwords
+			if utf8.RuneCountInString(part) >= 3 && strings.Contains(lowered, part) {
+				violations = append(violations, PasswordViolation{
+					Rule:    PasswordRuleContainsName,
+					Message: "Password must not contain your name",
+				})
+				break
+			}
+		}
+
+		local, _, _ := strings.Cut(strings.ToLower(email), "@")
+		if utf8.RuneCountInString(local) >= 3 && strings.Contains(lowered, local) {
+			violations = append(violations, PasswordViolation{
+				Rule:    PasswordRuleContainsMail,
+				Message: "Password must not contain your email address",
+			})
+		}
+	}
+
+	if policy.CheckBreached && isBreachedPassword(password) {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleBreached,
+			Message: "This password has appeared in a data breach, please choose another one",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+//go:embed breached_passwords.txt
+var bundledBreachedPasswords string
+
+var (
+	breachedOnce   sync.Once
+	breachedRanges map[string]map[string]struct{}
+)
+
+// isBreachedPassword looks the password's SHA-1 up by its 5 character prefix, like the
+// Pwned Passwords range API, so a larger list can be dropped in without changing the lookup
+func isBreachedPassword(password string) bool {
+	breachedOnce.Do(loadBreachedPasswords)
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := breachedRanges[hash[:5]][hash[5:]]
+	return found
+}
+
+func loadBreachedPasswords() {
+	var source io.Reader = strings.NewReader(bundledBreachedPasswords)
+
+	if path := passwordPolicy.BreachedListPath; path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			slog.Error("failed to open breached password list, using the bundled list", "path", path, "error", err)
+		} else {
+			defer file.Close()
+			source = file
+		}
+	}
+
+	breachedRanges = make(map[string]map[string]struct{})
+
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// "HASH" or "HASH:COUNT"
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != 40 {
+			continue
+		}
+
+		suffixes, ok := breachedRanges[hash[:5]]
+		if !ok {
+			suffixes = make(map[string]struct{})
+			breachedRanges[hash[:5]] = suffixes
+		}
+		suffixes[hash[5:]] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("failed to read breached password list", "error", err)
+	}
+}
@@ -0,0 +1,296 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Tokens are signed with the first key in AUTH_SIGNING_KEYS (Ed25519 -> EdDSA, RSA -> RS256).
+// The other keys only verify, so a key can be rotated out while tokens signed with it are
+// still valid. Every key is identified by its RFC 7638 thumbprint, sent as the "kid" header,
+// and the public keys are published at /.well-known/jwks.json.
+//
+// AUTH_SECRET (HS256) can't be published, so other services can't verify tokens signed with
+// it. It only signs in development; production requires a PEM signing key and keeps
+// AUTH_SECRET for verification, so tokens issued before the switch stay valid until they expire.
+
+// minimum AUTH_SECRET length accepted in production (256 bits)
+const minAuthSecretLength = 32
+
+// minimum RSA modulus size for signing keys
+const minRSAKeyBits = 2048
+
+// SigningKey is one key of the key set
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signer is nil for verification-only keys
+	signer    interface{}
+	verifier  interface{}
+	publicJWK map[string]string
+}
+
+// KeySet holds the signing key and the keys accepted for verification
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	// legacy is the HS256 key; tokens without a kid are verified with it
+	legacy *SigningKey
+}
+
+var signingKeys *KeySet
+
+var errNoSigningKey = errors.New("no signing key configured: set AUTH_SIGNING_KEYS to an Ed25519 or RSA private key")
+
+// SetupSigningKeys loads the token signing keys. It fails in production when no PEM signing
+// key is configured; in development it falls back to AUTH_SECRET or a throwaway key.
+func SetupSigningKeys(config *Config) error {
+	keys, err := LoadKeySet(config)
+	if err != nil {
+		return err
+	}
+	signingKeys = keys
+	return nil
+}
+
+// SigningKeys returns the key set loaded by SetupSigningKeys
+func SigningKeys() *KeySet {
+	return signingKeys
+}
+
+// LoadKeySet builds a key set from AUTH_SIGNING_KEYS and AUTH_SECRET
+func LoadKeySet(config *Config) (*KeySet, error) {
+	keys := &KeySet{keys: make(map[string]*SigningKey)}
+
+	for _, path := range config.AuthSigningKeyFiles {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+		}
+		if keys.active == nil {
+			if key.signer == nil {
+				return nil, fmt.Errorf("signing key %s is a public key; the first key must be a private key", path)
+			}
+			keys.active = key
+		}
+		keys.keys[key.ID] = key
+	}
+
+	if config.AuthSecret != "" {
+		if config.IsProduction() && len(config.AuthSecret) < minAuthSecretLength {
+			return nil, fmt.Errorf("AUTH_SECRET must be at least %d bytes in production", minAuthSecretLength)
+		}
+		keys.legacy = newHMACKey([]byte(config.AuthSecret))
+		keys.keys[keys.legacy.ID] = keys.legacy
+		if keys.active == nil && !config.IsProduction() {
+			keys.active = keys.legacy
+		}
+	}
+
+	if config.IsProduction() {
+		if keys.active == nil {
+			return nil, errNoSigningKey
+		}
+		if keys.legacy != nil {
+			slog.Warn("AUTH_SECRET only verifies tokens issued before AUTH_SIGNING_KEYS was set; remove it once they have expired")
+		}
+	}
+
+	if keys.active == nil {
+		key, err := generateEphemeralKey()
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("no signing key configured, using a throwaway key; access tokens won't survive a restart")
+		keys.active = key
+		keys.keys[key.ID] = key
+	}
+
+	return keys, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.signer)
+}
+
+// Keyfunc picks the verification key for a token by its kid header
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	var key *SigningKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = k.keys[kid]
+	} else {
+		// tokens issued before key IDs were introduced
+		key = k.legacy
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifier, nil
+}
+
+// Algorithms lists the algorithms of the keys in the set, for jwt.WithValidMethods
+func (k *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range k.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWKS returns the public keys as a JSON Web Key Set, active key first. HMAC keys are secret
+// and never included.
+func (k *KeySet) JWKS() map[string][]map[string]string {
+	jwks := []map[string]string{}
+	if k.active.publicJWK != nil {
+		jwks = append(jwks, k.active.publicJWK)
+	}
+	for _, key := range k.keys {
+		if key != k.active && key.publicJWK != nil {
+			jwks = append(jwks, key.publicJWK)
+		}
+	}
+	return map[string][]map[string]string{"keys": jwks}
+}
+
+// loadSigningKey reads a PEM encoded Ed25519 or RSA key (private or public)
+func loadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case ed25519.PrivateKey:
+		return newEd25519Key(key.Public().(ed25519.PublicKey), key), nil
+	case ed25519.PublicKey:
+		return newEd25519Key(key, nil), nil
+	case *rsa.PrivateKey:
+		return newRSAKey(&key.PublicKey, key)
+	case *rsa.PublicKey:
+		return newRSAKey(key, nil)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use Ed25519 or RSA", parsed)
+	}
+}
+
+func newEd25519Key(public ed25519.PublicKey, private ed25519.PrivateKey) *SigningKey {
+	x := base64.RawURLEncoding.EncodeToString(public)
+	// RFC 7638 thumbprint members, in lexicographic order
+	id := jwkThumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, x))
+
+	key := &SigningKey{
+		ID:       id,
+		Method:   jwt.SigningMethodEdDSA,
+		verifier: public,
+		publicJWK: map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   x,
+			"kid": id,
+			"alg": jwt.SigningMethodEdDSA.Alg(),
+			"use": "sig",
+		},
+	}
+	if private != nil {
+		key.signer = private
+	}
+	return key
+}
+
+func newRSAKey(public *rsa.PublicKey, private *rsa.PrivateKey) (*SigningKey, error) {
+	if public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+	}
+
+	n := base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	id := jwkThumbprint(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, e, n))
+
+	key := &SigningKey{
+		ID:       id,
+		Method:   jwt.SigningMethodRS256,
+		verifier: public,
+		publicJWK: map[string]string{
+			"kty": "RSA",
+			"n":   n,
+			"e":   e,
+			"kid": id,
+			"alg": jwt.SigningMethodRS256.Alg(),
+			"use": "sig",
+		},
+	}
+	if private != nil {
+		key.signer = private
+	}
+	return key, nil
+}
+
+func newHMACKey(secret []byte) *SigningKey {
+	// the kid must not reveal the secret, so it is derived from a hash of it
+	sum := sha256.Sum256(append([]byte("pollex:hs256:"), secret...))
+	return &SigningKey{
+		ID:       "hs256-" + base64.RawURLEncoding.EncodeToString(sum[:8]),
+		Method:   jwt.SigningMethodHS256,
+		signer:   secret,
+		verifier: secret,
+	}
+}
+
+// generateEphemeralKey creates an Ed25519 key that lives until the process exits
+func generateEphemeralKey() (*SigningKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newEd25519Key(public, private), nil
+}
+
+// jwkThumbprint is the base64url SHA-256 of the canonical JWK members (RFC 7638)
+func jwkThumbprint(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testAuthSecret = "0123456789abcdef0123456789abcdef"
+
+// writeEd25519Key writes a PKCS#8 PEM private key to a temp file and returns its path
+func writeEd25519Key(t *testing.T) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "signing-key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKeySetRequiresSigningKeyInProduction(t *testing.T) {
+	_, err := LoadKeySet(&Config{Environment: "production", AuthSecret: testAuthSecret})
+	if !errors.Is(err, errNoSigningKey) {
+		t.Fatalf("err = %v, want errNoSigningKey", err)
+	}
+}
+
+func TestLoadKeySetKeepsAuthSecretForVerificationInProduction(t *testing.T) {
+	keys, err := LoadKeySet(&Config{
+		Environment:         "production",
+		AuthSecret:          testAuthSecret,
+		AuthSigningKeyFiles: []string{writeEd25519Key(t)},
+	})
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+	signed, err := keys.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg := token.Method.Alg(); alg != jwt.SigningMethodEdDSA.Alg() {
+		t.Errorf("signed with %s, want EdDSA", alg)
+	}
+	if jwks := keys.JWKS()["keys"]; len(jwks) != 1 {
+		t.Errorf("JWKS has %d keys, want the signing key", len(jwks))
+	}
+
+	// a token signed with the secret before the switch still verifies
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testAuthSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(legacy, keys.Keyfunc, jwt.WithValidMethods(keys.Algorithms())); err != nil {
+		t.Errorf("legacy HS256 token rejected: %v", err)
+	}
+}
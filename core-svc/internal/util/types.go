@@ -24,4 +24,68 @@ var (
 	ErrLastAdmin          = errors.New("cannot demote or delete the last admin")
 	ErrInsufficientPerms  = errors.New("insufficient permissions")
 	ErrPollNotFound       = errors.New("poll not found")
+	ErrInvalidRefresh     = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionRevoked     = errors.New("session revoked")
+	ErrInvalidAccessToken = errors.New("invalid or expired access token")
+	ErrInvalidScope       = errors.New("invalid token scope")
+	ErrTokenNotFound      = errors.New("token not found")
+
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this account")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTooManyTwoFactorCodes   = errors.New("too many invalid two-factor codes, please sign in again")
+
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrOIDCStateMismatch    = errors.New("oidc state mismatch")
+	ErrOIDCInvalidToken     = errors.New("invalid oidc id token")
+	ErrOIDCEmailMissing     = errors.New("identity provider did not return an email")
+	ErrOIDCEmailNotVerified = errors.New("identity provider email is not verified")
+	ErrIdentityNotFound     = errors.New("identity not found")
+
+	ErrInvalidMagicLink         = errors.New("invalid or expired sign-in link")
+	ErrMagicLinkBrowserMismatch = errors.New("sign-in link was requested from a different browser")
+
+	ErrAccountLocked        = errors.New("account temporarily locked")
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
+	ErrInvalidUnlockToken   = errors.New("invalid or expired unlock link")
+
+	ErrPasswordPolicy = errors.New("password does not meet the password policy")
+
+	ErrInvalidEmailChange     = errors.New("invalid or expired email change link")
+	ErrEmailUnchanged         = errors.New("new email is the same as the current one")
+	ErrEmailChangeRateLimited = errors.New("too many email change requests, please try again later")
+
+	ErrDeletionNotScheduled = errors.New("account deletion not scheduled")
+	ErrRecentSignInRequired = errors.New("confirm with your password or sign in again")
+
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleInUse         = errors.New("role is assigned to users")
+	ErrBuiltInRole       = errors.New("built-in roles cannot be changed")
+	ErrInvalidPermission = errors.New("invalid permission")
+
+	ErrAccountSuspended  = errors.New("account suspended")
+	ErrNotSuspended      = errors.New("account not suspended")
+	ErrCannotSuspendSelf = errors.New("cannot suspend your own account")
+
+	ErrCannotImpersonate = errors.New("this user cannot be impersonated")
+	ErrNotImpersonating  = errors.New("not impersonating a user")
+
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+
+	ErrEmailNotFound      = errors.New("email not found")
+	ErrEmailNotFailed     = errors.New("only failed emails can be retried")
+	ErrInvalidEmailStatus = errors.New("invalid email status")
+
+	ErrUnknownNotificationCategory = errors.New("unknown notification category")
+	ErrInvalidUnsubscribeToken     = errors.New("invalid or expired unsubscribe link")
+
+	ErrNotPollOwner         = errors.New("not the owner of this poll")
+	ErrInvalidReminderHours = errors.New("invalid reminder time")
+	ErrPollReminderNotFound = errors.New("poll reminder not found")
+	ErrTooManyInvitees      = errors.New("too many invitees")
+	ErrPollInviteeNotFound  = errors.New("poll invitee not found")
 )
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -12,9 +12,14 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yatochka-dev/pollex/core-svc/internal/controllers"
 	"github.com/yatochka-dev/pollex/core-svc/internal/db/repository"
+	"github.com/yatochka-dev/pollex/core-svc/internal/mailer"
+	"github.com/yatochka-dev/pollex/core-svc/internal/middleware"
 	"github.com/yatochka-dev/pollex/core-svc/internal/pubsub"
+	"github.com/yatochka-dev/pollex/core-svc/internal/ratelimit"
 	"github.com/yatochka-dev/pollex/core-svc/internal/service"
+	"github.com/yatochka-dev/pollex/core-svc/internal/telemetry"
 	"github.com/yatochka-dev/pollex/core-svc/internal/util"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 var ctx = context.Background()
@@ -35,6 +40,9 @@ func mustPool(ctx context.Context, config *util.Config) *pgxpool.Pool {
 	// this fixes the "conn busy" errors
 	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
 
+	// one span per query, named after the sqlc query
+	cfg.ConnConfig.Tracer = telemetry.NewQueryTracer()
+
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		panic(err)
@@ -45,6 +53,27 @@ func mustPool(ctx context.Context, config *util.Config) *pgxpool.Pool {
 func main() {
 	util.LoadEnvironment()
 	config := util.NewConfig()
+	util.SetupLogger(config)
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	util.SetupPasswordHashing(config)
+	util.SetupPasswordPolicy(config)
+	if err := util.SetupSigningKeys(config); err != nil {
+		panic(err)
+	}
+
+	shutdownTracing, err := telemetry.Setup(ctx, config)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("failed to shut down tracing", "error", err)
+		}
+	}()
 
 	pool := mustPool(ctx, config)
 	repo := repository.New(pool)
@@ -52,17 +81,24 @@ func main() {
 	// disable gin's logging - we use custom logger
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
+	// let gin.Context fall back to the request context (request ID, user ID)
+	r.ContextWithFallback = true
+	// c.ClientIP() keys login protection and rate limits, so forwarded headers are only
+	// believed from the configured proxies
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		panic(fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
 
-	// recovery middleware with colors
+	// tracing runs first so the request span covers everything below,
+	// then request ID so everything can log with both
+	r.Use(otelgin.Middleware(config.OtelServiceName))
+	r.Use(middleware.RequestID())
+
+	// recovery middleware
 	r.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		log.Printf("%s[PANIC]%s %s%s %s%s | recovered=%v",
-			util.ColorRed+util.ColorBold,
-			util.ColorReset,
-			util.ColorYellow,
-			c.Request.Method,
-			util.ColorReset+util.ColorGray,
-			c.FullPath(),
-			recovered,
+		util.Logger(c.Request.Context()).Error("panic recovered",
+			"method", c.Request.Method,
+			"recovered", recovered,
 		)
 		c.AbortWithStatus(500)
 	}))
@@ -71,40 +107,87 @@ func main() {
 	corsCfg := cors.Config{
 		AllowOrigins:     config.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", util.RequestIDHeader, middleware.CSRFHeaderName},
+		ExposeHeaders:    []string{"Content-Length", util.RequestIDHeader, "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
-		MaxAge:           time.Duration(config.AuthTokenLifespanHours) * time.Hour,
+		MaxAge:           12 * time.Hour,
 	}
 
 	r.Use(cors.New(corsCfg))
 
-	log.Printf("%s[SERVER]%s Starting Pollex API on %s:8080%s",
-		util.ColorCyan+util.ColorBold,
-		util.ColorReset+util.ColorGreen,
-		util.ColorYellow,
-		util.ColorReset,
-	)
+	// after CORS so preflight requests are answered before the CSRF check
+	r.Use(middleware.CSRF(config))
+
+	slog.Info("starting Pollex API", "port", config.Port, "environment", config.Environment)
 
 	// setup deps
 	broker := pubsub.NewBroker()
 
+	// nil disables rate limiting
+	var limiter *ratelimit.Limiter
+	if config.RateLimitEnabled {
+		if config.RateLimitStore == "postgres" {
+			limiter = ratelimit.New(ratelimit.NewPostgresStore(repo))
+		} else {
+			limiter = ratelimit.New(ratelimit.NewMemoryStore())
+		}
+	}
+
 	// services
 	voteSvc := service.NewVotingService(repo, broker)
-	emailSvc := service.NewEmailService(repo, config.ResendAPIKey)
+	mail, err := mailer.New(config)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("mail driver configured", "driver", config.MailDriver)
+
+	emailSvc := service.NewEmailService(repo, config)
+	outboxSvc := service.NewEmailOutboxService(repo, mail)
+	accountSvc := service.NewAccountService(repo, emailSvc)
+	pollNotificationSvc := service.NewPollNotificationService(repo, emailSvc)
 	// pollSvc := service.NewPollService(repo) // TODO: Use this for poll lifecycle features
 
+	// delivers queued emails, retrying failed sends with backoff
+	go outboxSvc.RunWorker(ctx, 5*time.Second)
+
+	// deletes accounts once their deletion grace period is over
+	go accountSvc.RunDeletionWorker(ctx, time.Hour)
+
+	// closes expired polls, emails results of closed polls and reminds invitees
+	go pollNotificationSvc.RunWorker(ctx, time.Minute)
+
 	// register routes
-	controllers.RegisterAuthRoutes(r, repo, config, emailSvc)
+	controllers.RegisterJWKSRoutes(r)
+
+	controllers.RegisterAuthRoutes(r, repo, config, emailSvc, limiter)
+
+	controllers.RegisterSessionRoutes(r, repo, config)
 
-	controllers.RegisterPollsRoutes(r, repo, config, emailSvc)
+	controllers.RegisterPersonalAccessTokenRoutes(r, repo)
 
-	controllers.RegisterVoteRoutes(r, voteSvc, broker)
+	controllers.RegisterTwoFactorRoutes(r, repo)
+
+	controllers.RegisterOIDCRoutes(r, repo, config, emailSvc)
+
+	controllers.RegisterPollsRoutes(r, repo, config, emailSvc, limiter)
+	controllers.RegisterPollNotificationRoutes(r, repo, pollNotificationSvc)
+
+	controllers.RegisterVoteRoutes(r, voteSvc, broker, limiter)
 
 	controllers.RegisterAdminRoutes(r, repo, config)
 
+	controllers.RegisterRoleRoutes(r, repo)
+	controllers.RegisterEmailTemplateRoutes(r, repo, emailSvc)
+	controllers.RegisterEmailOutboxRoutes(r, repo, outboxSvc)
+
 	controllers.RegisterEmailRoutes(r, repo, emailSvc)
 
+	controllers.RegisterEmailChangeRoutes(r, repo, emailSvc)
+
+	controllers.RegisterAccountRoutes(r, repo, emailSvc)
+
+	controllers.RegisterNotificationRoutes(r, repo, config)
+
 	if err := r.Run(fmt.Sprintf(":%d", config.Port)); err != nil {
 		panic(err)
 	}
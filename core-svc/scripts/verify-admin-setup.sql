@@ -21,15 +21,14 @@ WHERE table_name = 'app_user'
 
 \echo ''
 
--- 2. Check if user_role enum exists
-\echo '2. Checking user_role enum type...'
+-- 2. Check the roles
+\echo '2. Checking app_role table...'
 SELECT
-    t.typname as enum_name,
-    e.enumlabel as enum_value
-FROM pg_type t
-JOIN pg_enum e ON t.oid = e.enumtypid
-WHERE t.typname = 'user_role'
-ORDER BY e.enumsortorder;
+    name,
+    built_in,
+    permissions
+FROM app_role
+ORDER BY built_in DESC, name;
 
 \echo ''
 
@@ -146,7 +145,7 @@ WHERE tc.constraint_type = 'FOREIGN KEY'
 \echo ''
 \echo 'Expected results:'
 \echo '  ✓ app_user has role and email_verified_at columns'
-\echo '  ✓ user_role enum exists with values: user, admin'
+\echo '  ✓ app_role has the built-in roles: user, moderator, admin'
 \echo '  ✓ audit_log table exists with all required columns'
 \echo '  ✓ Indexes exist on role and audit log fields'
 \echo '  ✓ At least one admin user exists'